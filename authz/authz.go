@@ -24,15 +24,21 @@ import (
 	stringadapter "github.com/qiangmzsx/string-adapter/v2"
 )
 
-var Enforcer *casbin.Enforcer
+var Enforcer *casbin.SyncedEnforcer
 
 func InitApi() {
-	e, err := object.GetInitializedEnforcer(util.GetId("built-in", "api-enforcer-built-in"))
+	enforcerId := util.GetId("built-in", "api-enforcer-built-in")
+	e, err := object.GetInitializedSyncedEnforcer(enforcerId)
+	if err != nil {
+		panic(err)
+	}
+
+	Enforcer = e
+	err = object.WatchEnforcer(enforcerId, Enforcer)
 	if err != nil {
 		panic(err)
 	}
 
-	Enforcer = e.Enforcer
 	Enforcer.ClearPolicy()
 
 	// if len(Enforcer.GetPolicy()) == 0 {
@@ -43,6 +49,8 @@ p, app, *, *, *, *, *
 p, *, *, POST, /api/signup, *, *
 p, *, *, GET, /api/get-email-and-phone, *, *
 p, *, *, POST, /api/login, *, *
+p, *, *, POST, /api/send-magic-link, *, *
+p, *, *, GET, /api/login/magic-link, *, *
 p, *, *, GET, /api/get-app-login, *, *
 p, *, *, POST, /api/logout, *, *
 p, *, *, GET, /api/logout, *, *
@@ -60,7 +68,6 @@ p, *, *, GET, /api/get-organization-applications, *, *
 p, *, *, GET, /api/get-user, *, *
 p, *, *, GET, /api/get-user-application, *, *
 p, *, *, GET, /api/get-resources, *, *
-p, *, *, GET, /api/get-records, *, *
 p, *, *, GET, /api/get-product, *, *
 p, *, *, POST, /api/buy-product, *, *
 p, *, *, GET, /api/get-payment, *, *
@@ -73,6 +80,21 @@ p, *, *, POST, /api/send-verification-code, *, *
 p, *, *, GET, /api/get-captcha, *, *
 p, *, *, POST, /api/verify-captcha, *, *
 p, *, *, POST, /api/verify-code, *, *
+p, *, *, POST, /api/recover-account, *, *
+p, *, *, POST, /api/complete-account-recovery, *, *
+p, *, *, GET, /api/get-security-questions, *, *
+p, *, *, POST, /api/set-security-questions, *, *
+p, *, *, GET, /api/get-signin-activities, *, *
+p, *, *, GET, /api/get-signin-devices, *, *
+p, *, *, POST, /api/revoke-signin-activity, *, *
+p, *, *, POST, /api/revoke-signin-device, *, *
+p, *, *, POST, /api/request-assignment, *, *
+p, *, *, GET, /api/get-pending-approval-requests, *, *
+p, *, *, POST, /api/submit-approval-request, *, *
+p, *, *, POST, /api/review-approval-request, *, *
+p, *, *, POST, /api/cancel-approval-request, *, *
+p, *, *, GET, /api/get-reviewer-access-review-items, *, *
+p, *, *, POST, /api/decide-access-review-item, *, *
 p, *, *, POST, /api/reset-email-or-phone, *, *
 p, *, *, POST, /api/upload-resource, *, *
 p, *, *, GET, /.well-known/openid-configuration, *, *
@@ -82,6 +104,7 @@ p, *, *, POST, /api/acs, *, *
 p, *, *, GET, /api/saml/metadata, *, *
 p, *, *, *, /cas, *, *
 p, *, *, *, /api/webauthn, *, *
+p, *, *, *, /scim, *, *
 p, *, *, GET, /api/get-release, *, *
 p, *, *, GET, /api/get-default-application, *, *
 p, *, *, GET, /api/get-prometheus-info, *, *
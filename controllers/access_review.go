@@ -0,0 +1,300 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetAccessReviews
+// @Title GetAccessReviews
+// @Tag Access Review API
+// @Description get the access review campaigns
+// @Param   owner     query    string  true        "The owner of access reviews"
+// @Success 200 {array} object.AccessReview The Response object
+// @router /get-access-reviews [get]
+func (c *ApiController) GetAccessReviews() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		accessReviews, err := object.GetAccessReviews(owner)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(accessReviews)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetAccessReviewCount(owner, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		accessReviews, err := object.GetPaginationAccessReviews(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(accessReviews, paginator.Nums())
+	}
+}
+
+// GetAccessReview
+// @Title GetAccessReview
+// @Tag Access Review API
+// @Description get an access review campaign
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review"
+// @Success 200 {object} object.AccessReview The Response object
+// @router /get-access-review [get]
+func (c *ApiController) GetAccessReview() {
+	id := c.Input().Get("id")
+
+	accessReview, err := object.GetAccessReview(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(accessReview)
+}
+
+// UpdateAccessReview
+// @Title UpdateAccessReview
+// @Tag Access Review API
+// @Description update an access review campaign
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review"
+// @Param   body    body   object.AccessReview  true        "The details of the access review"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-access-review [post]
+func (c *ApiController) UpdateAccessReview() {
+	id := c.Input().Get("id")
+
+	var accessReview object.AccessReview
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &accessReview)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateAccessReview(id, &accessReview, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddAccessReview
+// @Title AddAccessReview
+// @Tag Access Review API
+// @Description add an access review campaign, it's a draft until started
+// @Param   body    body   object.AccessReview  true        "The details of the access review"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-access-review [post]
+func (c *ApiController) AddAccessReview() {
+	var accessReview object.AccessReview
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &accessReview)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddAccessReview(&accessReview, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteAccessReview
+// @Title DeleteAccessReview
+// @Tag Access Review API
+// @Description delete an access review campaign and its items
+// @Param   body    body   object.AccessReview  true        "The details of the access review"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-access-review [post]
+func (c *ApiController) DeleteAccessReview() {
+	var accessReview object.AccessReview
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &accessReview)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteAccessReview(&accessReview))
+	c.ServeJSON()
+}
+
+// StartAccessReview
+// @Title StartAccessReview
+// @Tag Access Review API
+// @Description generate the items of an access review campaign and assign them to the reviewers
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review"
+// @Success 200 {object} controllers.Response The Response object
+// @router /start-access-review [post]
+func (c *ApiController) StartAccessReview() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = wrapActionResponse(object.StartAccessReview(id, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// CloseAccessReview
+// @Title CloseAccessReview
+// @Tag Access Review API
+// @Description close an access review campaign and apply the revocations
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review"
+// @Success 200 {object} controllers.Response The Response object
+// @router /close-access-review [post]
+func (c *ApiController) CloseAccessReview() {
+	id := c.Input().Get("id")
+
+	c.Data["json"] = wrapActionResponse(object.CloseAccessReview(id, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// ExportAccessReview
+// @Title ExportAccessReview
+// @Tag Access Review API
+// @Description download the results of an access review campaign as an xlsx file
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review"
+// @Success 200 {file} file The xlsx file
+// @router /export-access-review [get]
+func (c *ApiController) ExportAccessReview() {
+	id := c.Input().Get("id")
+
+	accessReview, err := object.GetAccessReview(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if accessReview == nil {
+		c.ResponseError(fmt.Sprintf(c.T("accessReview:The access review: %s does not exist"), id))
+		return
+	}
+
+	data, err := object.ExportAccessReview(accessReview)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"access-review-%s.xlsx\"", accessReview.Name))
+	err = c.Ctx.Output.Body(data)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+}
+
+// GetAccessReviewItems
+// @Title GetAccessReviewItems
+// @Tag Access Review API
+// @Description get the items of an access review campaign
+// @Param   owner     query    string  true        "The owner of the access review"
+// @Param   review     query    string  true        "The name of the access review"
+// @Success 200 {array} object.AccessReviewItem The Response object
+// @router /get-access-review-items [get]
+func (c *ApiController) GetAccessReviewItems() {
+	owner := c.Input().Get("owner")
+	review := c.Input().Get("review")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		accessReviewItems, err := object.GetAccessReviewItems(owner, review)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(accessReviewItems)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetAccessReviewItemCount(owner, review, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		accessReviewItems, err := object.GetPaginationAccessReviewItems(owner, review, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(accessReviewItems, paginator.Nums())
+	}
+}
+
+// GetReviewerAccessReviewItems
+// @Title GetReviewerAccessReviewItems
+// @Tag Access Review API
+// @Description get the undecided access review items assigned to the signed-in user
+// @Success 200 {array} object.AccessReviewItem The Response object
+// @router /get-reviewer-access-review-items [get]
+func (c *ApiController) GetReviewerAccessReviewItems() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	accessReviewItems, err := object.GetReviewerAccessReviewItems(user)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(accessReviewItems)
+}
+
+// DecideAccessReviewItem
+// @Title DecideAccessReviewItem
+// @Tag Access Review API
+// @Description certify or revoke an access review item, the decision of the body is "Certified" or "Revoked"
+// @Param   body    body   object.AccessReviewItem  true        "The owner, name, decision and comment of the item"
+// @Success 200 {object} controllers.Response The Response object
+// @router /decide-access-review-item [post]
+func (c *ApiController) DecideAccessReviewItem() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var accessReviewItem object.AccessReviewItem
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &accessReviewItem)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	id := util.GetId(accessReviewItem.Owner, accessReviewItem.Name)
+	c.Data["json"] = wrapActionResponse(object.DecideAccessReviewItem(id, user, accessReviewItem.Decision, accessReviewItem.Comment, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -35,12 +35,13 @@ const (
 )
 
 type Response struct {
-	Status string      `json:"status"`
-	Msg    string      `json:"msg"`
-	Sub    string      `json:"sub"`
-	Name   string      `json:"name"`
-	Data   interface{} `json:"data"`
-	Data2  interface{} `json:"data2"`
+	Status  string      `json:"status"`
+	Msg     string      `json:"msg"`
+	Sub     string      `json:"sub"`
+	Name    string      `json:"name"`
+	Data    interface{} `json:"data"`
+	Data2   interface{} `json:"data2"`
+	TraceId string      `json:"traceId,omitempty"`
 }
 
 type Captcha struct {
@@ -77,7 +78,7 @@ func (c *ApiController) Signup() {
 		return
 	}
 
-	application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+	application, err := object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -88,7 +89,7 @@ func (c *ApiController) Signup() {
 		return
 	}
 
-	organization, err := object.GetOrganization(util.GetId("admin", authForm.Organization))
+	organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", authForm.Organization))
 	if err != nil {
 		c.ResponseError(c.T(err.Error()))
 		return
@@ -101,7 +102,7 @@ func (c *ApiController) Signup() {
 	}
 
 	if application.IsSignupItemVisible("Email") && application.GetSignupItemRule("Email") != "No verification" && authForm.Email != "" {
-		checkResult := object.CheckVerificationCode(authForm.Email, authForm.EmailCode, c.GetAcceptLanguage())
+		checkResult := object.CheckVerificationCode(authForm.Email, authForm.EmailCode, SignupVerification, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
 			c.ResponseError(checkResult.Msg)
 			return
@@ -111,7 +112,7 @@ func (c *ApiController) Signup() {
 	var checkPhone string
 	if application.IsSignupItemVisible("Phone") && application.GetSignupItemRule("Phone") != "No verification" && authForm.Phone != "" {
 		checkPhone, _ = util.GetE164Number(authForm.Phone, authForm.CountryCode)
-		checkResult := object.CheckVerificationCode(checkPhone, authForm.PhoneCode, c.GetAcceptLanguage())
+		checkResult := object.CheckVerificationCode(checkPhone, authForm.PhoneCode, SignupVerification, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
 			c.ResponseError(checkResult.Msg)
 			return
@@ -207,13 +208,13 @@ func (c *ApiController) Signup() {
 		c.SetSessionUsername(user.GetId())
 	}
 
-	err = object.DisableVerificationCode(authForm.Email)
+	err = object.DisableVerificationCode(authForm.Email, SignupVerification)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
 	}
 
-	err = object.DisableVerificationCode(checkPhone)
+	err = object.DisableVerificationCode(checkPhone, SignupVerification)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -223,6 +224,7 @@ func (c *ApiController) Signup() {
 	record.Organization = application.Organization
 	record.User = user.Name
 	util.SafeGoroutine(func() { object.AddRecord(record) })
+	util.SafeGoroutine(func() { object.AddSignupStats(application.Organization, application.Name) })
 
 	userId := user.GetId()
 	util.LogInfo(c.Ctx, "API: [%s] is signed up as new user", userId)
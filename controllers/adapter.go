@@ -102,7 +102,13 @@ func (c *ApiController) UpdateAdapter() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateAdapter(id, &adapter))
+	affected, err := c.updateWithRecordDiff(id, adapter.GetId(), func(id string) (interface{}, error) {
+		return object.GetAdapter(id)
+	}, func() (bool, error) {
+		return object.UpdateAdapter(id, &adapter)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
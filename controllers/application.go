@@ -84,7 +84,7 @@ func (c *ApiController) GetApplication() {
 	userId := c.GetSessionUsername()
 	id := c.Input().Get("id")
 
-	application, err := object.GetApplication(id)
+	application, err := object.GetApplication(c.Ctx.Request.Context(), id)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -213,7 +213,13 @@ func (c *ApiController) UpdateApplication() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateApplication(id, &application))
+	affected, err := c.updateWithRecordDiff(id, application.GetId(), func(id string) (interface{}, error) {
+		return object.GetApplication(c.Ctx.Request.Context(), id)
+	}, func() (bool, error) {
+		return object.UpdateApplication(id, &application)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -0,0 +1,187 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetApprovalRequests
+// @Title GetApprovalRequests
+// @Tag Approval API
+// @Description get the approval requests of an organization
+// @Param   owner     query    string  true        "The owner of approval requests"
+// @Success 200 {array} object.ApprovalRequest The Response object
+// @router /get-approval-requests [get]
+func (c *ApiController) GetApprovalRequests() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		approvalRequests, err := object.GetApprovalRequests(owner)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(approvalRequests)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetApprovalRequestCount(owner, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		approvalRequests, err := object.GetPaginationApprovalRequests(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(approvalRequests, paginator.Nums())
+	}
+}
+
+// GetPendingApprovalRequests
+// @Title GetPendingApprovalRequests
+// @Tag Approval API
+// @Description get the approval requests waiting for the signed-in user
+// @Success 200 {array} object.ApprovalRequest The Response object
+// @router /get-pending-approval-requests [get]
+func (c *ApiController) GetPendingApprovalRequests() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	approvalRequests, err := object.GetPendingApprovalRequests(user)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(approvalRequests)
+}
+
+// GetApprovalRequest
+// @Title GetApprovalRequest
+// @Tag Approval API
+// @Description get an approval request
+// @Param   id     query    string  true        "The id ( owner/name ) of the approval request"
+// @Success 200 {object} object.ApprovalRequest The Response object
+// @router /get-approval-request [get]
+func (c *ApiController) GetApprovalRequest() {
+	id := c.Input().Get("id")
+
+	approvalRequest, err := object.GetApprovalRequest(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(approvalRequest)
+}
+
+// SubmitApprovalRequest
+// @Title SubmitApprovalRequest
+// @Tag Approval API
+// @Description request the approval of a permission submitted by the signed-in user, or request a role for them
+// @Param   body    body   object.ApprovalRequest  true        "The type, target and reason of the request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /submit-approval-request [post]
+func (c *ApiController) SubmitApprovalRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var approvalRequest object.ApprovalRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &approvalRequest)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	approvalRequest.Name = util.GenerateId()
+	approvalRequest.CreatedTime = util.GetCurrentTime()
+	c.Data["json"] = wrapActionResponse(object.SubmitApprovalRequest(&approvalRequest, user, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// ReviewApprovalRequest
+// @Title ReviewApprovalRequest
+// @Tag Approval API
+// @Description approve or reject the current step of an approval request, the state of the body is "Approved" or "Rejected"
+// @Param   body    body   object.ApprovalRequest  true        "The owner, name, state and message of the approval request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /review-approval-request [post]
+func (c *ApiController) ReviewApprovalRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var approvalRequest object.ApprovalRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &approvalRequest)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if approvalRequest.State != object.ApprovalStateApproved && approvalRequest.State != object.ApprovalStateRejected {
+		c.ResponseError(fmt.Sprintf(c.T("approval:Unknown state: %s"), approvalRequest.State))
+		return
+	}
+
+	approved := approvalRequest.State == object.ApprovalStateApproved
+	c.Data["json"] = wrapActionResponse(object.ReviewApprovalRequest(approvalRequest.GetId(), user, approved, approvalRequest.Message, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// CancelApprovalRequest
+// @Title CancelApprovalRequest
+// @Tag Approval API
+// @Description withdraw a pending approval request of the signed-in user
+// @Param   body    body   object.ApprovalRequest  true        "The owner and name of the approval request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /cancel-approval-request [post]
+func (c *ApiController) CancelApprovalRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var approvalRequest object.ApprovalRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &approvalRequest)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.CancelApprovalRequest(approvalRequest.GetId(), user, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetAssignments
+// @Title GetAssignments
+// @Tag Assignment API
+// @Description get the time-bound role and permission assignments
+// @Param   owner     query    string  true        "The owner of assignments"
+// @Success 200 {array} object.Assignment The Response object
+// @router /get-assignments [get]
+func (c *ApiController) GetAssignments() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		assignments, err := object.GetAssignments(owner)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(assignments)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetAssignmentCount(owner, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		assignments, err := object.GetPaginationAssignments(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(assignments, paginator.Nums())
+	}
+}
+
+// GetAssignment
+// @Title GetAssignment
+// @Tag Assignment API
+// @Description get an assignment
+// @Param   id     query    string  true        "The id ( owner/name ) of the assignment"
+// @Success 200 {object} object.Assignment The Response object
+// @router /get-assignment [get]
+func (c *ApiController) GetAssignment() {
+	id := c.Input().Get("id")
+
+	assignment, err := object.GetAssignment(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(assignment)
+}
+
+// AddAssignment
+// @Title AddAssignment
+// @Tag Assignment API
+// @Description assign a role or a permission to a user or a group for a time window
+// @Param   body    body   object.Assignment  true        "The details of the assignment"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-assignment [post]
+func (c *ApiController) AddAssignment() {
+	var assignment object.Assignment
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &assignment)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	assignment.Name = util.GenerateId()
+	assignment.CreatedTime = util.GetCurrentTime()
+	assignment.Requester = c.GetSessionUsername()
+	c.Data["json"] = wrapActionResponse(object.AddAssignment(&assignment, c.GetSessionUsername(), c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// RequestAssignment
+// @Title RequestAssignment
+// @Tag Assignment API
+// @Description request a just-in-time role or permission for the signed-in user, it takes effect once approved
+// @Param   body    body   object.Assignment  true        "The target, the time window and the reason of the request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /request-assignment [post]
+func (c *ApiController) RequestAssignment() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var assignment object.Assignment
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &assignment)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	assignment.Name = util.GenerateId()
+	assignment.CreatedTime = util.GetCurrentTime()
+	c.Data["json"] = wrapActionResponse(object.RequestAssignment(&assignment, user, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// ReviewAssignment
+// @Title ReviewAssignment
+// @Tag Assignment API
+// @Description approve or reject a requested assignment, the state of the body is "Approved" or "Rejected"
+// @Param   body    body   object.Assignment  true        "The owner, name, state and message of the assignment"
+// @Success 200 {object} controllers.Response The Response object
+// @router /review-assignment [post]
+func (c *ApiController) ReviewAssignment() {
+	var assignment object.Assignment
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &assignment)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if assignment.State != object.AssignmentStateApproved && assignment.State != object.AssignmentStateRejected {
+		c.ResponseError(fmt.Sprintf(c.T("assignment:Unknown state: %s"), assignment.State))
+		return
+	}
+
+	approved := assignment.State == object.AssignmentStateApproved
+	c.Data["json"] = wrapActionResponse(object.ReviewAssignment(assignment.GetId(), c.GetSessionUsername(), approved, assignment.Message, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// RevokeAssignment
+// @Title RevokeAssignment
+// @Tag Assignment API
+// @Description end an assignment before its end time
+// @Param   body    body   object.Assignment  true        "The owner, name and message of the assignment"
+// @Success 200 {object} controllers.Response The Response object
+// @router /revoke-assignment [post]
+func (c *ApiController) RevokeAssignment() {
+	var assignment object.Assignment
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &assignment)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RevokeAssignment(assignment.GetId(), assignment.Message, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
@@ -25,6 +26,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/casdoor/casdoor/captcha"
 	"github.com/casdoor/casdoor/conf"
@@ -32,8 +34,10 @@ import (
 	"github.com/casdoor/casdoor/idp"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/proxy"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -60,7 +64,7 @@ func tokenToResponse(token *object.Token) *Response {
 func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *form.AuthForm) (resp *Response) {
 	userId := user.GetId()
 
-	allowed, err := object.CheckLoginPermission(userId, application)
+	allowed, err := object.CheckLoginPermission(userId, application, c.getAbacContext(""))
 	if err != nil {
 		c.ResponseError(err.Error(), nil)
 		return
@@ -70,6 +74,9 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		return
 	}
 
+	// the signed-in user is added to the login stats by observeLogin()
+	c.Ctx.Input.SetData("signinUser", user)
+
 	// check user's tag
 	if !user.IsGlobalAdmin() && !user.IsAdmin && len(application.Tags) > 0 {
 		// only users with the tag that is listed in the application tags can login
@@ -137,7 +144,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			c.ResponseError(c.T("auth:Challenge method should be S256"))
 			return
 		}
-		code, err := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.GetAcceptLanguage())
+		code, err := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.Ctx.Input.CruSession.SessionID(), c.GetAcceptLanguage())
 		if err != nil {
 			c.ResponseError(err.Error(), nil)
 			return
@@ -154,7 +161,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: grant_type: %s is not supported in this application", form.Type), Data: ""}
 		} else {
 			scope := c.Input().Get("scope")
-			token, _ := object.GetTokenByUser(application, user, scope, c.Ctx.Request.Host)
+			token, _ := object.GetTokenByUser(application, user, scope, c.Ctx.Request.Host, c.Ctx.Input.CruSession.SessionID())
 			resp = tokenToResponse(token)
 		}
 	} else if form.Type == ResponseTypeSaml { // saml flow
@@ -206,6 +213,8 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			c.ResponseError(err.Error(), nil)
 			return
 		}
+
+		c.addSigninActivity(application, user, form)
 	}
 
 	return resp
@@ -241,7 +250,7 @@ func (c *ApiController) GetApplicationLogin() {
 			return
 		}
 	} else if loginType == "cas" {
-		application, err = object.GetApplication(id)
+		application, err = object.GetApplication(c.Ctx.Request.Context(), id)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -266,11 +275,12 @@ func (c *ApiController) GetApplicationLogin() {
 	}
 }
 
-func setHttpClient(idProvider idp.IdProvider, providerType string) {
+// setHttpClient gives the provider a client whose requests are traced as the children of the span in ctx
+func setHttpClient(ctx context.Context, idProvider idp.IdProvider, providerType string) {
 	if isProxyProviderType(providerType) {
-		idProvider.SetHttpClient(proxy.ProxyHttpClient)
+		idProvider.SetHttpClient(telemetry.WithParent(proxy.ProxyHttpClient, ctx))
 	} else {
-		idProvider.SetHttpClient(proxy.DefaultHttpClient)
+		idProvider.SetHttpClient(telemetry.WithParent(proxy.DefaultHttpClient, ctx))
 	}
 }
 
@@ -297,6 +307,62 @@ func isProxyProviderType(providerType string) bool {
 	return false
 }
 
+// observeLogin counts the login by the response of Login(), linking a provider to the signed-in user isn't a login
+func (c *ApiController) observeLogin(authForm *form.AuthForm) {
+	resp, ok := c.Data["json"].(*Response)
+	if !ok || (authForm.Provider != "" && authForm.Method != "signup") {
+		return
+	}
+
+	result := "failure"
+	if resp.Status == "ok" {
+		result = "success"
+		if resp.Data == object.NextMfa || resp.Data == object.RequiredMfa {
+			result = "mfa_required"
+		}
+	}
+
+	provider := c.getSigninProvider(authForm)
+
+	// the names come from the request, only an existing organization and application are used as label values so
+	// that made-up names can't use up the label limit
+	organization, application := "", ""
+	if authForm.Application != "" {
+		app, err := object.GetApplication(c.Ctx.Request.Context(), util.GetId("admin", authForm.Application))
+		if err == nil && app != nil {
+			organization, application = app.Organization, app.Name
+		}
+	}
+	if authForm.Organization != "" && authForm.Organization != organization {
+		org, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", authForm.Organization))
+		if err == nil && org != nil {
+			organization = org.Name
+		}
+	}
+
+	object.ObserveLogin(organization, application, provider, result)
+
+	if result == "mfa_required" {
+		return
+	}
+
+	event := &object.LoginEvent{
+		Organization: organization,
+		Application:  authForm.Application,
+		User:         authForm.Username,
+		Provider:     provider,
+		ClientIp:     c.Ctx.Input.IP(),
+		MfaType:      getSigninMfaType(authForm),
+		IsSucceeded:  result == "success",
+		Time:         time.Now(),
+	}
+	if user, ok := c.Ctx.Input.GetData("signinUser").(*object.User); ok && event.IsSucceeded {
+		event.Organization = user.Owner
+		event.User = user.Name
+	}
+	util.SafeGoroutine(func() { object.AddLoginStats(event) })
+}
+
 // Login ...
 // @Title Login
 // @Tag Login API
@@ -322,6 +388,8 @@ func (c *ApiController) Login() {
 		return
 	}
 
+	defer c.observeLogin(&authForm)
+
 	if authForm.Username != "" {
 		if authForm.Type == ResponseTypeLogin {
 			if c.GetSessionUsername() != "" {
@@ -334,7 +402,7 @@ func (c *ApiController) Login() {
 		var msg string
 
 		if authForm.Password == "" {
-			if user, err = object.GetUserByFields(authForm.Organization, authForm.Username); err != nil {
+			if user, err = object.GetUserByFields(c.Ctx.Request.Context(), authForm.Organization, authForm.Username); err != nil {
 				c.ResponseError(err.Error(), nil)
 				return
 			} else if user == nil {
@@ -343,7 +411,7 @@ func (c *ApiController) Login() {
 			}
 
 			verificationCodeType := object.GetVerifyType(authForm.Username)
-			var checkDest string
+			checkDest := authForm.Username
 			if verificationCodeType == object.VerifyTypePhone {
 				authForm.CountryCode = user.GetCountryCode(authForm.CountryCode)
 				var ok bool
@@ -361,13 +429,13 @@ func (c *ApiController) Login() {
 			}
 
 			// disable the verification code
-			err := object.DisableVerificationCode(checkDest)
+			err := object.DisableVerificationCode(checkDest, LoginVerification)
 			if err != nil {
 				c.ResponseError(err.Error(), nil)
 				return
 			}
 		} else {
-			application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+			application, err := object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
 				c.ResponseError(err.Error(), nil)
 				return
@@ -405,7 +473,7 @@ func (c *ApiController) Login() {
 		if msg != "" {
 			resp = &Response{Status: "error", Msg: msg}
 		} else {
-			application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+			application, err := object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -430,6 +498,7 @@ func (c *ApiController) Login() {
 
 			if user.IsMfaEnabled() {
 				c.setMfaUserSession(user.GetId())
+				object.ObserveMfaChallenge(user.Owner, user.PreferredMfaType, "issued")
 				c.ResponseOk(object.NextMfa, user.GetPreferredMfaProps(true))
 				return
 			}
@@ -450,7 +519,7 @@ func (c *ApiController) Login() {
 				return
 			}
 		} else {
-			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+			application, err = object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -461,12 +530,12 @@ func (c *ApiController) Login() {
 			c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), authForm.Application))
 			return
 		}
-		organization, err := object.GetOrganization(util.GetId("admin", application.Organization))
+		organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", application.Organization))
 		if err != nil {
 			c.ResponseError(c.T(err.Error()))
 		}
 
-		provider, err := object.GetProvider(util.GetId("admin", authForm.Provider))
+		provider, err := object.GetProvider(c.Ctx.Request.Context(), util.GetId("admin", authForm.Provider))
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -495,15 +564,17 @@ func (c *ApiController) Login() {
 				return
 			}
 
-			setHttpClient(idProvider, provider.Type)
-
 			if authForm.State != conf.GetConfigString("authState") && authForm.State != application.Name {
 				c.ResponseError(fmt.Sprintf(c.T("auth:State expected: %s, but got: %s"), conf.GetConfigString("authState"), authForm.State))
 				return
 			}
 
+			ctx, span := telemetry.StartSpan(c.Ctx.Request.Context(), "idp.GetToken", attribute.String("provider.type", provider.Type))
+			setHttpClient(ctx, idProvider, provider.Type)
+
 			// https://github.com/golang/oauth2/issues/123#issuecomment-103715338
 			token, err := idProvider.GetToken(authForm.Code)
+			telemetry.EndSpan(span, err)
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -514,7 +585,10 @@ func (c *ApiController) Login() {
 				return
 			}
 
+			ctx, span = telemetry.StartSpan(c.Ctx.Request.Context(), "idp.GetUserInfo", attribute.String("provider.type", provider.Type))
+			setHttpClient(ctx, idProvider, provider.Type)
 			userInfo, err = idProvider.GetUserInfo(token)
+			telemetry.EndSpan(span, err)
 			if err != nil {
 				c.ResponseError(fmt.Sprintf(c.T("auth:Failed to login in: %s"), err.Error()))
 				return
@@ -530,7 +604,7 @@ func (c *ApiController) Login() {
 					return
 				}
 			} else if provider.Category == "OAuth" || provider.Category == "Web3" {
-				user, err = object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
+				user, err = object.GetUserByField(c.Ctx.Request.Context(), application.Organization, provider.Type, userInfo.Id)
 				if err != nil {
 					c.ResponseError(err.Error())
 					return
@@ -555,7 +629,7 @@ func (c *ApiController) Login() {
 				if application.EnableLinkWithEmail {
 					if userInfo.Email != "" {
 						// Find existing user with Email
-						user, err = object.GetUserByField(application.Organization, "email", userInfo.Email)
+						user, err = object.GetUserByField(c.Ctx.Request.Context(), application.Organization, "email", userInfo.Email)
 						if err != nil {
 							c.ResponseError(err.Error())
 							return
@@ -564,7 +638,7 @@ func (c *ApiController) Login() {
 
 					if user == nil && userInfo.Phone != "" {
 						// Find existing user with phone number
-						user, err = object.GetUserByField(application.Organization, "phone", userInfo.Phone)
+						user, err = object.GetUserByField(c.Ctx.Request.Context(), application.Organization, "phone", userInfo.Phone)
 						if err != nil {
 							c.ResponseError(err.Error())
 							return
@@ -651,6 +725,8 @@ func (c *ApiController) Login() {
 						c.ResponseError(fmt.Sprintf(c.T("auth:Failed to create user, user information is invalid: %s"), util.StructToJson(user)))
 						return
 					}
+
+					util.SafeGoroutine(func() { object.AddSignupStats(application.Organization, application.Name) })
 				}
 
 				// sync info from 3rd-party if possible
@@ -689,7 +765,7 @@ func (c *ApiController) Login() {
 				return
 			}
 
-			oldUser, err := object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
+			oldUser, err := object.GetUserByField(c.Ctx.Request.Context(), application.Organization, provider.Type, userInfo.Id)
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -745,21 +821,25 @@ func (c *ApiController) Login() {
 
 			err = mfaUtil.Verify(authForm.Passcode)
 			if err != nil {
+				object.ObserveMfaChallenge(user.Owner, authForm.MfaType, "failure")
 				c.ResponseError(err.Error())
 				return
 			}
+			object.ObserveMfaChallenge(user.Owner, authForm.MfaType, "success")
 		} else if authForm.RecoveryCode != "" {
 			err = object.MfaRecover(user, authForm.RecoveryCode)
 			if err != nil {
+				object.ObserveMfaChallenge(user.Owner, "recovery", "failure")
 				c.ResponseError(err.Error())
 				return
 			}
+			object.ObserveMfaChallenge(user.Owner, "recovery", "success")
 		} else {
 			c.ResponseError("missing passcode or recovery code")
 			return
 		}
 
-		application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+		application, err := object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -780,7 +860,7 @@ func (c *ApiController) Login() {
 	} else {
 		if c.GetSessionUsername() != "" {
 			// user already signed in to Casdoor, so let the user click the avatar button to do the quick sign-in
-			application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+			application, err := object.GetApplication(c.Ctx.Request.Context(), fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -890,7 +970,7 @@ func (c *ApiController) GetWebhookEventType() {
 func (c *ApiController) GetCaptchaStatus() {
 	organization := c.Input().Get("organization")
 	userId := c.Input().Get("user_id")
-	user, err := object.GetUserByFields(organization, userId)
+	user, err := object.GetUserByFields(c.Ctx.Request.Context(), organization, userId)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
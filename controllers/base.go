@@ -22,6 +22,8 @@ import (
 	"github.com/beego/beego/logs"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ApiController
@@ -233,6 +235,20 @@ func wrapErrorResponse(err error) *Response {
 }
 
 func (c *ApiController) Finish() {
+	switch resp := c.Data["json"].(type) {
+	case *Response:
+		c.Ctx.Input.SetData("responseStatus", resp.Status)
+		c.Ctx.Input.SetData("responseMsg", resp.Msg)
+	case Response:
+		c.Ctx.Input.SetData("responseStatus", resp.Status)
+		c.Ctx.Input.SetData("responseMsg", resp.Msg)
+	}
+
+	if responseStatus, ok := c.Ctx.Input.GetData("responseStatus").(string); ok && responseStatus == "error" {
+		msg, _ := c.Ctx.Input.GetData("responseMsg").(string)
+		trace.SpanFromContext(c.Ctx.Request.Context()).SetStatus(codes.Error, msg)
+	}
+
 	if strings.HasPrefix(c.Ctx.Input.URL(), "/api") {
 		startTime := c.Ctx.Input.GetData("startTime")
 		if startTime != nil {
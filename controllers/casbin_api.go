@@ -16,11 +16,28 @@ package controllers
 
 import (
 	"encoding/json"
+	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
 
+// getAbacContext returns the context exposed to the models in the ABAC mode. The application backend calling the
+// enforce APIs should give the ip of its end user, otherwise it is the first one of the forwarded chain, which the
+// client controls unless a reverse proxy in front of Casdoor overwrites the X-Forwarded-For header
+func (c *ApiController) getAbacContext(ip string) *object.AbacContext {
+	if ip == "" {
+		ip = strings.Split(util.GetIPFromRequest(c.Ctx.Request), " -> ")[0]
+		ip = strings.TrimSuffix(ip, ": ")
+	}
+
+	return &object.AbacContext{
+		Ip:   ip,
+		Time: time.Now(),
+	}
+}
+
 // Enforce
 // @Title Enforce
 // @Tag Enforce API
@@ -29,6 +46,7 @@ import (
 // @Param   permissionId    query   string  false   "permission id"
 // @Param   modelId    query   string  false   "model id"
 // @Param   resourceId    query   string  false   "resource id"
+// @Param   ip    query   string  false   "The ip of the end user exposed to the models in the ABAC mode"
 // @Success 200 {object} controllers.Response The Response object
 // @router /enforce [post]
 func (c *ApiController) Enforce() {
@@ -73,7 +91,7 @@ func (c *ApiController) Enforce() {
 		if permission == nil {
 			res = append(res, false)
 		} else {
-			enforceResult, err := object.Enforce(permission, &request)
+			enforceResult, err := object.Enforce(permission, &request, c.getAbacContext(c.Input().Get("ip")))
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -115,7 +133,7 @@ func (c *ApiController) Enforce() {
 			return
 		}
 
-		enforceResult, err := object.Enforce(firstPermission, &request, permissionIds...)
+		enforceResult, err := object.Enforce(firstPermission, &request, c.getAbacContext(c.Input().Get("ip")), permissionIds...)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -134,6 +152,7 @@ func (c *ApiController) Enforce() {
 // @Param   body    body   object.CasbinRequest  true   "array of casbin requests"
 // @Param   permissionId    query   string  false   "permission id"
 // @Param   modelId    query   string  false   "model id"
+// @Param   ip    query   string  false   "The ip of the end user exposed to the models in the ABAC mode"
 // @Success 200 {object} controllers.Response The Response object
 // @router /batch-enforce [post]
 func (c *ApiController) BatchEnforce() {
@@ -183,7 +202,7 @@ func (c *ApiController) BatchEnforce() {
 
 			res = append(res, resRequest)
 		} else {
-			enforceResult, err := object.BatchEnforce(permission, &requests)
+			enforceResult, err := object.BatchEnforce(permission, &requests, c.getAbacContext(c.Input().Get("ip")))
 			if err != nil {
 				c.ResponseError(err.Error())
 				return
@@ -219,7 +238,7 @@ func (c *ApiController) BatchEnforce() {
 			return
 		}
 
-		enforceResult, err := object.BatchEnforce(firstPermission, &requests, permissionIds...)
+		enforceResult, err := object.BatchEnforce(firstPermission, &requests, c.getAbacContext(c.Input().Get("ip")), permissionIds...)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -260,3 +279,128 @@ func (c *ApiController) GetAllRoles() {
 
 	c.ResponseOk(object.GetAllRoles(userId))
 }
+
+// ExplainEnforce
+// @Title ExplainEnforce
+// @Tag Enforce API
+// @Description explain the decision of Casbin Enforce API: the deciding policy, every matching policy, the role chain and the groups of the subject
+// @Param   body    body   object.CasbinRequest  true   "Casbin request"
+// @Param   permissionId    query   string  false   "permission id"
+// @Param   modelId    query   string  false   "model id"
+// @Param   resourceId    query   string  false   "resource id"
+// @Param   enforcerId    query   string  false   "enforcer id"
+// @Param   ip    query   string  false   "The ip of the end user exposed to the models in the ABAC mode"
+// @Success 200 {array} object.EnforceExplanation The Response object
+// @router /explain-enforce [post]
+func (c *ApiController) ExplainEnforce() {
+	permissionId := c.Input().Get("permissionId")
+	modelId := c.Input().Get("modelId")
+	resourceId := c.Input().Get("resourceId")
+	enforcerId := c.Input().Get("enforcerId")
+
+	var request object.CasbinRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &request)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if enforcerId != "" {
+		enforcer, err := object.GetInitializedEnforcer(enforcerId)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		explanation, err := object.ExplainEnforcerEnforce(enforcer, &request)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk([]*object.EnforceExplanation{explanation})
+		return
+	}
+
+	permissions := []*object.Permission{}
+	if permissionId != "" {
+		permission, err := object.GetPermission(permissionId)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		if permission != nil {
+			permissions = append(permissions, permission)
+		}
+	} else if modelId != "" {
+		owner, modelName := util.GetOwnerAndNameFromId(modelId)
+		permissions, err = object.GetPermissionsByModel(owner, modelName)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	} else if resourceId != "" {
+		permissions, err = object.GetPermissionsByResource(resourceId)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	} else {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	res := []*object.EnforceExplanation{}
+
+	listPermissionIdMap := object.GroupPermissionsByModelAdapter(permissions)
+	for _, permissionIds := range listPermissionIdMap {
+		firstPermission, err := object.GetPermission(permissionIds[0])
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		explanation, err := object.ExplainPermissionEnforce(firstPermission, &request, c.getAbacContext(c.Input().Get("ip")), permissionIds...)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		res = append(res, explanation)
+	}
+
+	c.ResponseOk(res)
+}
+
+// SimulatePolicyChange
+// @Title SimulatePolicyChange
+// @Tag Enforce API
+// @Description dry-run a permission, role or model change against test requests or the recent enforce requests, nothing is saved
+// @Param   body    body   object.PolicySimulation  true   "The proposed change and the requests"
+// @Param   ip    query   string  false   "The ip of the end user exposed to the models in the ABAC mode"
+// @Success 200 {array} object.SimulationResult The Response object
+// @router /simulate-policy-change [post]
+func (c *ApiController) SimulatePolicyChange() {
+	var simulation object.PolicySimulation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &simulation)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	results, err := object.SimulatePolicyChange(&simulation, c.getAbacContext(c.Input().Get("ip")))
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	flippedCount := 0
+	for _, result := range results {
+		if result.IsFlipped {
+			flippedCount++
+		}
+	}
+
+	c.ResponseOk(results, flippedCount)
+}
@@ -142,7 +142,13 @@ func (c *ApiController) UpdateCert() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateCert(id, &cert))
+	affected, err := c.updateWithRecordDiff(id, cert.GetId(), func(id string) (interface{}, error) {
+		return object.GetCert(id)
+	}, func() (bool, error) {
+		return object.UpdateCert(id, &cert)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -111,7 +111,13 @@ func (c *ApiController) UpdateEnforcer() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateEnforcer(id, &enforcer))
+	affected, err := c.updateWithRecordDiff(id, enforcer.GetId(), func(id string) (interface{}, error) {
+		return object.GetEnforcer(id)
+	}, func() (bool, error) {
+		return object.UpdateEnforcer(id, &enforcer)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -0,0 +1,148 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetEventSinks
+// @Title GetEventSinks
+// @Tag Event Sink API
+// @Description get event sinks
+// @Param   owner     query    string  built-in/admin	true        "The owner of event sinks"
+// @Success 200 {array} object.EventSink The Response object
+// @router /get-event-sinks [get]
+// @Security test_apiKey
+func (c *ApiController) GetEventSinks() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	organization := c.Input().Get("organization")
+
+	if limit == "" || page == "" {
+		eventSinks, err := object.GetEventSinks(owner, organization)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(eventSinks)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetEventSinkCount(owner, organization, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+
+		eventSinks, err := object.GetPaginationEventSinks(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(eventSinks, paginator.Nums())
+	}
+}
+
+// GetEventSink
+// @Title GetEventSink
+// @Tag Event Sink API
+// @Description get event sink with its status in this replica
+// @Param   id     query    string  built-in/admin	true        "The id ( owner/name ) of the event sink"
+// @Success 200 {object} object.EventSink The Response object
+// @router /get-event-sink [get]
+func (c *ApiController) GetEventSink() {
+	id := c.Input().Get("id")
+
+	eventSink, err := object.GetEventSink(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(eventSink)
+}
+
+// UpdateEventSink
+// @Title UpdateEventSink
+// @Tag Event Sink API
+// @Description update event sink
+// @Param   id     query    string  built-in/admin true        "The id ( owner/name ) of the event sink"
+// @Param   body    body   object.EventSink  true        "The details of the event sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-event-sink [post]
+func (c *ApiController) UpdateEventSink() {
+	id := c.Input().Get("id")
+
+	var eventSink object.EventSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &eventSink)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateEventSink(id, &eventSink))
+	c.ServeJSON()
+}
+
+// AddEventSink
+// @Title AddEventSink
+// @Tag Event Sink API
+// @Description add event sink
+// @Param   body    body   object.EventSink  true        "The details of the event sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-event-sink [post]
+func (c *ApiController) AddEventSink() {
+	var eventSink object.EventSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &eventSink)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddEventSink(&eventSink))
+	c.ServeJSON()
+}
+
+// DeleteEventSink
+// @Title DeleteEventSink
+// @Tag Event Sink API
+// @Description delete event sink
+// @Param   body    body   object.EventSink  true        "The details of the event sink"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-event-sink [post]
+func (c *ApiController) DeleteEventSink() {
+	var eventSink object.EventSink
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &eventSink)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteEventSink(&eventSink))
+	c.ServeJSON()
+}
@@ -14,7 +14,12 @@
 
 package controllers
 
-import "github.com/casdoor/casdoor/object"
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
 
 // GetDashboard
 // @Title GetDashboard
@@ -33,3 +38,38 @@ func (c *ApiController) GetDashboard() {
 
 	c.ResponseOk(data)
 }
+
+// GetDashboardStats
+// @Title GetDashboardStats
+// @Tag GetDashboard API
+// @Description get the series of active users, signups, logins, MFA adoption, providers and countries of an organization
+// @Param   owner     query    string  true        "The owner of the stats"
+// @Param   application     query    string  false        "The application of the stats, all applications if empty"
+// @Param   granularity     query    string  false        "day or month, day by default"
+// @Param   count     query    int  false        "The count of the days or months, 30 by default"
+// @Success 200 {object} object.DashboardStats The Response object
+// @router /get-dashboard-stats [get]
+func (c *ApiController) GetDashboardStats() {
+	owner := c.Input().Get("owner")
+	application := c.Input().Get("application")
+	granularity := c.Input().Get("granularity")
+	count := c.Input().Get("count")
+
+	if granularity != "" && granularity != "day" && granularity != "month" {
+		c.ResponseError(fmt.Sprintf("the granularity: %s is not supported", granularity))
+		return
+	}
+
+	periodCount := 30
+	if count != "" {
+		periodCount = util.ParseInt(count)
+	}
+
+	stats, err := object.GetDashboardStats(owner, application, granularity, periodCount)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(stats)
+}
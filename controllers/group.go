@@ -105,7 +105,13 @@ func (c *ApiController) UpdateGroup() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateGroup(id, &group))
+	affected, err := c.updateWithRecordDiff(id, group.GetId(), func(id string) (interface{}, error) {
+		return object.GetGroup(id)
+	}, func() (bool, error) {
+		return object.UpdateGroup(id, &group)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -0,0 +1,209 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// SendMagicLink
+// @Title SendMagicLink
+// @Tag Login API
+// @Description send a single-use sign-in link to the user's email, the OAuth parameters of the query are kept for the login
+// @Param   form   body   form.AuthForm  true        "Login information, username is the email"
+// @Success 200 {object} controllers.Response The Response object
+// @router /send-magic-link [post]
+func (c *ApiController) SendMagicLink() {
+	var authForm form.AuthForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &authForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if authForm.Type != ResponseTypeLogin && authForm.Type != ResponseTypeCode {
+		c.ResponseError(fmt.Sprintf("unknown response type: %s", authForm.Type))
+		return
+	}
+
+	if authForm.Type == ResponseTypeLogin && c.GetSessionUsername() != "" {
+		c.ResponseError(c.T("account:Please sign out first"), c.GetSessionUsername())
+		return
+	}
+
+	application, err := object.GetApplication(c.Ctx.Request.Context(), util.GetId("admin", authForm.Application))
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), authForm.Application))
+		return
+	}
+	if !application.EnableMagicLink {
+		c.ResponseError(c.T("auth:The login method: login with magic link is not enabled for the application"))
+		return
+	}
+
+	organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", application.Organization))
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if organization == nil {
+		c.ResponseError(c.T("check:Organization does not exist"))
+		return
+	}
+
+	if !util.IsEmailValid(authForm.Username) {
+		c.ResponseError(c.T("check:Email is invalid"))
+		return
+	}
+
+	user, err := object.GetUserByEmail(organization.Name, authForm.Username)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if user == nil || user.IsDeleted {
+		c.ResponseError(c.T("verification:the user does not exist, please sign up first"))
+		return
+	}
+	if user.IsForbidden {
+		c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+		return
+	}
+	// the link completes the sign-in by itself, so it can't be used as a first factor before MFA
+	if user.IsMfaEnabled() || object.IsNeedPromptMfa(organization, user) {
+		c.ResponseError(c.T("auth:The login method: login with magic link is not available for users with multi-factor authentication"))
+		return
+	}
+
+	provider, err := application.GetEmailProvider()
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	authForm.Password = ""
+	authForm.Code = ""
+	authFormBytes, err := json.Marshal(authForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	remoteAddr := util.GetIPFromRequest(c.Ctx.Request)
+	sessionId := c.Ctx.Input.CruSession.SessionID()
+	err = object.SendMagicLinkToEmail(c.Ctx.Request.Context(), organization, application, user, provider, remoteAddr, sessionId, c.Ctx.Request.Host, string(authFormBytes), c.Ctx.Request.URL.RawQuery)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// MagicLinkLogin
+// @Title MagicLinkLogin
+// @Tag Login API
+// @Description sign in with a magic link sent by /api/send-magic-link and redirect to the application
+// @Param   id          query    string  true        "The id ( owner/name ) of the magic link"
+// @Param   expires     query    string  true        "The expire time of the magic link"
+// @Param   signature   query    string  true        "The signature of the magic link"
+// @Success 302
+// @router /login/magic-link [get]
+func (c *ApiController) MagicLinkLogin() {
+	id := c.Input().Get("id")
+	expires := c.Input().Get("expires")
+	signature := c.Input().Get("signature")
+
+	magicLink, application, err := object.ConsumeMagicLink(id, expires, signature, c.Ctx.Input.CruSession.SessionID(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	user, err := object.GetUser(magicLink.User)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if user == nil || user.IsDeleted {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), magicLink.User))
+		return
+	}
+	if user.IsForbidden {
+		c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+		return
+	}
+
+	var authForm form.AuthForm
+	err = json.Unmarshal([]byte(magicLink.AuthForm), &authForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	// HandleLoggedIn reads the OAuth parameters from the request, so restore the ones of the original login
+	query, err := url.ParseQuery(magicLink.Query)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	c.Ctx.Request.Form = query
+
+	c.Ctx.Input.SetData("signinProvider", "magic-link")
+	resp := c.HandleLoggedIn(application, user, &authForm)
+	if resp == nil {
+		return
+	}
+
+	record := object.NewRecord(c.Ctx)
+	record.Organization = application.Organization
+	record.User = user.Name
+	util.SafeGoroutine(func() { object.AddRecord(record) })
+
+	if resp.Status != "ok" {
+		c.ResponseError(resp.Msg)
+		return
+	}
+
+	redirectUrl := application.HomepageUrl
+	if authForm.Type == ResponseTypeCode {
+		redirectUri, err := url.Parse(query.Get("redirectUri"))
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		params := redirectUri.Query()
+		params.Set("code", resp.Data.(string))
+		params.Set("state", query.Get("state"))
+		redirectUri.RawQuery = params.Encode()
+		redirectUrl = redirectUri.String()
+	}
+	if redirectUrl == "" {
+		redirectUrl = "/"
+	}
+
+	c.Ctx.Redirect(302, redirectUrl)
+}
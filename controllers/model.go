@@ -102,7 +102,13 @@ func (c *ApiController) UpdateModel() {
 		return
 	}
 
-	c.Data["json"] = wrapErrorResponse(object.UpdateModelWithCheck(id, &model))
+	_, err = c.updateWithRecordDiff(id, model.GetId(), func(id string) (interface{}, error) {
+		return object.GetModel(id)
+	}, func() (bool, error) {
+		return true, object.UpdateModelWithCheck(id, &model)
+	})
+
+	c.Data["json"] = wrapErrorResponse(err)
 	c.ServeJSON()
 }
 
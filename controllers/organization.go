@@ -93,7 +93,7 @@ func (c *ApiController) GetOrganizations() {
 // @router /get-organization [get]
 func (c *ApiController) GetOrganization() {
 	id := c.Input().Get("id")
-	maskedOrganization, err := object.GetMaskedOrganization(object.GetOrganization(id))
+	maskedOrganization, err := object.GetMaskedOrganization(object.GetOrganization(c.Ctx.Request.Context(), id))
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -120,7 +120,13 @@ func (c *ApiController) UpdateOrganization() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateOrganization(id, &organization))
+	affected, err := c.updateWithRecordDiff(id, util.GetId(organization.Owner, organization.Name), func(id string) (interface{}, error) {
+		return object.GetOrganization(c.Ctx.Request.Context(), id)
+	}, func() (bool, error) {
+		return object.UpdateOrganization(id, &organization)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -141,7 +141,13 @@ func (c *ApiController) UpdatePermission() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdatePermission(id, &permission))
+	affected, err := c.updateWithRecordDiff(id, permission.GetId(), func(id string) (interface{}, error) {
+		return object.GetPermission(id)
+	}, func() (bool, error) {
+		return object.UpdatePermission(id, &permission)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
@@ -0,0 +1,132 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetPolicyVersions
+// @Title GetPolicyVersions
+// @Tag Policy Version API
+// @Description get the versions of a model, an adapter, an enforcer or a permission
+// @Param   owner     query    string  true        "The owner of the versions"
+// @Param   objectType     query    string  false        "Model, Adapter, Enforcer or Permission"
+// @Param   objectId     query    string  false        "The id ( owner/name ) of the versioned object"
+// @Success 200 {array} object.PolicyVersion The Response object
+// @router /get-policy-versions [get]
+func (c *ApiController) GetPolicyVersions() {
+	owner := c.Input().Get("owner")
+	objectType := c.Input().Get("objectType")
+	objectId := c.Input().Get("objectId")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		versions, err := object.GetPolicyVersions(owner, objectType, objectId)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(versions)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetPolicyVersionCount(owner, objectType, objectId, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		versions, err := object.GetPaginationPolicyVersions(owner, objectType, objectId, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(versions, paginator.Nums())
+	}
+}
+
+// GetPolicyVersion
+// @Title GetPolicyVersion
+// @Tag Policy Version API
+// @Description get a policy version
+// @Param   id     query    string  true        "The id ( owner/name ) of the version"
+// @Success 200 {object} object.PolicyVersion The Response object
+// @router /get-policy-version [get]
+func (c *ApiController) GetPolicyVersion() {
+	id := c.Input().Get("id")
+
+	version, err := object.GetPolicyVersion(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(version)
+}
+
+// DiffPolicyVersions
+// @Title DiffPolicyVersions
+// @Tag Policy Version API
+// @Description get the lines added and removed between two versions of the same object
+// @Param   from     query    string  true        "The id ( owner/name ) of the older version"
+// @Param   to     query    string  true        "The id ( owner/name ) of the newer version"
+// @Success 200 {object} object.PolicyVersionDiff The Response object
+// @router /diff-policy-versions [get]
+func (c *ApiController) DiffPolicyVersions() {
+	from := c.Input().Get("from")
+	to := c.Input().Get("to")
+	if from == "" || to == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	diff, err := object.DiffPolicyVersions(from, to)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(diff)
+}
+
+// RollbackEnforcer
+// @Title RollbackEnforcer
+// @Tag Policy Version API
+// @Description replace all the policies of an enforcer with the policies of one of its versions
+// @Param   id     query    string  true        "The id ( owner/name ) of the enforcer"
+// @Param   version     query    string  true        "The id ( owner/name ) of the version"
+// @Success 200 {object} controllers.Response The Response object
+// @router /rollback-enforcer [post]
+func (c *ApiController) RollbackEnforcer() {
+	id := c.Input().Get("id")
+	versionId := c.Input().Get("version")
+	if id == "" || versionId == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RollbackEnforcer(id, versionId, c.GetSessionUsername()))
+	c.ServeJSON()
+}
@@ -132,7 +132,7 @@ func (c *ApiController) GetProvider() {
 	if !ok {
 		return
 	}
-	provider, err := object.GetProvider(id)
+	provider, err := object.GetProvider(c.Ctx.Request.Context(), id)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -159,7 +159,13 @@ func (c *ApiController) UpdateProvider() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateProvider(id, &provider))
+	affected, err := c.updateWithRecordDiff(id, provider.GetId(), func(id string) (interface{}, error) {
+		return object.GetProvider(c.Ctx.Request.Context(), id)
+	}, func() (bool, error) {
+		return object.UpdateProvider(id, &provider)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
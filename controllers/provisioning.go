@@ -0,0 +1,97 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetProvisioningTasks
+// @Title GetProvisioningTasks
+// @Tag Provisioning API
+// @Description get the provisioning tasks of an organization, they are the status and error log of the downstream applications
+// @Param   owner     query    string  true        "The owner of provisioning tasks"
+// @Param   application     query    string  false        "The name of the application"
+// @Success 200 {array} object.ProvisioningTask The Response object
+// @router /get-provisioning-tasks [get]
+func (c *ApiController) GetProvisioningTasks() {
+	owner := c.Input().Get("owner")
+	application := c.Input().Get("application")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		provisioningTasks, err := object.GetProvisioningTasks(owner, application)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(provisioningTasks)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetProvisioningTaskCount(owner, application, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		provisioningTasks, err := object.GetPaginationProvisioningTasks(owner, application, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(provisioningTasks, paginator.Nums())
+	}
+}
+
+// ReconcileProvisioning
+// @Title ReconcileProvisioning
+// @Tag Provisioning API
+// @Description queue a full reconciliation of the users of the application with its downstream SCIM service
+// @Param   id     query    string  true        "The id ( owner/name ) of the application"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reconcile-provisioning [post]
+func (c *ApiController) ReconcileProvisioning() {
+	id := c.Input().Get("id")
+
+	application, err := object.GetApplication(c.Ctx.Request.Context(), id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), id))
+		return
+	}
+
+	upsertCount, deleteCount, err := object.ReconcileProvisioning(application)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(upsertCount, deleteCount)
+}
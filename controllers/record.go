@@ -0,0 +1,249 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// setRecordDiff keeps the fields changed by an update in the record of the request. The new object is read right
+// after the update in the request, because some fields of the body are not saved
+func (c *ApiController) setRecordDiff(oldObject interface{}, newObject interface{}, err error) {
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the updated object of %s, error: %s", c.Ctx.Request.URL.Path, err.Error()))
+		return
+	}
+
+	changes := object.GetRecordDiff(oldObject, newObject)
+	if len(changes) != 0 {
+		c.Ctx.Input.SetData("recordDiff", util.StructToJson(changes))
+	}
+}
+
+// updateWithRecordDiff gets the object before and after the update, and keeps the fields changed by the update in
+// the record of the request. The id of the object can be changed by the update, so the new one is read with newId
+func (c *ApiController) updateWithRecordDiff(id string, newId string, get func(id string) (interface{}, error), update func() (bool, error)) (bool, error) {
+	oldObject, err := get(id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := update()
+	if err == nil && affected {
+		newObject, err := get(newId)
+		c.setRecordDiff(oldObject, newObject, err)
+	}
+	return affected, err
+}
+
+func (c *ApiController) getRecordFilter() *object.RecordFilter {
+	return &object.RecordFilter{
+		User:      c.Input().Get("user"),
+		Action:    c.Input().Get("action"),
+		Type:      c.Input().Get("type"),
+		ClientIp:  c.Input().Get("clientIp"),
+		Result:    c.Input().Get("result"),
+		StartTime: c.Input().Get("startTime"),
+		EndTime:   c.Input().Get("endTime"),
+	}
+}
+
+// GetRecords
+// @Title GetRecords
+// @Tag Record API
+// @Description get the records stored in Casdoor
+// @Param   owner     query    string  true        "The organization of the records"
+// @Param   user     query    string  false        "The name of the user"
+// @Param   action     query    string  false        "The action, like update-user"
+// @Param   type     query    string  false        "The event type, like user.updated or login.failed"
+// @Param   clientIp     query    string  false        "The client IP"
+// @Param   result     query    string  false        "The result: ok or error"
+// @Param   startTime     query    string  false        "The start of the created time, in RFC3339"
+// @Param   endTime     query    string  false        "The end of the created time, in RFC3339"
+// @Success 200 {array} object.Record The Response object
+// @router /get-records [get]
+func (c *ApiController) GetRecords() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	filter := c.getRecordFilter()
+
+	if limit == "" || page == "" {
+		records, err := object.GetRecords(owner, filter)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(records)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetRecordCount(owner, field, value, filter)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		records, err := object.GetPaginationRecords(owner, paginator.Offset(), limit, field, value, sortField, sortOrder, filter)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(records, paginator.Nums())
+	}
+}
+
+// GetRecord
+// @Title GetRecord
+// @Tag Record API
+// @Description get a record
+// @Param   id     query    string  true        "The id ( owner/name ) of the record"
+// @Success 200 {object} object.Record The Response object
+// @router /get-record [get]
+func (c *ApiController) GetRecord() {
+	id := c.Input().Get("id")
+
+	record, err := object.GetRecord(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(record)
+}
+
+// GetRecordCheckpoints
+// @Title GetRecordCheckpoints
+// @Tag Record API
+// @Description get the signed checkpoints of the record chain of an organization
+// @Param   owner     query    string  true        "The organization of the records"
+// @Success 200 {array} object.RecordCheckpoint The Response object
+// @router /get-record-checkpoints [get]
+func (c *ApiController) GetRecordCheckpoints() {
+	owner := c.Input().Get("owner")
+
+	checkpoints, err := object.GetRecordCheckpoints(owner)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(checkpoints)
+}
+
+// VerifyRecordChain
+// @Title VerifyRecordChain
+// @Tag Record API
+// @Description walk the record chain of an organization and report the modified, missing and unchained records
+// @Param   owner     query    string  true        "The organization of the records"
+// @Success 200 {object} object.RecordChainReport The Response object
+// @router /verify-record-chain [get]
+func (c *ApiController) VerifyRecordChain() {
+	owner := c.Input().Get("owner")
+
+	report, err := object.VerifyRecordChain(owner)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// ExportRecords
+// @Title ExportRecords
+// @Tag Record API
+// @Description download the filtered records as a CSV or JSON file, the JSON file also has the signed checkpoints and their certificates
+// @Param   owner     query    string  true        "The organization of the records"
+// @Param   format     query    string  false        "csv (default) or json"
+// @Success 200 {file} file The exported records
+// @router /export-records [get]
+func (c *ApiController) ExportRecords() {
+	owner := c.Input().Get("owner")
+	format := c.Input().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	export, err := object.GetRecordExport(owner, c.getRecordFilter())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	var data []byte
+	switch format {
+	case "json":
+		data, err = json.MarshalIndent(export, "", "  ")
+		c.Ctx.Output.Header("Content-Type", "application/json")
+	case "csv":
+		data, err = getRecordsCsv(export.Records)
+		c.Ctx.Output.Header("Content-Type", "text/csv")
+	default:
+		c.ResponseError(fmt.Sprintf("unsupported format: %s", format))
+		return
+	}
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"records-%s-%s.%s\"", owner, time.Now().Format("20060102150405"), format))
+	err = c.Ctx.Output.Body(data)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+}
+
+func getRecordsCsv(records []*object.Record) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+
+	err := writer.Write([]string{"id", "owner", "name", "createdTime", "organization", "clientIp", "user", "method", "requestUri", "action", "type", "target", "result", "reason", "object", "diff", "isTriggered", "prevHash", "hash"})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		err = writer.Write([]string{
+			strconv.Itoa(record.Id), record.Owner, record.Name, record.CreatedTime, record.Organization, record.ClientIp, record.User,
+			record.Method, record.RequestUri, record.Action, record.Type, record.Target, record.Result, record.Reason, record.Object, record.Diff,
+			strconv.FormatBool(record.IsTriggered), record.PrevHash, record.Hash,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
@@ -0,0 +1,280 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// RecoverAccount
+// @Title RecoverAccount
+// @Tag Recovery API
+// @Description prove the ownership of an account with a recovery code, an email or phone code plus security questions, or file a request for an administrator to approve
+// @Param   form   body   form.RecoveryForm  true        "The recovery information"
+// @Success 200 {object} controllers.Response The Response object, data is the id of the recovery request and data2 is the token to complete it, the token of an admin recovery is emailed to the user once approved
+// @router /recover-account [post]
+func (c *ApiController) RecoverAccount() {
+	var recoveryForm form.RecoveryForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recoveryForm)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if recoveryForm.Organization == "" || recoveryForm.Username == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	user, err := object.GetUserByFields(c.Ctx.Request.Context(), recoveryForm.Organization, recoveryForm.Username)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+	if user == nil || user.IsDeleted {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(recoveryForm.Organization, recoveryForm.Username)))
+		return
+	}
+	if user.IsForbidden {
+		c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+		return
+	}
+
+	remoteAddr := util.GetIPFromRequest(c.Ctx.Request)
+
+	var recoveryRequest *object.RecoveryRequest
+	var token string
+	switch recoveryForm.Method {
+	case object.RecoveryMethodRecoveryCode:
+		recoveryRequest, token, err = object.RecoverByRecoveryCode(user, recoveryForm.RecoveryCode, remoteAddr, c.GetAcceptLanguage())
+	case object.RecoveryMethodProofs:
+		checkDest := recoveryForm.Dest
+		if !strings.Contains(checkDest, "@") {
+			var ok bool
+			if checkDest, ok = util.GetE164Number(recoveryForm.Dest, user.GetCountryCode(recoveryForm.CountryCode)); !ok {
+				c.ResponseError(fmt.Sprintf(c.T("verification:Phone number is invalid in your region %s"), recoveryForm.CountryCode))
+				return
+			}
+		}
+
+		recoveryRequest, token, err = object.RecoverByProofs(user, checkDest, recoveryForm.Code, recoveryForm.Answers, remoteAddr, c.GetAcceptLanguage())
+	case object.RecoveryMethodAdmin:
+		if strings.TrimSpace(recoveryForm.Reason) == "" {
+			c.ResponseError(c.T("general:Missing parameter"))
+			return
+		}
+
+		recoveryRequest, token, err = object.AddAdminRecoveryRequest(user, recoveryForm.Reason, recoveryForm.DisableMfa, remoteAddr)
+	default:
+		c.ResponseError(fmt.Sprintf(c.T("recovery:Unknown recovery method: %s"), recoveryForm.Method))
+		return
+	}
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(recoveryRequest.GetId(), token)
+}
+
+// CompleteAccountRecovery
+// @Title CompleteAccountRecovery
+// @Tag Recovery API
+// @Description set a new password with an approved recovery request, all the sessions and tokens of the user are revoked
+// @Param   id            formData    string  true        "The id ( owner/name ) of the recovery request"
+// @Param   token         formData    string  true        "The token returned by /api/recover-account or emailed to the user"
+// @Param   newPassword   formData    string  true        "The new password of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /complete-account-recovery [post]
+func (c *ApiController) CompleteAccountRecovery() {
+	id := c.Ctx.Request.Form.Get("id")
+	token := c.Ctx.Request.Form.Get("token")
+	newPassword := c.Ctx.Request.Form.Get("newPassword")
+
+	if strings.Contains(newPassword, " ") {
+		c.ResponseError(c.T("user:New password cannot contain blank space."))
+		return
+	}
+
+	err := object.CompleteRecovery(id, token, newPassword, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// GetSecurityQuestions
+// @Title GetSecurityQuestions
+// @Tag Recovery API
+// @Description get the security questions of a user without the answers, with a verification code sent to the email or phone of the user
+// @Param   organization   query    string  true        "The organization of the user"
+// @Param   username       query    string  true        "The username, email or phone of the user"
+// @Param   dest           query    string  true        "The email or phone that the verification code was sent to"
+// @Param   countryCode    query    string  false       "The country code of the phone"
+// @Param   code           query    string  true        "The verification code"
+// @Success 200 {array} string The Response object
+// @router /get-security-questions [get]
+func (c *ApiController) GetSecurityQuestions() {
+	organization := c.Input().Get("organization")
+	username := c.Input().Get("username")
+	dest := c.Input().Get("dest")
+	countryCode := c.Input().Get("countryCode")
+	code := c.Input().Get("code")
+
+	user, err := object.GetUserByFields(c.Ctx.Request.Context(), organization, username)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if user != nil && dest != "" && !strings.Contains(dest, "@") {
+		if phone, ok := util.GetE164Number(dest, user.GetCountryCode(countryCode)); ok {
+			dest = phone
+		}
+	}
+
+	questions, err := object.GetUserSecurityQuestions(user, dest, code, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(questions)
+}
+
+// SetSecurityQuestions
+// @Title SetSecurityQuestions
+// @Tag Recovery API
+// @Description set the security questions of the signed-in user
+// @Param   body    body   []object.SecurityQuestion  true        "The questions and their answers"
+// @Success 200 {object} controllers.Response The Response object
+// @router /set-security-questions [post]
+func (c *ApiController) SetSecurityQuestions() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var securityQuestions []*object.SecurityQuestion
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &securityQuestions)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	err = object.SetUserSecurityQuestions(user, securityQuestions, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// GetRecoveryRequests
+// @Title GetRecoveryRequests
+// @Tag Recovery API
+// @Description get the recovery requests of an organization
+// @Param   owner     query    string  true        "The owner of recovery requests"
+// @Success 200 {array} object.RecoveryRequest The Response object
+// @router /get-recovery-requests [get]
+func (c *ApiController) GetRecoveryRequests() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		recoveryRequests, err := object.GetRecoveryRequests(owner)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(recoveryRequests)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetRecoveryRequestCount(owner, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		recoveryRequests, err := object.GetPaginationRecoveryRequests(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(recoveryRequests, paginator.Nums())
+	}
+}
+
+// GetRecoveryRequest
+// @Title GetRecoveryRequest
+// @Tag Recovery API
+// @Description get a recovery request
+// @Param   id     query    string  true        "The id ( owner/name ) of the recovery request"
+// @Success 200 {object} object.RecoveryRequest The Response object
+// @router /get-recovery-request [get]
+func (c *ApiController) GetRecoveryRequest() {
+	id := c.Input().Get("id")
+
+	recoveryRequest, err := object.GetRecoveryRequest(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(recoveryRequest)
+}
+
+// ReviewRecoveryRequest
+// @Title ReviewRecoveryRequest
+// @Tag Recovery API
+// @Description approve or reject a pending recovery request, the state of the body is "Approved" or "Rejected"
+// @Param   body    body   object.RecoveryRequest  true        "The owner, name, state and message of the recovery request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /review-recovery-request [post]
+func (c *ApiController) ReviewRecoveryRequest() {
+	var recoveryRequest object.RecoveryRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recoveryRequest)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if recoveryRequest.State != object.RecoveryStateApproved && recoveryRequest.State != object.RecoveryStateRejected {
+		c.ResponseError(fmt.Sprintf(c.T("recovery:Unknown state: %s"), recoveryRequest.State))
+		return
+	}
+
+	approved := recoveryRequest.State == object.RecoveryStateApproved
+	c.Data["json"] = wrapActionResponse(object.ReviewRecoveryRequest(recoveryRequest.GetId(), c.GetSessionUsername(), approved, recoveryRequest.Message, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -178,7 +178,7 @@ func (c *ApiController) DeleteResource() {
 	}
 	_, resource.Name = refineFullFilePath(resource.Name)
 
-	err = object.DeleteFile(provider, resource.Name, c.GetAcceptLanguage())
+	err = object.DeleteFile(c.Ctx.Request.Context(), provider, resource.Name, c.GetAcceptLanguage())
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -266,7 +266,7 @@ func (c *ApiController) UploadResource() {
 		}
 	}
 
-	fileUrl, objectKey, err := object.UploadFileSafe(provider, fullFilePath, fileBuffer, c.GetAcceptLanguage())
+	fileUrl, objectKey, err := object.UploadFileSafe(c.Ctx.Request.Context(), provider, fullFilePath, fileBuffer, c.GetAcceptLanguage())
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -337,7 +337,7 @@ func (c *ApiController) UploadResource() {
 		}
 
 		_, applicationId := util.GetOwnerAndNameFromIdNoCheck(strings.TrimSuffix(fullFilePath, ".html"))
-		applicationObj, err := object.GetApplication(applicationId)
+		applicationObj, err := object.GetApplication(c.Ctx.Request.Context(), applicationId)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -102,7 +102,13 @@ func (c *ApiController) UpdateRole() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateRole(id, &role))
+	affected, err := c.updateWithRecordDiff(id, role.GetId(), func(id string) (interface{}, error) {
+		return object.GetRole(id)
+	}, func() (bool, error) {
+		return object.UpdateRole(id, &role)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
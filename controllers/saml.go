@@ -23,7 +23,7 @@ import (
 func (c *ApiController) GetSamlMeta() {
 	host := c.Ctx.Request.Host
 	paramApp := c.Input().Get("application")
-	application, err := object.GetApplication(paramApp)
+	application, err := object.GetApplication(c.Ctx.Request.Context(), paramApp)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -65,7 +65,7 @@ func (c *ApiController) SendEmail() {
 	var provider *object.Provider
 	if emailForm.Provider != "" {
 		// called by frontend's TestEmailWidget, provider name is set by frontend
-		provider, err = object.GetProvider(util.GetId("admin", emailForm.Provider))
+		provider, err = object.GetProvider(c.Ctx.Request.Context(), util.GetId("admin", emailForm.Provider))
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -111,7 +111,7 @@ func (c *ApiController) SendEmail() {
 	// "You have requested a verification code at Casdoor. Here is your code: %s, please enter in 5 minutes."
 	content := fmt.Sprintf(emailForm.Content, code)
 	for _, receiver := range emailForm.Receivers {
-		err = object.SendEmail(provider, emailForm.Title, content, receiver, emailForm.Sender)
+		err = object.SendEmail(c.Ctx.Request.Context(), provider, emailForm.Title, content, receiver, emailForm.Sender)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -152,7 +152,7 @@ func (c *ApiController) SendSms() {
 		}
 	}
 
-	err = object.SendSms(provider, smsForm.Content, smsForm.Receivers...)
+	err = object.SendSms(c.Ctx.Request.Context(), provider, smsForm.Content, smsForm.Receivers...)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
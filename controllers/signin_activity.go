@@ -0,0 +1,245 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const deviceCookieName = "casdoor_device_id"
+
+// getDeviceId returns the ID of the browser by its device cookie, the cookie is set if the browser doesn't have
+// one yet. The ID is the hash of the cookie, so it can be shown in the device list
+func (c *ApiController) getDeviceId(isCreated bool) string {
+	deviceCookie := c.Ctx.GetCookie(deviceCookieName)
+	if deviceCookie == "" {
+		if !isCreated {
+			return ""
+		}
+
+		deviceCookie = util.GenerateId()
+		c.Ctx.SetCookie(deviceCookieName, deviceCookie, 365*24*3600, "/", "", c.Ctx.Input.IsSecure(), true)
+	}
+	return util.GetSha256Hash(deviceCookie)
+}
+
+// getSigninProvider returns the provider of the sign-in, or how the user signed in without a provider. The sign-ins
+// that don't post the login form, like a magic link or WebAuthn, set "signinProvider" before HandleLoggedIn()
+func (c *ApiController) getSigninProvider(authForm *form.AuthForm) string {
+	if provider, ok := c.Ctx.Input.GetData("signinProvider").(string); ok {
+		return provider
+	}
+
+	if authForm.Provider != "" {
+		return authForm.Provider
+	} else if authForm.Passcode != "" || authForm.RecoveryCode != "" {
+		return "mfa"
+	} else if authForm.Password != "" {
+		return "password"
+	} else if authForm.Username != "" {
+		return "code"
+	}
+	return "session"
+}
+
+func getSigninMfaType(authForm *form.AuthForm) string {
+	if authForm.Passcode != "" {
+		return authForm.MfaType
+	} else if authForm.RecoveryCode != "" {
+		return "recovery"
+	}
+	return ""
+}
+
+// addSigninActivity records a sign-in of the user, it's called by HandleLoggedIn() so that every way to sign in
+// is recorded
+func (c *ApiController) addSigninActivity(application *object.Application, user *object.User, authForm *form.AuthForm) {
+	activity := &object.SigninActivity{
+		Owner:       user.Owner,
+		User:        user.Name,
+		Application: application.Name,
+		Provider:    c.getSigninProvider(authForm),
+		MfaType:     getSigninMfaType(authForm),
+		ClientIp:    c.Ctx.Input.IP(),
+		UserAgent:   c.Ctx.Request.UserAgent(),
+		SessionId:   c.Ctx.Input.CruSession.SessionID(),
+		DeviceId:    c.getDeviceId(true),
+	}
+	util.SafeGoroutine(func() {
+		_, err := object.AddSigninActivity(activity)
+		if err != nil {
+			util.LogWarning(c.Ctx, "failed to add the sign-in activity of user: %s, error: %s", user.GetId(), err.Error())
+		}
+	})
+}
+
+// getSigninActivityUser returns the user of "id", or the signed-in user if "id" is empty, only the admins can
+// get the sign-ins of the other users
+func (c *ApiController) getSigninActivityUser(id string) (*object.User, bool) {
+	if id == "" {
+		return c.RequireSignedInUser()
+	}
+
+	user, err := object.GetUser(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return nil, false
+	}
+
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return nil, false
+	}
+
+	if !c.IsAdminOrSelf(user) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+	return user, true
+}
+
+// GetSigninActivities
+// @Title GetSigninActivities
+// @Tag Signin Activity API
+// @Description get the recent sign-ins of a user, the signed-in user by default
+// @Param   id     query    string  false        "The id ( owner/name ) of the user"
+// @Param   pageSize     query    string  false        "The size of each page"
+// @Param   p     query    string  false        "The number of the page"
+// @Success 200 {array} object.SigninActivity The Response object
+// @router /get-signin-activities [get]
+func (c *ApiController) GetSigninActivities() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+
+	user, ok := c.getSigninActivityUser(id)
+	if !ok {
+		return
+	}
+
+	pageSize := 10
+	if limit != "" {
+		pageSize = util.ParseInt(limit)
+	}
+
+	count, err := object.GetSigninActivityCount(user.Owner, user.Name)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, pageSize, count)
+	activities, err := object.GetPaginationSigninActivities(user.Owner, user.Name, paginator.Offset(), pageSize)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	sessionId := c.Ctx.Input.CruSession.SessionID()
+	for _, activity := range activities {
+		activity.IsCurrent = !activity.IsRevoked && activity.SessionId == sessionId
+	}
+
+	c.ResponseOk(activities, paginator.Nums())
+}
+
+// GetSigninDevices
+// @Title GetSigninDevices
+// @Tag Signin Activity API
+// @Description get the devices that a user has signed in with, the signed-in user by default
+// @Param   id     query    string  false        "The id ( owner/name ) of the user"
+// @Success 200 {array} object.SigninDevice The Response object
+// @router /get-signin-devices [get]
+func (c *ApiController) GetSigninDevices() {
+	id := c.Input().Get("id")
+
+	user, ok := c.getSigninActivityUser(id)
+	if !ok {
+		return
+	}
+
+	devices, err := object.GetSigninDevices(user.Owner, user.Name)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	deviceId := c.getDeviceId(false)
+	for _, device := range devices {
+		device.IsCurrent = device.DeviceId == deviceId
+	}
+
+	c.ResponseOk(devices)
+}
+
+// RevokeSigninActivity
+// @Title RevokeSigninActivity
+// @Tag Signin Activity API
+// @Description sign out the session of a sign-in and delete the tokens issued in it
+// @Param   id     query    string  true        "The id ( owner/name ) of the sign-in"
+// @Success 200 {object} controllers.Response The Response object
+// @router /revoke-signin-activity [post]
+func (c *ApiController) RevokeSigninActivity() {
+	id := c.Input().Get("id")
+
+	activity, err := object.GetSigninActivity(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	if activity == nil {
+		c.ResponseError(fmt.Sprintf("the sign-in: %s doesn't exist", id))
+		return
+	}
+
+	_, ok := c.getSigninActivityUser(util.GetId(activity.Owner, activity.User))
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RevokeSigninActivity(activity))
+	c.ServeJSON()
+}
+
+// RevokeSigninDevice
+// @Title RevokeSigninDevice
+// @Tag Signin Activity API
+// @Description sign out all the sessions of a device of a user and delete the tokens issued in them, the signed-in user by default
+// @Param   id     query    string  false        "The id ( owner/name ) of the user"
+// @Param   deviceId     query    string  true        "The id of the device"
+// @Success 200 {object} controllers.Response The Response object
+// @router /revoke-signin-device [post]
+func (c *ApiController) RevokeSigninDevice() {
+	id := c.Input().Get("id")
+	deviceId := c.Input().Get("deviceId")
+
+	if deviceId == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	user, ok := c.getSigninActivityUser(id)
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RevokeSigninDevice(user.Owner, user.Name, deviceId))
+	c.ServeJSON()
+}
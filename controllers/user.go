@@ -164,7 +164,7 @@ func (c *ApiController) GetUser() {
 		owner = util.GetOwnerFromId(id)
 	}
 
-	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", owner))
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -288,6 +288,9 @@ func (c *ApiController) UpdateUser() {
 	}
 
 	if affected {
+		newUser, err := object.GetUser(user.GetId())
+		c.setRecordDiff(oldUser, newUser, err)
+
 		err = object.UpdateUserToOriginalDatabase(&user)
 		if err != nil {
 			c.ResponseError(err.Error())
@@ -371,7 +374,7 @@ func (c *ApiController) GetEmailAndPhone() {
 	organization := c.Ctx.Request.Form.Get("organization")
 	username := c.Ctx.Request.Form.Get("username")
 
-	user, err := object.GetUserByFields(organization, username)
+	user, err := object.GetUserByFields(c.Ctx.Request.Context(), organization, username)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -487,6 +490,15 @@ func (c *ApiController) SetPassword() {
 		return
 	}
 
+	// a password reset through the forget-password flow signs the user out everywhere
+	if code != "" {
+		err = object.RevokeUserSessionsAndTokens(targetUser)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+	}
+
 	c.ResponseOk()
 }
 
@@ -560,6 +572,25 @@ func (c *ApiController) GetUserCount() {
 	c.ResponseOk(count)
 }
 
+// GetUserPasswordTypeCounts
+// @Title GetUserPasswordTypeCounts
+// @Tag User API
+// @Description get how many users of an organization remain on each password hash type
+// @Param   owner     query    string  true        "The owner of users"
+// @Success 200 {array} object.PasswordTypeCount The Response object
+// @router /get-user-password-type-counts [get]
+func (c *ApiController) GetUserPasswordTypeCounts() {
+	owner := c.Input().Get("owner")
+
+	counts, err := object.GetUserPasswordTypeCounts(owner)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(counts)
+}
+
 // AddUserkeys
 // @Title AddUserkeys
 // @router /add-user-keys [post]
@@ -587,7 +618,7 @@ func (c *ApiController) RemoveUserFromGroup() {
 	name := c.Ctx.Request.Form.Get("name")
 	groupName := c.Ctx.Request.Form.Get("groupName")
 
-	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId("admin", owner))
 	if err != nil {
 		return
 	}
@@ -21,6 +21,7 @@ import (
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/i18n"
 	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
 )
 
@@ -37,6 +38,19 @@ func (c *ApiController) ResponseJsonData(resp *Response, data ...interface{}) {
 	c.ServeJSON()
 }
 
+// ServeJSON adds the trace ID to the error responses when the request is traced, so that a failed request can be
+// found in the traces
+func (c *ApiController) ServeJSON(encoding ...bool) {
+	if resp, ok := c.Data["json"].(*Response); ok && resp.Status == "error" {
+		resp.TraceId = telemetry.GetTraceId(c.Ctx.Request.Context())
+		if resp.TraceId != "" {
+			util.LogDebug(c.Ctx, "%s %s failed: %s", c.Ctx.Request.Method, c.Ctx.Request.URL.Path, resp.Msg)
+		}
+	}
+
+	c.Controller.ServeJSON(encoding...)
+}
+
 // ResponseOk ...
 func (c *ApiController) ResponseOk(data ...interface{}) {
 	resp := &Response{Status: "ok"}
@@ -169,7 +183,7 @@ func (c *ApiController) GetProviderFromContext(category string) (*object.Provide
 	}
 
 	if providerName != "" {
-		provider, err := object.GetProvider(util.GetId("admin", providerName))
+		provider, err := object.GetProvider(c.Ctx.Request.Context(), util.GetId("admin", providerName))
 		if err != nil {
 			return nil, err
 		}
@@ -27,12 +27,12 @@ import (
 )
 
 const (
-	SignupVerification   = "signup"
-	ResetVerification    = "reset"
-	LoginVerification    = "login"
-	ForgetVerification   = "forget"
-	MfaSetupVerification = "mfaSetup"
-	MfaAuthVerification  = "mfaAuth"
+	SignupVerification   = object.VerifyPurposeSignup
+	ResetVerification    = object.VerifyPurposeReset
+	LoginVerification    = object.VerifyPurposeLogin
+	ForgetVerification   = object.VerifyPurposeForget
+	MfaSetupVerification = object.VerifyPurposeMfaSetup
+	MfaAuthVerification  = object.VerifyPurposeMfaAuth
 )
 
 // SendVerificationCode ...
@@ -66,13 +66,13 @@ func (c *ApiController) SendVerificationCode() {
 		}
 	}
 
-	application, err := object.GetApplication(vform.ApplicationId)
+	application, err := object.GetApplication(c.Ctx.Request.Context(), vform.ApplicationId)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
 	}
 
-	organization, err := object.GetOrganization(util.GetId(application.Owner, application.Organization))
+	organization, err := object.GetOrganization(c.Ctx.Request.Context(), util.GetId(application.Owner, application.Organization))
 	if err != nil {
 		c.ResponseError(c.T(err.Error()))
 	}
@@ -143,7 +143,7 @@ func (c *ApiController) SendVerificationCode() {
 			return
 		}
 
-		sendResp = object.SendVerificationCodeToEmail(organization, user, provider, remoteAddr, vform.Dest)
+		sendResp = object.SendVerificationCodeToEmail(c.Ctx.Request.Context(), organization, user, provider, remoteAddr, vform.Dest, vform.Method)
 	case object.VerifyTypePhone:
 		if vform.Method == LoginVerification || vform.Method == ForgetVerification {
 			if user != nil && util.GetMaskedPhone(user.Phone) == vform.Dest {
@@ -189,7 +189,7 @@ func (c *ApiController) SendVerificationCode() {
 			c.ResponseError(fmt.Sprintf(c.T("verification:Phone number is invalid in your region %s"), vform.CountryCode))
 			return
 		} else {
-			sendResp = object.SendVerificationCodeToPhone(organization, user, provider, remoteAddr, phone)
+			sendResp = object.SendVerificationCodeToPhone(c.Ctx.Request.Context(), organization, user, provider, remoteAddr, phone, vform.Method)
 		}
 	}
 
@@ -296,7 +296,7 @@ func (c *ApiController) ResetEmailOrPhone() {
 		}
 	}
 
-	if result := object.CheckVerificationCode(checkDest, code, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
+	if result := object.CheckVerificationCode(checkDest, code, ResetVerification, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
 		c.ResponseError(result.Msg)
 		return
 	}
@@ -317,7 +317,7 @@ func (c *ApiController) ResetEmailOrPhone() {
 		return
 	}
 
-	err = object.DisableVerificationCode(checkDest)
+	err = object.DisableVerificationCode(checkDest, ResetVerification)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -340,7 +340,7 @@ func (c *ApiController) VerifyCode() {
 
 	var user *object.User
 	if authForm.Name != "" {
-		user, err = object.GetUserByFields(authForm.Organization, authForm.Name)
+		user, err = object.GetUserByFields(c.Ctx.Request.Context(), authForm.Organization, authForm.Name)
 		if err != nil {
 			c.ResponseError(err.Error())
 			return
@@ -359,7 +359,7 @@ func (c *ApiController) VerifyCode() {
 		}
 	}
 
-	if user, err = object.GetUserByFields(authForm.Organization, authForm.Username); err != nil {
+	if user, err = object.GetUserByFields(c.Ctx.Request.Context(), authForm.Organization, authForm.Username); err != nil {
 		c.ResponseError(err.Error())
 		return
 	} else if user == nil {
@@ -377,11 +377,11 @@ func (c *ApiController) VerifyCode() {
 		}
 	}
 
-	if result := object.CheckVerificationCode(checkDest, authForm.Code, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
+	if result := object.CheckVerificationCode(checkDest, authForm.Code, ForgetVerification, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
 		c.ResponseError(result.Msg)
 		return
 	}
-	err = object.DisableVerificationCode(checkDest)
+	err = object.DisableVerificationCode(checkDest, ForgetVerification)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
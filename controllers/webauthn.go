@@ -120,7 +120,7 @@ func (c *ApiController) WebAuthnSigninBegin() {
 
 	userOwner := c.Input().Get("owner")
 	userName := c.Input().Get("name")
-	user, err := object.GetUserByFields(userOwner, userName)
+	user, err := object.GetUserByFields(c.Ctx.Request.Context(), userOwner, userName)
 	if err != nil {
 		c.ResponseError(err.Error())
 		return
@@ -190,6 +190,7 @@ func (c *ApiController) WebAuthnSigninFinish() {
 
 	var authForm form.AuthForm
 	authForm.Type = responseType
+	c.Ctx.Input.SetData("signinProvider", "webauthn")
 	resp := c.HandleLoggedIn(application, user, &authForm)
 	c.Data["json"] = resp
 	c.ServeJSON()
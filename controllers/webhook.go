@@ -105,7 +105,13 @@ func (c *ApiController) UpdateWebhook() {
 		return
 	}
 
-	c.Data["json"] = wrapActionResponse(object.UpdateWebhook(id, &webhook))
+	affected, err := c.updateWithRecordDiff(id, webhook.GetId(), func(id string) (interface{}, error) {
+		return object.GetWebhook(id)
+	}, func() (bool, error) {
+		return object.UpdateWebhook(id, &webhook)
+	})
+
+	c.Data["json"] = wrapActionResponse(affected, err)
 	c.ServeJSON()
 }
 
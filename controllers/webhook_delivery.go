@@ -0,0 +1,104 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetWebhookDeliveries
+// @Title GetWebhookDeliveries
+// @Tag Webhook API
+// @Description get the deliveries of the webhooks, they are the delivery history with the response codes and latencies
+// @Param   owner     query    string  true        "The owner of the webhooks"
+// @Param   webhook     query    string  false        "The name of the webhook"
+// @Success 200 {array} object.WebhookDelivery The Response object
+// @router /get-webhook-deliveries [get]
+func (c *ApiController) GetWebhookDeliveries() {
+	owner := c.Input().Get("owner")
+	webhook := c.Input().Get("webhook")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		deliveries, err := object.GetWebhookDeliveries(owner, webhook)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(deliveries)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetWebhookDeliveryCount(owner, webhook, field, value)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		deliveries, err := object.GetPaginationWebhookDeliveries(owner, webhook, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseError(err.Error())
+			return
+		}
+
+		c.ResponseOk(deliveries, paginator.Nums())
+	}
+}
+
+// GetWebhookDelivery
+// @Title GetWebhookDelivery
+// @Tag Webhook API
+// @Description get a webhook delivery with its request body and response
+// @Param   id     query    string  true        "The id ( owner/name ) of the delivery"
+// @Success 200 {object} object.WebhookDelivery The Response object
+// @router /get-webhook-delivery [get]
+func (c *ApiController) GetWebhookDelivery() {
+	id := c.Input().Get("id")
+
+	delivery, err := object.GetWebhookDelivery(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(delivery)
+}
+
+// RedeliverWebhook
+// @Title RedeliverWebhook
+// @Tag Webhook API
+// @Description send the body of a delivery again as a new delivery
+// @Param   id     query    string  true        "The id ( owner/name ) of the delivery"
+// @Success 200 {object} object.WebhookDelivery The Response object
+// @router /redeliver-webhook [post]
+func (c *ApiController) RedeliverWebhook() {
+	id := c.Input().Get("id")
+
+	delivery, err := object.RedeliverWebhook(id)
+	if err != nil {
+		c.ResponseError(err.Error())
+		return
+	}
+
+	c.ResponseOk(delivery)
+}
@@ -14,17 +14,38 @@
 
 package cred
 
-import "github.com/alexedwards/argon2id"
+import (
+	"strconv"
 
-type Argon2idCredManager struct{}
+	"github.com/alexedwards/argon2id"
+	"github.com/casdoor/casdoor/conf"
+)
+
+type Argon2idCredManager struct {
+	params *argon2id.Params
+}
+
+func getArgon2idParams() *argon2id.Params {
+	params := *argon2id.DefaultParams
+	if memory, err := strconv.ParseUint(conf.GetConfigString("argon2idMemory"), 10, 32); err == nil && memory > 0 {
+		params.Memory = uint32(memory)
+	}
+	if iterations, err := strconv.ParseUint(conf.GetConfigString("argon2idIterations"), 10, 32); err == nil && iterations > 0 {
+		params.Iterations = uint32(iterations)
+	}
+	if parallelism, err := strconv.ParseUint(conf.GetConfigString("argon2idParallelism"), 10, 8); err == nil && parallelism > 0 {
+		params.Parallelism = uint8(parallelism)
+	}
+	return &params
+}
 
 func NewArgon2idCredManager() *Argon2idCredManager {
-	cm := &Argon2idCredManager{}
+	cm := &Argon2idCredManager{params: getArgon2idParams()}
 	return cm
 }
 
 func (cm *Argon2idCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(password, cm.params)
 	if err != nil {
 		return ""
 	}
@@ -35,3 +56,13 @@ func (cm *Argon2idCredManager) IsPasswordCorrect(plainPwd string, hashedPwd stri
 	match, _ := argon2id.ComparePasswordAndHash(plainPwd, hashedPwd)
 	return match
 }
+
+func (cm *Argon2idCredManager) IsRehashNeeded(hashedPwd string) bool {
+	params, _, _, err := argon2id.DecodeHash(hashedPwd)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < cm.params.Memory || params.Iterations < cm.params.Iterations || params.Parallelism < cm.params.Parallelism ||
+		params.SaltLength < cm.params.SaltLength || params.KeyLength < cm.params.KeyLength
+}
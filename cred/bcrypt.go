@@ -1,16 +1,31 @@
 package cred
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"strconv"
 
-type BcryptCredManager struct{}
+	"github.com/casdoor/casdoor/conf"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type BcryptCredManager struct {
+	cost int
+}
+
+func getBcryptCost() int {
+	cost, err := strconv.Atoi(conf.GetConfigString("bcryptCost"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
 
 func NewBcryptCredManager() *BcryptCredManager {
-	cm := &BcryptCredManager{}
+	cm := &BcryptCredManager{cost: getBcryptCost()}
 	return cm
 }
 
 func (cm *BcryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cm.cost)
 	if err != nil {
 		return ""
 	}
@@ -21,3 +36,11 @@ func (cm *BcryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPwd), []byte(plainPwd))
 	return err == nil
 }
+
+func (cm *BcryptCredManager) IsRehashNeeded(hashedPwd string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPwd))
+	if err != nil {
+		return true
+	}
+	return cost < cm.cost
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// FirebaseScryptCredManager handles the modified scrypt hashes exported by Firebase Authentication.
+// The user salt and the password hash are base64 strings from the export, and the organization salt
+// given to it is the project's hash config, which the organization keeps in its own password hash config
+// rather than in its password salt: <base64 signer key>$<base64 salt separator>$<rounds>$<mem cost>
+type FirebaseScryptCredManager struct{}
+
+type firebaseScryptConfig struct {
+	signerKey     []byte
+	saltSeparator []byte
+	rounds        int
+	memCost       int
+}
+
+func parseFirebaseScryptConfig(organizationSalt string) (*firebaseScryptConfig, error) {
+	parts := strings.Split(organizationSalt, "$")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid Firebase scrypt config")
+	}
+
+	signerKey, err := base64.StdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	saltSeparator, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	memCost, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, err
+	}
+	if rounds <= 0 || memCost <= 0 || memCost >= 32 {
+		return nil, fmt.Errorf("invalid Firebase scrypt rounds or mem cost")
+	}
+
+	return &firebaseScryptConfig{signerKey: signerKey, saltSeparator: saltSeparator, rounds: rounds, memCost: memCost}, nil
+}
+
+func getFirebaseScryptHash(password string, salt []byte, config *firebaseScryptConfig) ([]byte, error) {
+	key, err := scrypt.Key([]byte(password), append(salt, config.saltSeparator...), 1<<config.memCost, config.rounds, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, len(config.signerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(res, config.signerKey)
+	return res, nil
+}
+
+func NewFirebaseScryptCredManager() *FirebaseScryptCredManager {
+	cm := &FirebaseScryptCredManager{}
+	return cm
+}
+
+func (cm *FirebaseScryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	config, err := parseFirebaseScryptConfig(organizationSalt)
+	if err != nil {
+		return ""
+	}
+
+	// Firebase hashes are imported rather than created, so the user must already carry the exported salt
+	salt, err := base64.StdEncoding.DecodeString(userSalt)
+	if err != nil || len(salt) == 0 {
+		return ""
+	}
+
+	hash, err := getFirebaseScryptHash(password, salt, config)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(hash)
+}
+
+func (cm *FirebaseScryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	config, err := parseFirebaseScryptConfig(organizationSalt)
+	if err != nil {
+		return false
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(userSalt)
+	if err != nil {
+		return false
+	}
+	expectedHash, err := base64.StdEncoding.DecodeString(hashedPwd)
+	if err != nil {
+		return false
+	}
+
+	hash, err := getFirebaseScryptHash(plainPwd, salt, config)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(hash, expectedHash) == 1
+}
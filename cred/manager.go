@@ -32,6 +32,73 @@ func GetCredManager(passwordType string) CredManager {
 		return NewPbkdf2SaltCredManager()
 	} else if passwordType == "argon2id" {
 		return NewArgon2idCredManager()
+	} else if passwordType == "scrypt" {
+		return NewScryptCredManager()
+	} else if passwordType == "pbkdf2-django" {
+		return NewPbkdf2DjangoCredManager()
+	} else if passwordType == "pbkdf2-keycloak" {
+		return NewPbkdf2KeycloakCredManager()
+	} else if passwordType == "phpass" {
+		return NewPhpassCredManager()
+	} else if passwordType == "firebase-scrypt" {
+		return NewFirebaseScryptCredManager()
 	}
 	return nil
 }
+
+// RehashChecker is implemented by cred managers whose hashes carry tunable cost parameters
+type RehashChecker interface {
+	IsRehashNeeded(passwordHash string) bool
+}
+
+// passwordTypeStrengths ranks the password types from plain text to the memory or cost hard KDFs,
+// a hash is only moved to a type of the same or a higher rank
+var passwordTypeStrengths = map[string]int{
+	"plain":           0,
+	"salt":            1,
+	"md5-salt":        1,
+	"phpass":          2,
+	"pbkdf2-salt":     3,
+	"pbkdf2-django":   3,
+	"pbkdf2-keycloak": 3,
+	"bcrypt":          4,
+	"argon2id":        4,
+	"scrypt":          4,
+	"firebase-scrypt": 4,
+}
+
+func getPasswordTypeStrength(passwordType string) int {
+	if passwordType == "" {
+		return 0
+	}
+
+	strength, ok := passwordTypeStrengths[passwordType]
+	if !ok {
+		// an unknown stored type is never replaced by a weaker one
+		return len(passwordTypeStrengths)
+	}
+	return strength
+}
+
+// IsPasswordRehashNeeded reports whether a password hash stored with passwordType
+// should be regenerated with the organization's targetPasswordType, either because
+// the algorithm differs or because its cost parameters are weaker than configured.
+// A hash is never downgraded to a weaker type, like bcrypt to md5-salt or to plain text.
+func IsPasswordRehashNeeded(passwordType string, passwordHash string, targetPasswordType string) bool {
+	if targetPasswordType == "" || targetPasswordType == "plain" {
+		return false
+	}
+
+	if GetCredManager(targetPasswordType) == nil {
+		return false
+	}
+
+	if passwordType != targetPasswordType {
+		return getPasswordTypeStrength(targetPasswordType) >= getPasswordTypeStrength(passwordType)
+	}
+
+	if checker, ok := GetCredManager(passwordType).(RehashChecker); ok {
+		return checker.IsRehashNeeded(passwordHash)
+	}
+	return false
+}
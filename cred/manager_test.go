@@ -0,0 +1,88 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestIsPasswordRehashNeeded(t *testing.T) {
+	password := "123456"
+	bcryptHash := NewBcryptCredManager().GetHashedPassword(password, "", "")
+	weakBcryptHash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	argon2idHash := NewArgon2idCredManager().GetHashedPassword(password, "", "")
+
+	scenarios := []struct {
+		description        string
+		passwordType       string
+		passwordHash       string
+		targetPasswordType string
+		expected           bool
+	}{
+		{"Should upgrade md5-salt to bcrypt", "md5-salt", "e10adc3949ba59abbe56e057f20f883e", "bcrypt", true},
+		{"Should upgrade plain to argon2id", "plain", password, "argon2id", true},
+		{"Should not downgrade bcrypt to plain", "bcrypt", bcryptHash, "plain", false},
+		{"Should not downgrade bcrypt to md5-salt", "bcrypt", bcryptHash, "md5-salt", false},
+		{"Should not downgrade argon2id to salt", "argon2id", argon2idHash, "salt", false},
+		{"Should move bcrypt to argon2id", "bcrypt", bcryptHash, "argon2id", true},
+		{"Should not rehash for an empty target", "bcrypt", bcryptHash, "", false},
+		{"Should not rehash for an unknown target", "bcrypt", bcryptHash, "unknown", false},
+		{"Should keep a bcrypt hash with the configured cost", "bcrypt", bcryptHash, "bcrypt", false},
+		{"Should rehash a bcrypt hash with a lower cost", "bcrypt", string(weakBcryptHash), "bcrypt", true},
+		{"Should keep an argon2id hash with the configured params", "argon2id", argon2idHash, "argon2id", false},
+		{"Should rehash an argon2id hash with weaker params", "argon2id", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2g", "argon2id", true},
+	}
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			actual := IsPasswordRehashNeeded(scenery.passwordType, scenery.passwordHash, scenery.targetPasswordType)
+			assert.Equal(t, scenery.expected, actual, "The returned value not is expected")
+		})
+	}
+}
+
+func TestLegacyCredManagers(t *testing.T) {
+	firebaseConfig := "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==$Bw==$8$14"
+
+	scenarios := []struct {
+		description      string
+		passwordType     string
+		password         string
+		passwordHash     string
+		userSalt         string
+		organizationSalt string
+	}{
+		{"Should verify scrypt", "scrypt", "123456", "$scrypt$ln=10,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$TBoR7yUw1J9svedi5dPZHqX7KgJxip3SmLxK3F9qxEc", "", ""},
+		{"Should verify Django pbkdf2_sha256", "pbkdf2-django", "123456", "pbkdf2_sha256$1000$casdoorsalt$CkP1nktUMx2CQ+TPlT677ab/ncUXotB2y7T8jawJunY=", "", ""},
+		{"Should verify Django pbkdf2_sha512", "pbkdf2-django", "123456", "pbkdf2_sha512$1000$casdoorsalt$nNpBoPgH9Fc2+RH8kWBlwo/dPGVmqTxutQKlf5AzHvvrLFJqjIAFpjv1YhBAyIr0DOmUjH26upghqRKTtOwrQA==", "", ""},
+		{"Should verify Keycloak pbkdf2-sha256", "pbkdf2-keycloak", "123456", `{"secretData": "{\"value\":\"ItJAE3b5Jr+qJdoizh1DGmLcgxE/wdwRuTACkkM/T11JX9pyNdTjvwUoytCt70oMsHwODbSa5kCAFcGDS9YG+w==\",\"salt\":\"MDEyMzQ1Njc4OWFiY2RlZg==\",\"additionalParameters\":{}}", "credentialData": "{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\",\"additionalParameters\":{}}"}`, "", ""},
+		{"Should verify phpass", "phpass", "test12345", "$P$9IQRaTwmfeRo7ud9Fh4E2PdI0S3r.L0", "", ""},
+		{"Should verify Firebase scrypt", "firebase-scrypt", "user1password", "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==", "42xEC+ixf3L2lw==", firebaseConfig},
+	}
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			cm := GetCredManager(scenery.passwordType)
+			assert.NotNil(t, cm)
+			assert.True(t, cm.IsPasswordCorrect(scenery.password, scenery.passwordHash, scenery.userSalt, scenery.organizationSalt))
+			assert.False(t, cm.IsPasswordCorrect(scenery.password+"x", scenery.passwordHash, scenery.userSalt, scenery.organizationSalt))
+
+			hash := cm.GetHashedPassword(scenery.password, scenery.userSalt, scenery.organizationSalt)
+			assert.NotEmpty(t, hash)
+			assert.True(t, cm.IsPasswordCorrect(scenery.password, hash, scenery.userSalt, scenery.organizationSalt))
+		})
+	}
+}
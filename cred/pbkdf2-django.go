@@ -0,0 +1,114 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Pbkdf2DjangoCredManager handles hashes in Django's format:
+// <algorithm>$<iterations>$<salt>$<base64 hash>, e.g., pbkdf2_sha256$600000$salt$hash
+type Pbkdf2DjangoCredManager struct{}
+
+const pbkdf2DjangoIterations = 600000
+
+func generateAlphanumericSalt(length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+	return string(b)
+}
+
+func getPbkdf2HashFunc(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// ParseDjangoPbkdf2Hash returns the digest name ("sha256", "sha512", ...), the iteration count,
+// the salt and the derived key of a Django pbkdf2 hash
+func ParseDjangoPbkdf2Hash(hashedPwd string) (string, int, []byte, []byte, error) {
+	parts := strings.Split(hashedPwd, "$")
+	if len(parts) != 4 || !strings.HasPrefix(parts[0], "pbkdf2_") {
+		return "", 0, nil, nil, fmt.Errorf("invalid Django pbkdf2 hash")
+	}
+
+	algorithm := strings.TrimPrefix(parts[0], "pbkdf2_")
+	if getPbkdf2HashFunc(algorithm) == nil {
+		return "", 0, nil, nil, fmt.Errorf("unsupported Django pbkdf2 algorithm: %s", algorithm)
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return "", 0, nil, nil, fmt.Errorf("invalid Django pbkdf2 iterations: %s", parts[1])
+	}
+
+	key, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+
+	return algorithm, iterations, []byte(parts[2]), key, nil
+}
+
+func NewPbkdf2DjangoCredManager() *Pbkdf2DjangoCredManager {
+	cm := &Pbkdf2DjangoCredManager{}
+	return cm
+}
+
+func (cm *Pbkdf2DjangoCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := userSalt
+	if salt == "" {
+		salt = generateAlphanumericSalt(22)
+	}
+
+	key := pbkdf2.Key([]byte(password), []byte(salt), pbkdf2DjangoIterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("pbkdf2_sha256$%d$%s$%s", pbkdf2DjangoIterations, salt, base64.StdEncoding.EncodeToString(key))
+}
+
+func (cm *Pbkdf2DjangoCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	algorithm, iterations, salt, expectedKey, err := ParseDjangoPbkdf2Hash(hashedPwd)
+	if err != nil {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(plainPwd), salt, iterations, len(expectedKey), getPbkdf2HashFunc(algorithm))
+	return subtle.ConstantTimeCompare(key, expectedKey) == 1
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Pbkdf2KeycloakCredManager handles the password credentials exported by Keycloak, e.g.,
+// {"secretData": "{\"value\":\"...\",\"salt\":\"...\"}", "credentialData": "{\"hashIterations\":27500,\"algorithm\":\"pbkdf2-sha256\"}"}
+type Pbkdf2KeycloakCredManager struct{}
+
+const pbkdf2KeycloakIterations = 27500
+
+type keycloakSecretData struct {
+	Value string `json:"value"`
+	Salt  string `json:"salt"`
+}
+
+type keycloakCredentialData struct {
+	HashIterations int    `json:"hashIterations"`
+	Algorithm      string `json:"algorithm"`
+}
+
+type keycloakCredential struct {
+	SecretData     json.RawMessage `json:"secretData"`
+	CredentialData json.RawMessage `json:"credentialData"`
+}
+
+// Keycloak's realm exports nest the secret and credential data as JSON strings,
+// while some tools flatten them into plain objects, so both forms are accepted
+func unmarshalKeycloakField(data json.RawMessage, v interface{}) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		data = json.RawMessage(s)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ParseKeycloakPbkdf2Credential returns the digest name ("sha256", "sha512", ...), the iteration count,
+// the salt and the derived key of a Keycloak pbkdf2 credential
+func ParseKeycloakPbkdf2Credential(hashedPwd string) (string, int, []byte, []byte, error) {
+	credential := keycloakCredential{}
+	err := json.Unmarshal([]byte(hashedPwd), &credential)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+
+	secretData := keycloakSecretData{}
+	err = unmarshalKeycloakField(credential.SecretData, &secretData)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+
+	credentialData := keycloakCredentialData{}
+	err = unmarshalKeycloakField(credential.CredentialData, &credentialData)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+
+	algorithm := "sha1"
+	if credentialData.Algorithm != "pbkdf2" {
+		algorithm = strings.TrimPrefix(credentialData.Algorithm, "pbkdf2-")
+	}
+	if getPbkdf2HashFunc(algorithm) == nil {
+		return "", 0, nil, nil, fmt.Errorf("unsupported Keycloak algorithm: %s", credentialData.Algorithm)
+	}
+	if credentialData.HashIterations <= 0 {
+		return "", 0, nil, nil, fmt.Errorf("invalid Keycloak hash iterations: %d", credentialData.HashIterations)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(secretData.Salt)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(secretData.Value)
+	if err != nil {
+		return "", 0, nil, nil, err
+	}
+
+	return algorithm, credentialData.HashIterations, salt, key, nil
+}
+
+func NewPbkdf2KeycloakCredManager() *Pbkdf2KeycloakCredManager {
+	cm := &Pbkdf2KeycloakCredManager{}
+	return cm
+}
+
+func (cm *Pbkdf2KeycloakCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return ""
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, pbkdf2KeycloakIterations, 64, getPbkdf2HashFunc("sha256"))
+	secretData, _ := json.Marshal(keycloakSecretData{
+		Value: base64.StdEncoding.EncodeToString(key),
+		Salt:  base64.StdEncoding.EncodeToString(salt),
+	})
+	credentialData, _ := json.Marshal(keycloakCredentialData{
+		HashIterations: pbkdf2KeycloakIterations,
+		Algorithm:      "pbkdf2-sha256",
+	})
+	credential, _ := json.Marshal(map[string]string{
+		"secretData":     string(secretData),
+		"credentialData": string(credentialData),
+	})
+	return string(credential)
+}
+
+func (cm *Pbkdf2KeycloakCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	algorithm, iterations, salt, expectedKey, err := ParseKeycloakPbkdf2Credential(hashedPwd)
+	if err != nil || len(expectedKey) == 0 {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(plainPwd), salt, iterations, len(expectedKey), getPbkdf2HashFunc(algorithm))
+	return subtle.ConstantTimeCompare(key, expectedKey) == 1
+}
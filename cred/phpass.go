@@ -0,0 +1,111 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"strings"
+)
+
+// PhpassCredManager handles the portable hashes of phpass used by WordPress, phpBB and Drupal 7 imports:
+// $P$<log2 count><8 chars salt><22 chars hash>
+type PhpassCredManager struct{}
+
+const (
+	phpassItoa64      = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	phpassCountLog2   = 8
+	phpassPrefixLen   = 3
+	phpassSettingsLen = 12
+)
+
+func encodePhpassBase64(input []byte) string {
+	var sb strings.Builder
+	count := len(input)
+	i := 0
+	for i < count {
+		value := int(input[i])
+		i++
+		sb.WriteByte(phpassItoa64[value&0x3f])
+		if i < count {
+			value |= int(input[i]) << 8
+		}
+		sb.WriteByte(phpassItoa64[(value>>6)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+		if i < count {
+			value |= int(input[i]) << 16
+		}
+		sb.WriteByte(phpassItoa64[(value>>12)&0x3f])
+		if i >= count {
+			break
+		}
+		i++
+		sb.WriteByte(phpassItoa64[(value>>18)&0x3f])
+	}
+	return sb.String()
+}
+
+func getPhpassHash(password string, setting string) string {
+	if len(setting) < phpassSettingsLen {
+		return ""
+	}
+
+	prefix := setting[:phpassPrefixLen]
+	if prefix != "$P$" && prefix != "$H$" {
+		return ""
+	}
+
+	countLog2 := strings.IndexByte(phpassItoa64, setting[3])
+	if countLog2 < 7 || countLog2 > 30 {
+		return ""
+	}
+
+	salt := setting[4:phpassSettingsLen]
+	hash := md5.Sum([]byte(salt + password))
+	for count := 1 << countLog2; count > 0; count-- {
+		hash = md5.Sum(append(hash[:], password...))
+	}
+
+	return setting[:phpassSettingsLen] + encodePhpassBase64(hash[:])
+}
+
+func NewPhpassCredManager() *PhpassCredManager {
+	cm := &PhpassCredManager{}
+	return cm
+}
+
+func (cm *PhpassCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	randomBytes := make([]byte, 6)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return ""
+	}
+
+	setting := "$P$" + string(phpassItoa64[phpassCountLog2+5]) + encodePhpassBase64(randomBytes)
+	return getPhpassHash(password, setting)
+}
+
+func (cm *PhpassCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	hash := getPhpassHash(plainPwd, hashedPwd)
+	if hash == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashedPwd)) == 1
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cred
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ScryptCredManager handles hashes in the passlib modular crypt format:
+// $scrypt$ln=<log2 N>,r=<r>,p=<p>$<salt>$<hash>
+type ScryptCredManager struct{}
+
+const (
+	scryptLogN   = 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// passlib uses "." instead of "+" and strips the padding of standard base64
+func decodeAdaptedBase64(s string) ([]byte, error) {
+	s = strings.ReplaceAll(s, ".", "+")
+	s = strings.TrimRight(s, "=")
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+func encodeAdaptedBase64(b []byte) string {
+	return strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(b), "+", ".")
+}
+
+func NewScryptCredManager() *ScryptCredManager {
+	cm := &ScryptCredManager{}
+	return cm
+}
+
+func (cm *ScryptCredManager) GetHashedPassword(password string, userSalt string, organizationSalt string) string {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return ""
+	}
+
+	key, err := scrypt.Key([]byte(password), salt, 1<<scryptLogN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", scryptLogN, scryptR, scryptP, encodeAdaptedBase64(salt), encodeAdaptedBase64(key))
+}
+
+func (cm *ScryptCredManager) IsPasswordCorrect(plainPwd string, hashedPwd string, userSalt string, organizationSalt string) bool {
+	parts := strings.Split(hashedPwd, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return false
+	}
+
+	var logN, r, p int
+	_, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &logN, &r, &p)
+	if err != nil || logN <= 0 || logN >= 32 {
+		return false
+	}
+
+	salt, err := decodeAdaptedBase64(parts[3])
+	if err != nil {
+		return false
+	}
+	expectedKey, err := decodeAdaptedBase64(parts[4])
+	if err != nil || len(expectedKey) == 0 {
+		return false
+	}
+
+	key, err := scrypt.Key([]byte(plainPwd), salt, 1<<logN, r, p, len(expectedKey))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, expectedKey) == 1
+}
@@ -18,6 +18,7 @@
 package deployment
 
 import (
+	"context"
 	"testing"
 
 	"github.com/casdoor/casdoor/object"
@@ -27,7 +28,7 @@ import (
 func TestDeployStaticFiles(t *testing.T) {
 	object.InitConfig()
 
-	provider, err := object.GetProvider(util.GetId("admin", "provider_storage_aliyun_oss"))
+	provider, err := object.GetProvider(context.Background(), util.GetId("admin", "provider_storage_aliyun_oss"))
 	if err != nil {
 		panic(err)
 	}
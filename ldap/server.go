@@ -49,6 +49,7 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 			res.SetResultCode(ldap.LDAPResultInvalidDNSyntax)
 			res.SetDiagnosticMessage("bind failed ErrMsg: " + err)
 			w.Write(res)
+			observeLdapRequest("bind", ldap.LDAPResultInvalidDNSyntax)
 			return
 		}
 
@@ -59,6 +60,7 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 			res.SetResultCode(ldap.LDAPResultInvalidCredentials)
 			res.SetDiagnosticMessage("invalid credentials ErrMsg: " + err)
 			w.Write(res)
+			observeLdapRequest("bind", ldap.LDAPResultInvalidCredentials)
 			return
 		}
 
@@ -71,9 +73,11 @@ func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 		m.Client.IsAuthenticated = true
 		m.Client.UserName = bindUsername
 		m.Client.OrgName = bindOrg
+		observeLdapRequest("bind", ldap.LDAPResultSuccess)
 	} else {
 		res.SetResultCode(ldap.LDAPResultAuthMethodNotSupported)
 		res.SetDiagnosticMessage("Authentication method not supported,Please use Simple Authentication")
+		observeLdapRequest("bind", ldap.LDAPResultAuthMethodNotSupported)
 	}
 	w.Write(res)
 }
@@ -83,12 +87,14 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	if !m.Client.IsAuthenticated {
 		res.SetResultCode(ldap.LDAPResultUnwillingToPerform)
 		w.Write(res)
+		observeLdapRequest("search", ldap.LDAPResultUnwillingToPerform)
 		return
 	}
 
 	r := m.GetSearchRequest()
 	if r.FilterString() == "(objectClass=*)" {
 		w.Write(res)
+		observeLdapRequest("search", ldap.LDAPResultSuccess)
 		return
 	}
 
@@ -104,6 +110,7 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	if code != ldap.LDAPResultSuccess {
 		res.SetResultCode(code)
 		w.Write(res)
+		observeLdapRequest("search", code)
 		return
 	}
 
@@ -112,7 +119,13 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 		e := ldap.NewSearchResultEntry(dn)
 
 		for _, attr := range r.Attributes() {
-			e.AddAttribute(message.AttributeDescription(attr), getAttribute(string(attr), user))
+			value := getAttribute(string(attr), user)
+			if value == "" && string(attr) == "userPassword" {
+				// the hash has no LDAP scheme, an empty value would look like an empty password
+				continue
+			}
+
+			e.AddAttribute(message.AttributeDescription(attr), value)
 			if string(attr) == "cn" {
 				e.AddAttribute(message.AttributeDescription(attr), getAttribute("title", user))
 			}
@@ -121,4 +134,15 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 		w.Write(e)
 	}
 	w.Write(res)
+	observeLdapRequest("search", ldap.LDAPResultSuccess)
+}
+
+func observeLdapRequest(operation string, code int) {
+	result := "success"
+	if code == ldap.LDAPResultInvalidCredentials {
+		result = "invalid_credentials"
+	} else if code != ldap.LDAPResultSuccess {
+		result = "failure"
+	}
+	object.LdapRequestCounter.WithLabelValues(operation, result).Inc()
 }
@@ -15,10 +15,13 @@
 package ldap
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"strings"
 
+	"github.com/casdoor/casdoor/cred"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 	"github.com/lor00x/goldap/message"
@@ -131,7 +134,7 @@ func GetFilteredUsers(m *ldap.Message) (filteredUsers []*object.User, code int)
 			return filteredUsers, ldap.LDAPResultSuccess
 		}
 
-		organization, err := object.GetOrganization(util.GetId("admin", org))
+		organization, err := object.GetOrganization(context.Background(), util.GetId("admin", org))
 		if err != nil {
 			panic(err)
 		}
@@ -163,20 +166,57 @@ func getUserPasswordWithType(user *object.User) string {
 		panic(err)
 	}
 
-	if org.PasswordType == "" || org.PasswordType == "plain" {
+	passwordType := user.PasswordType
+	if passwordType == "" {
+		passwordType = org.PasswordType
+	}
+
+	if passwordType == "" || passwordType == "plain" {
 		return user.Password
 	}
-	prefix := org.PasswordType
+	prefix := passwordType
 	if prefix == "salt" {
 		prefix = "sha256"
 	} else if prefix == "md5-salt" {
 		prefix = "md5"
 	} else if prefix == "pbkdf2-salt" {
 		prefix = "pbkdf2"
+	} else if prefix == "scrypt" || prefix == "phpass" || prefix == "firebase-scrypt" {
+		// LDAP has no userPassword scheme for these hashes, the clients must bind to verify the password
+		return ""
+	} else if prefix == "pbkdf2-django" || prefix == "pbkdf2-keycloak" {
+		return getOpenLdapPbkdf2Password(passwordType, user.Password)
 	}
 	return fmt.Sprintf("{%s}%s", prefix, user.Password)
 }
 
+// convert Django and Keycloak pbkdf2 hashes to the format of OpenLDAP's pw-pbkdf2 module
+// @return {PBKDF2-SHA256}10000$<salt>$<hash>
+func getOpenLdapPbkdf2Password(passwordType string, password string) string {
+	var algorithm string
+	var iterations int
+	var salt, key []byte
+	var err error
+	if passwordType == "pbkdf2-django" {
+		algorithm, iterations, salt, key, err = cred.ParseDjangoPbkdf2Hash(password)
+	} else {
+		algorithm, iterations, salt, key, err = cred.ParseKeycloakPbkdf2Credential(password)
+	}
+	if err != nil {
+		return ""
+	}
+
+	prefix := "PBKDF2"
+	if algorithm != "sha1" {
+		prefix = fmt.Sprintf("PBKDF2-%s", strings.ToUpper(algorithm))
+	}
+
+	encode := func(b []byte) string {
+		return strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(b), "+", ".")
+	}
+	return fmt.Sprintf("{%s}%d$%s$%s", prefix, iterations, encode(salt), encode(key))
+}
+
 func getAttribute(attributeName string, user *object.User) message.AttributeValue {
 	switch attributeName {
 	case "cn":
@@ -27,10 +27,14 @@ import (
 	"github.com/casdoor/casdoor/proxy"
 	"github.com/casdoor/casdoor/radius"
 	"github.com/casdoor/casdoor/routers"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
 )
 
 func main() {
+	telemetry.InitTracer()
+	defer telemetry.ShutdownTracer()
+
 	object.InitFlag()
 	object.InitAdapter()
 	object.CreateTables()
@@ -41,11 +45,23 @@ func main() {
 	object.InitDefaultStorageProvider()
 	object.InitLdapAutoSynchronizer()
 	proxy.InitHttpClient()
+	object.InitPolicyWatcher()
 	authz.InitApi()
 	object.InitUserManager()
 	object.InitCasvisorConfig()
+	object.InitEventSinks()
 
 	util.SafeGoroutine(func() { object.RunSyncUsersJob() })
+	util.SafeGoroutine(func() { object.RunProvisioningJob() })
+	util.SafeGoroutine(func() { object.RunAssignmentJob() })
+	util.SafeGoroutine(func() { object.RunApprovalJob() })
+	util.SafeGoroutine(func() { object.RunAccessReviewJob() })
+	util.SafeGoroutine(func() { object.RunRecordRetentionJob() })
+	util.SafeGoroutine(func() { object.RunRecordCheckpointJob() })
+	util.SafeGoroutine(func() { object.RunWebhookDeliveryJob() })
+	util.SafeGoroutine(func() { object.RunDashboardStatJob() })
+	util.SafeGoroutine(func() { object.RunSigninActivityRetentionJob() })
+	util.SafeGoroutine(func() { object.RunEventSinkJob() })
 
 	// beego.DelStaticPath("/static")
 	// beego.SetStaticPath("/static", "web/build/static")
@@ -60,6 +76,7 @@ func main() {
 	beego.InsertFilter("*", beego.BeforeRouter, routers.ApiFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.PrometheusFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.RecordMessage)
+	beego.InsertFilter("*", beego.FinishRouter, routers.AfterRecordMessage, false)
 
 	beego.BConfig.WebConfig.Session.SessionOn = true
 	beego.BConfig.WebConfig.Session.SessionName = "casdoor_session_id"
@@ -85,5 +102,5 @@ func main() {
 	go radius.StartRadiusServer()
 	go object.ClearThroughputPerSecond()
 
-	beego.Run(fmt.Sprintf(":%v", port))
+	beego.RunWithMiddleWares(fmt.Sprintf(":%v", port), routers.TracingHandler)
 }
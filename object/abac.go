@@ -0,0 +1,222 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// AbacAttributes are the attributes of the subject that a model can expose to its matcher, a single property
+// can also be exposed as "properties.<key>"
+var AbacAttributes = []string{
+	"type", "displayName", "affiliation", "title", "tag", "region", "location", "language", "isAdmin", "score",
+	"groups", "roles", "properties", "ip", "time",
+}
+
+// AbacContext is the context of an enforce request. The ip is taken from the X-Forwarded-For header when the caller
+// doesn't give it, which is only trustworthy behind a reverse proxy that overwrites the header
+type AbacContext struct {
+	Ip   string
+	Time time.Time
+}
+
+// AbacSubject replaces the subject of an enforce request when the model exposes attributes, so that the matcher
+// can use them like "r.sub.Affiliation == p.sub", "'built-in/admins' in r.sub.Groups" or "userProperty(r.sub, 'level') == '2'".
+// Only the exposed attributes are filled, the RBAC functions can still be called with "g(r.sub.Id, p.sub)"
+type AbacSubject struct {
+	Id          string
+	Owner       string
+	Name        string
+	Type        string
+	DisplayName string
+	Affiliation string
+	Title       string
+	Tag         string
+	Region      string
+	Location    string
+	Language    string
+	IsAdmin     bool
+	Score       int
+	Groups      []interface{}
+	Roles       []interface{}
+	Properties  map[string]string
+
+	Ip      string
+	Time    string
+	Hour    int
+	Weekday string
+}
+
+func (subject *AbacSubject) String() string {
+	return subject.Id
+}
+
+// userPropertyFunction is added to the matchers as "userProperty(r.sub, key)", because a matcher can't index a map
+func userPropertyFunction(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("userProperty() expects 2 arguments, but got %d", len(args))
+	}
+
+	subject, ok := args[0].(*AbacSubject)
+	if !ok {
+		return nil, fmt.Errorf("the first argument of userProperty() should be the subject in the ABAC mode")
+	}
+
+	key, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("the second argument of userProperty() should be a string")
+	}
+
+	return subject.Properties[key], nil
+}
+
+func checkAbacAttributes(attributes []string) error {
+	for _, attribute := range attributes {
+		if strings.HasPrefix(attribute, "properties.") && attribute != "properties." {
+			continue
+		}
+		if !util.InSlice(AbacAttributes, attribute) {
+			return fmt.Errorf("unknown ABAC attribute: %s", attribute)
+		}
+	}
+	return nil
+}
+
+func toInterfaces(values []string) []interface{} {
+	res := []interface{}{}
+	for _, value := range values {
+		res = append(res, value)
+	}
+	return res
+}
+
+func getAbacSubject(owner string, sub string, attributes []string, abacContext *AbacContext) (*AbacSubject, error) {
+	userId := sub
+	if !strings.Contains(userId, "/") {
+		userId = util.GetId(owner, sub)
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	} else if user == nil {
+		return nil, fmt.Errorf("the user: %s doesn't exist", userId)
+	}
+
+	subject := &AbacSubject{Id: user.GetId(), Owner: user.Owner, Name: user.Name}
+	for _, attribute := range attributes {
+		switch attribute {
+		case "type":
+			subject.Type = user.Type
+		case "displayName":
+			subject.DisplayName = user.DisplayName
+		case "affiliation":
+			subject.Affiliation = user.Affiliation
+		case "title":
+			subject.Title = user.Title
+		case "tag":
+			subject.Tag = user.Tag
+		case "region":
+			subject.Region = user.Region
+		case "location":
+			subject.Location = user.Location
+		case "language":
+			subject.Language = user.Language
+		case "isAdmin":
+			subject.IsAdmin = user.IsAdmin
+		case "score":
+			subject.Score = user.Score
+		case "groups":
+			subject.Groups = toInterfaces(user.Groups)
+		case "roles":
+			roles, err := getRolesByUser(user.GetId())
+			if err != nil {
+				return nil, err
+			}
+
+			subject.Roles = []interface{}{}
+			for _, role := range roles {
+				subject.Roles = append(subject.Roles, role.GetId())
+			}
+		case "properties":
+			subject.Properties = user.Properties
+		case "ip":
+			if abacContext != nil {
+				subject.Ip = abacContext.Ip
+			}
+		case "time":
+			if abacContext != nil {
+				subject.Time = abacContext.Time.Format(time.RFC3339)
+				subject.Hour = abacContext.Time.Hour()
+				subject.Weekday = abacContext.Time.Weekday().String()
+			}
+		default:
+			key := strings.TrimPrefix(attribute, "properties.")
+			if value, ok := user.Properties[key]; ok {
+				if subject.Properties == nil {
+					subject.Properties = map[string]string{}
+				}
+				subject.Properties[key] = value
+			}
+		}
+	}
+	return subject, nil
+}
+
+// getAbacRequests resolves the subjects of the requests to users when the model of the permission exposes attributes,
+// otherwise the requests are returned as they are
+func getAbacRequests(permission *Permission, requests []CasbinRequest, abacContext *AbacContext) ([]CasbinRequest, error) {
+	model, err := GetModel(util.GetId(permission.Owner, permission.Model))
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return requests, nil
+	}
+
+	return getAbacRequestsByAttributes(permission.Owner, model.AbacAttributes, requests, abacContext)
+}
+
+func getAbacRequestsByAttributes(owner string, attributes []string, requests []CasbinRequest, abacContext *AbacContext) ([]CasbinRequest, error) {
+	if len(attributes) == 0 {
+		return requests, nil
+	}
+
+	res := []CasbinRequest{}
+	for _, request := range requests {
+		if len(request) == 0 {
+			res = append(res, request)
+			continue
+		}
+
+		sub, ok := request[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("the subject of the request should be a user id in the ABAC mode")
+		}
+
+		subject, err := getAbacSubject(owner, sub, attributes, abacContext)
+		if err != nil {
+			return nil, err
+		}
+
+		abacRequest := append(CasbinRequest{subject}, request[1:]...)
+		res = append(res, abacRequest)
+	}
+	return res, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const abacModelText = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = (g(r.sub.Id, p.sub) || r.sub.Affiliation == p.sub || p.sub in r.sub.Groups || userProperty(r.sub, "level") == p.sub) && r.obj == p.obj && r.act == p.act`
+
+func TestAbacSubject(t *testing.T) {
+	m, err := model.NewModelFromString(abacModelText)
+	assert.Nil(t, err)
+
+	enforcer, err := casbin.NewEnforcer(m)
+	assert.Nil(t, err)
+	enforcer.AddFunction("userProperty", userPropertyFunction)
+
+	_, err = enforcer.AddPolicies([][]string{
+		{"built-in/admin", "data1", "read"},
+		{"sales", "data2", "read"},
+		{"built-in/group1", "data3", "read"},
+		{"2", "data4", "read"},
+	})
+	assert.Nil(t, err)
+	_, err = enforcer.AddGroupingPolicy("built-in/alice", "built-in/admin")
+	assert.Nil(t, err)
+
+	alice := &AbacSubject{Id: "built-in/alice", Affiliation: "sales", Groups: []interface{}{"built-in/group1"}, Properties: map[string]string{"level": "2"}}
+	bob := &AbacSubject{Id: "built-in/bob", Groups: []interface{}{}}
+
+	scenarios := []struct {
+		description string
+		subject     *AbacSubject
+		obj         string
+		expected    bool
+	}{
+		{"role of the user id", alice, "data1", true},
+		{"affiliation", alice, "data2", true},
+		{"group", alice, "data3", true},
+		{"property", alice, "data4", true},
+		{"no role", bob, "data1", false},
+		{"attribute not exposed", bob, "data2", false},
+		{"property not exposed", bob, "data4", false},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			allowed, err := enforcer.Enforce(scenario.subject, scenario.obj, "read")
+			assert.Nil(t, err)
+			assert.Equal(t, scenario.expected, allowed)
+		})
+	}
+
+	assert.Nil(t, checkAbacAttributes([]string{"affiliation", "groups", "properties.level", "ip"}))
+	assert.NotNil(t, checkAbacAttributes([]string{"password"}))
+
+	// a model with an unknown attribute is rejected before it is saved
+	_, err = AddModel(&Model{Owner: "built-in", Name: "abac-model", ModelText: abacModelText, AbacAttributes: []string{"password"}})
+	assert.NotNil(t, err)
+}
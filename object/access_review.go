@@ -0,0 +1,571 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casdoor/casdoor/xlsx"
+	"github.com/xorm-io/core"
+)
+
+const (
+	AccessReviewStateDraft  = "Draft"
+	AccessReviewStateActive = "Active"
+	AccessReviewStateClosed = "Closed"
+)
+
+const (
+	AccessReviewDecisionCertified = "Certified"
+	AccessReviewDecisionRevoked   = "Revoked"
+)
+
+const accessReviewInterval = 10 * time.Minute
+
+// AccessReview is a certification campaign: every member of the roles and permissions in its scope is reviewed,
+// the memberships revoked by the reviewers are removed when the campaign closes
+type AccessReview struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Roles           []string `xorm:"mediumtext" json:"roles"`
+	Permissions     []string `xorm:"mediumtext" json:"permissions"`
+	Applications    []string `xorm:"mediumtext" json:"applications"`
+	DefaultReviewer string   `xorm:"varchar(100)" json:"defaultReviewer"`
+	EndTime         string   `xorm:"varchar(100)" json:"endTime"`
+
+	State      string `xorm:"varchar(100)" json:"state"`
+	StartTime  string `xorm:"varchar(100)" json:"startTime"`
+	ClosedTime string `xorm:"varchar(100)" json:"closedTime"`
+	ItemCount  int    `json:"itemCount"`
+}
+
+// AccessReviewItem is the review of the membership of a user, a group or a sub-role in a role or a permission
+type AccessReviewItem struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Review      string `xorm:"varchar(100) index" json:"review"`
+	TargetType  string `xorm:"varchar(100)" json:"targetType"`
+	Target      string `xorm:"varchar(100)" json:"target"`
+	MemberType  string `xorm:"varchar(100)" json:"memberType"`
+	Member      string `xorm:"varchar(100)" json:"member"`
+	Reviewer    string `xorm:"varchar(100) index" json:"reviewer"`
+	Decision    string `xorm:"varchar(100)" json:"decision"`
+	Comment     string `xorm:"varchar(1000)" json:"comment"`
+	DecidedTime string `xorm:"varchar(100)" json:"decidedTime"`
+	IsApplied   bool   `json:"isApplied"`
+}
+
+func GetAccessReviewCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&AccessReview{})
+}
+
+func GetAccessReviews(owner string) ([]*AccessReview, error) {
+	accessReviews := []*AccessReview{}
+	err := ormer.Engine.Desc("created_time").Find(&accessReviews, &AccessReview{Owner: owner})
+	if err != nil {
+		return accessReviews, err
+	}
+
+	return accessReviews, nil
+}
+
+func GetPaginationAccessReviews(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*AccessReview, error) {
+	accessReviews := []*AccessReview{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&accessReviews)
+	if err != nil {
+		return accessReviews, err
+	}
+
+	return accessReviews, nil
+}
+
+func getAccessReview(owner string, name string) (*AccessReview, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	accessReview := AccessReview{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&accessReview)
+	if err != nil {
+		return &accessReview, err
+	}
+
+	if existed {
+		return &accessReview, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetAccessReview(id string) (*AccessReview, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	return getAccessReview(owner, name)
+}
+
+// checkAccessReviewScope makes sure the roles and the permissions of the campaign belong to its organization,
+// as the revoked memberships are removed from them when the campaign closes
+func checkAccessReviewScope(accessReview *AccessReview, lang string) error {
+	for _, id := range append(append([]string{}, accessReview.Roles...), accessReview.Permissions...) {
+		owner, _ := util.GetOwnerAndNameFromIdNoCheck(id)
+		if owner != accessReview.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "accessReview:The role or permission: %s does not belong to the organization: %s"), id, accessReview.Owner)
+		}
+	}
+	return nil
+}
+
+func UpdateAccessReview(id string, accessReview *AccessReview, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	if a, err := getAccessReview(owner, name); err != nil {
+		return false, err
+	} else if a == nil {
+		return false, nil
+	}
+
+	err := checkAccessReviewScope(accessReview, lang)
+	if err != nil {
+		return false, err
+	}
+
+	// the state is driven by StartAccessReview and CloseAccessReview
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).Omit("state", "start_time", "closed_time", "item_count").AllCols().Update(accessReview)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddAccessReview(accessReview *AccessReview, lang string) (bool, error) {
+	err := checkAccessReviewScope(accessReview, lang)
+	if err != nil {
+		return false, err
+	}
+
+	accessReview.State = AccessReviewStateDraft
+	affected, err := ormer.Engine.Insert(accessReview)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteAccessReview(accessReview *AccessReview) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{accessReview.Owner, accessReview.Name}).Delete(&AccessReview{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = ormer.Engine.Where("owner = ? and review = ?", accessReview.Owner, accessReview.Name).Delete(&AccessReviewItem{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (accessReview *AccessReview) GetId() string {
+	return fmt.Sprintf("%s/%s", accessReview.Owner, accessReview.Name)
+}
+
+func GetAccessReviewItemCount(owner, review, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.And("review = ?", review).Count(&AccessReviewItem{})
+}
+
+func GetAccessReviewItems(owner string, review string) ([]*AccessReviewItem, error) {
+	accessReviewItems := []*AccessReviewItem{}
+	err := ormer.Engine.Asc("target").Asc("member").Find(&accessReviewItems, &AccessReviewItem{Owner: owner, Review: review})
+	if err != nil {
+		return accessReviewItems, err
+	}
+
+	return accessReviewItems, nil
+}
+
+func GetPaginationAccessReviewItems(owner, review string, offset, limit int, field, value, sortField, sortOrder string) ([]*AccessReviewItem, error) {
+	accessReviewItems := []*AccessReviewItem{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.And("review = ?", review).Find(&accessReviewItems)
+	if err != nil {
+		return accessReviewItems, err
+	}
+
+	return accessReviewItems, nil
+}
+
+// GetReviewerAccessReviewItems returns the undecided items assigned to the user in the active campaigns
+func GetReviewerAccessReviewItems(user *User) ([]*AccessReviewItem, error) {
+	accessReviewItems := []*AccessReviewItem{}
+	err := ormer.Engine.Where("reviewer = ? and decision = ?", user.GetId(), "").Find(&accessReviewItems)
+	if err != nil {
+		return accessReviewItems, err
+	}
+
+	res := []*AccessReviewItem{}
+	for _, accessReviewItem := range accessReviewItems {
+		accessReview, err := getAccessReview(accessReviewItem.Owner, accessReviewItem.Review)
+		if err != nil {
+			return nil, err
+		}
+		if accessReview != nil && accessReview.State == AccessReviewStateActive {
+			res = append(res, accessReviewItem)
+		}
+	}
+	return res, nil
+}
+
+// getAccessReviewScope returns the roles and the permissions of the campaign, a campaign without any scope
+// covers all of them in the organization
+func getAccessReviewScope(accessReview *AccessReview) ([]*Role, []*Permission, error) {
+	if len(accessReview.Roles) == 0 && len(accessReview.Permissions) == 0 && len(accessReview.Applications) == 0 {
+		roles, err := GetRoles(accessReview.Owner)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		permissions, err := GetPermissions(accessReview.Owner)
+		return roles, permissions, err
+	}
+
+	permissionIds := append([]string{}, accessReview.Permissions...)
+	if len(accessReview.Applications) != 0 {
+		permissions, err := GetPermissions(accessReview.Owner)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, permission := range permissions {
+			if permission.ResourceType != "Application" {
+				continue
+			}
+			for _, application := range accessReview.Applications {
+				if util.InSlice(permission.Resources, application) && !util.InSlice(permissionIds, permission.GetId()) {
+					permissionIds = append(permissionIds, permission.GetId())
+				}
+			}
+		}
+	}
+
+	permissions := []*Permission{}
+	roleIds := append([]string{}, accessReview.Roles...)
+	for _, permissionId := range permissionIds {
+		permission, err := GetPermission(permissionId)
+		if err != nil {
+			return nil, nil, err
+		}
+		// a campaign only reviews and changes its own organization
+		if permission == nil || permission.Owner != accessReview.Owner {
+			continue
+		}
+
+		permissions = append(permissions, permission)
+		// the roles granting an application are part of its review
+		if len(accessReview.Applications) != 0 {
+			for _, roleId := range permission.Roles {
+				if !util.InSlice(roleIds, roleId) {
+					roleIds = append(roleIds, roleId)
+				}
+			}
+		}
+	}
+
+	roles := []*Role{}
+	for _, roleId := range roleIds {
+		role, err := GetRole(roleId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if role != nil && role.Owner == accessReview.Owner {
+			roles = append(roles, role)
+		}
+	}
+	return roles, permissions, nil
+}
+
+// getAccessReviewer routes the item to a manager of the member's groups, or to the default reviewer of the campaign
+func getAccessReviewer(accessReview *AccessReview, member string) (string, error) {
+	user, err := GetUser(member)
+	if err != nil {
+		return "", err
+	}
+
+	if user != nil {
+		managers, err := getManagerApprovers(user)
+		if err != nil {
+			return "", err
+		}
+		if len(managers) != 0 {
+			return managers[0], nil
+		}
+	}
+	return accessReview.DefaultReviewer, nil
+}
+
+// StartAccessReview generates an item for every user, group and sub-role of the roles and permissions in scope and
+// activates the campaign
+func StartAccessReview(id string, lang string) (bool, error) {
+	accessReview, err := GetAccessReview(id)
+	if err != nil {
+		return false, err
+	}
+	if accessReview == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review: %s does not exist"), id)
+	}
+	if accessReview.State != AccessReviewStateDraft {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review has already started"))
+	}
+
+	roles, permissions, err := getAccessReviewScope(accessReview)
+	if err != nil {
+		return false, err
+	}
+
+	items := []*AccessReviewItem{}
+	addItems := func(targetType string, target string, memberType string, members []string) error {
+		for _, member := range members {
+			// wildcards like "org/*" aren't individual memberships
+			if strings.HasSuffix(member, "/*") {
+				continue
+			}
+
+			reviewer, err := getAccessReviewer(accessReview, member)
+			if err != nil {
+				return err
+			}
+
+			items = append(items, &AccessReviewItem{
+				Owner:       accessReview.Owner,
+				Name:        util.GenerateId(),
+				CreatedTime: util.GetCurrentTime(),
+				Review:      accessReview.Name,
+				TargetType:  targetType,
+				Target:      target,
+				MemberType:  memberType,
+				Member:      member,
+				Reviewer:    reviewer,
+			})
+		}
+		return nil
+	}
+
+	for _, role := range roles {
+		err = addItems(AssignmentTargetRole, role.GetId(), AssignmentMemberUser, role.Users)
+		if err == nil {
+			err = addItems(AssignmentTargetRole, role.GetId(), AssignmentMemberGroup, role.Groups)
+		}
+		if err == nil {
+			err = addItems(AssignmentTargetRole, role.GetId(), AssignmentMemberRole, role.Roles)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	for _, permission := range permissions {
+		err = addItems(AssignmentTargetPermission, permission.GetId(), AssignmentMemberUser, permission.Users)
+		if err == nil {
+			err = addItems(AssignmentTargetPermission, permission.GetId(), AssignmentMemberGroup, permission.Groups)
+		}
+		if err == nil {
+			err = addItems(AssignmentTargetPermission, permission.GetId(), AssignmentMemberRole, permission.Roles)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	accessReview.State = AccessReviewStateActive
+	accessReview.StartTime = util.GetCurrentTime()
+	accessReview.ItemCount = len(items)
+	affected, err := ormer.Engine.ID(core.PK{accessReview.Owner, accessReview.Name}).Where("state = ?", AccessReviewStateDraft).
+		Cols("state", "start_time", "item_count").Update(accessReview)
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if len(items) != 0 {
+		_, err = ormer.Engine.Insert(items)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// DecideAccessReviewItem certifies or revokes the membership, only the reviewer of the item or an admin can decide
+func DecideAccessReviewItem(id string, user *User, decision string, comment string, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	accessReviewItem := AccessReviewItem{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&accessReviewItem)
+	if err != nil {
+		return false, err
+	}
+	if !existed {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review item: %s does not exist"), id)
+	}
+
+	accessReview, err := getAccessReview(accessReviewItem.Owner, accessReviewItem.Review)
+	if err != nil {
+		return false, err
+	}
+	if accessReview == nil || accessReview.State != AccessReviewStateActive {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review is not active"))
+	}
+
+	if accessReviewItem.Reviewer != user.GetId() && !user.IsGlobalAdmin() && !(user.IsAdmin && user.Owner == accessReviewItem.Owner) {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+	if decision != AccessReviewDecisionCertified && decision != AccessReviewDecisionRevoked {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:Unknown decision: %s"), decision)
+	}
+
+	accessReviewItem.Reviewer = user.GetId()
+	accessReviewItem.Decision = decision
+	accessReviewItem.Comment = comment
+	accessReviewItem.DecidedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).Cols("reviewer", "decision", "comment", "decided_time").Update(&accessReviewItem)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// CloseAccessReview ends the campaign and removes the revoked memberships, undecided items are left as they are
+func CloseAccessReview(id string, lang string) (bool, error) {
+	accessReview, err := GetAccessReview(id)
+	if err != nil {
+		return false, err
+	}
+	if accessReview == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review: %s does not exist"), id)
+	}
+	if accessReview.State != AccessReviewStateActive {
+		return false, fmt.Errorf(i18n.Translate(lang, "accessReview:The access review is not active"))
+	}
+
+	accessReview.State = AccessReviewStateClosed
+	accessReview.ClosedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{accessReview.Owner, accessReview.Name}).Where("state = ?", AccessReviewStateActive).
+		Cols("state", "closed_time").Update(accessReview)
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	items := []*AccessReviewItem{}
+	err = ormer.Engine.Where("owner = ? and review = ? and decision = ?", accessReview.Owner, accessReview.Name, AccessReviewDecisionRevoked).Find(&items)
+	if err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		// a campaign only changes its own organization
+		targetOwner, _ := util.GetOwnerAndNameFromIdNoCheck(item.Target)
+		if targetOwner != accessReview.Owner {
+			continue
+		}
+
+		memberType := item.MemberType
+		if memberType == "" {
+			memberType = AssignmentMemberUser
+		}
+
+		assignment := &Assignment{TargetType: item.TargetType, Target: item.Target, MemberType: memberType, Member: item.Member}
+		_, err = updateAssignmentMembership(assignment, false)
+		if err != nil {
+			return false, err
+		}
+
+		item.IsApplied = true
+		_, err = ormer.Engine.ID(core.PK{item.Owner, item.Name}).Cols("is_applied").Update(item)
+		if err != nil {
+			return false, err
+		}
+
+		addSystemRecord(item.Owner, item.Member, "revoke-access-review-item", item)
+	}
+
+	return true, nil
+}
+
+// ExportAccessReview returns the results of the campaign as an xlsx file for the auditors
+func ExportAccessReview(accessReview *AccessReview) ([]byte, error) {
+	items, err := GetAccessReviewItems(accessReview.Owner, accessReview.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	table := [][]string{
+		{"Campaign", accessReview.DisplayName},
+		{"Started", accessReview.StartTime},
+		{"Closed", accessReview.ClosedTime},
+		{},
+		{"Type", "Target", "Member type", "Member", "Reviewer", "Decision", "Comment", "Decided time", "Applied"},
+	}
+	for _, item := range items {
+		table = append(table, []string{
+			item.TargetType, item.Target, item.MemberType, item.Member, item.Reviewer, item.Decision, item.Comment, item.DecidedTime, fmt.Sprintf("%t", item.IsApplied),
+		})
+	}
+
+	return xlsx.WriteXlsxFile(accessReview.Name, table)
+}
+
+func closeDueAccessReviews() error {
+	accessReviews := []*AccessReview{}
+	err := ormer.Engine.Where("state = ? and end_time != ?", AccessReviewStateActive, "").Find(&accessReviews)
+	if err != nil {
+		return err
+	}
+
+	for _, accessReview := range accessReviews {
+		endTime, err := time.Parse(time.RFC3339, accessReview.EndTime)
+		if err != nil || endTime.After(time.Now()) {
+			continue
+		}
+
+		_, err = CloseAccessReview(accessReview.GetId(), "en")
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to close the access review: %s, error %s", accessReview.GetId(), err))
+		}
+	}
+	return nil
+}
+
+// RunAccessReviewJob closes the active campaigns whose end time has passed
+func RunAccessReviewJob() {
+	for {
+		err := closeDueAccessReviews()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("access review job failed, error %s", err))
+		}
+
+		time.Sleep(accessReviewInterval)
+	}
+}
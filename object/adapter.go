@@ -41,6 +41,8 @@ type Adapter struct {
 	Database     string `xorm:"varchar(100)" json:"database"`
 
 	*xormadapter.Adapter `xorm:"-" json:"-"`
+	engine               *xorm.Engine
+	tableName            string
 }
 
 func GetAdapterCount(owner, field, value string) (int64, error) {
@@ -98,6 +100,8 @@ func UpdateAdapter(id string, adapter *Adapter) (bool, error) {
 		return false, err
 	}
 
+	addPolicyBaselines(PolicyVersionTypeAdapter, id)
+
 	if name != adapter.Name {
 		err := adapterChangeTrigger(name, adapter.Name)
 		if err != nil {
@@ -114,6 +118,14 @@ func UpdateAdapter(id string, adapter *Adapter) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		// a renamed adapter leaves an empty version under its old id
+		if id != adapter.GetId() {
+			addPolicyVersions(PolicyVersionTypeAdapter, "rename", id)
+		}
+		addPolicyVersions(PolicyVersionTypeAdapter, "update", adapter.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -123,15 +135,25 @@ func AddAdapter(adapter *Adapter) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeAdapter, "add", adapter.GetId())
+	}
+
 	return affected != 0, nil
 }
 
 func DeleteAdapter(adapter *Adapter) (bool, error) {
+	addPolicyBaselines(PolicyVersionTypeAdapter, adapter.GetId())
+
 	affected, err := ormer.Engine.ID(core.PK{adapter.Owner, adapter.Name}).Delete(&Adapter{})
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeAdapter, "delete", adapter.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -202,6 +224,9 @@ func (adapter *Adapter) InitAdapter() error {
 		return err
 	}
 
+	adapter.engine = engine
+	adapter.tableName = tableName
+
 	return nil
 }
 
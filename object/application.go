@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
@@ -48,6 +49,7 @@ type Application struct {
 	EnableSigninSession bool            `json:"enableSigninSession"`
 	EnableAutoSignin    bool            `json:"enableAutoSignin"`
 	EnableCodeSignin    bool            `json:"enableCodeSignin"`
+	EnableMagicLink     bool            `json:"enableMagicLink"`
 	EnableSamlCompress  bool            `json:"enableSamlCompress"`
 	EnableWebAuthn      bool            `json:"enableWebAuthn"`
 	EnableLinkWithEmail bool            `json:"enableLinkWithEmail"`
@@ -80,6 +82,8 @@ type Application struct {
 	FormOffset           int        `json:"formOffset"`
 	FormSideHtml         string     `xorm:"mediumtext" json:"formSideHtml"`
 	FormBackgroundUrl    string     `xorm:"varchar(200)" json:"formBackgroundUrl"`
+
+	Provisioning *ProvisioningConfig `xorm:"json" json:"provisioning"`
 }
 
 func GetApplicationCount(owner, field, value string) (int64, error) {
@@ -134,8 +138,8 @@ func GetPaginationOrganizationApplications(owner, organization string, offset, l
 	return applications, nil
 }
 
-func getProviderMap(owner string) (m map[string]*Provider, err error) {
-	providers, err := GetProviders(owner)
+func getProviderMap(ctx context.Context, owner string) (m map[string]*Provider, err error) {
+	providers, err := getProviders(ctx, owner)
 	if err != nil {
 		return nil, err
 	}
@@ -157,8 +161,8 @@ func getProviderMap(owner string) (m map[string]*Provider, err error) {
 	return m, err
 }
 
-func extendApplicationWithProviders(application *Application) (err error) {
-	m, err := getProviderMap(application.Organization)
+func extendApplicationWithProviders(ctx context.Context, application *Application) (err error) {
+	m, err := getProviderMap(ctx, application.Organization)
 	if err != nil {
 		return err
 	}
@@ -172,30 +176,30 @@ func extendApplicationWithProviders(application *Application) (err error) {
 	return
 }
 
-func extendApplicationWithOrg(application *Application) (err error) {
-	organization, err := getOrganization(application.Owner, application.Organization)
+func extendApplicationWithOrg(ctx context.Context, application *Application) (err error) {
+	organization, err := getOrganization(ctx, application.Owner, application.Organization)
 	application.OrganizationObj = organization
 	return
 }
 
-func getApplication(owner string, name string) (*Application, error) {
+func getApplication(ctx context.Context, owner string, name string) (*Application, error) {
 	if owner == "" || name == "" {
 		return nil, nil
 	}
 
 	application := Application{Owner: owner, Name: name}
-	existed, err := ormer.Engine.Get(&application)
+	existed, err := ormer.Engine.Context(ctx).Get(&application)
 	if err != nil {
 		return nil, err
 	}
 
 	if existed {
-		err = extendApplicationWithProviders(&application)
+		err = extendApplicationWithProviders(ctx, &application)
 		if err != nil {
 			return nil, err
 		}
 
-		err = extendApplicationWithOrg(&application)
+		err = extendApplicationWithOrg(ctx, &application)
 		if err != nil {
 			return nil, err
 		}
@@ -214,12 +218,12 @@ func GetApplicationByOrganizationName(organization string) (*Application, error)
 	}
 
 	if existed {
-		err = extendApplicationWithProviders(&application)
+		err = extendApplicationWithProviders(context.Background(), &application)
 		if err != nil {
 			return nil, err
 		}
 
-		err = extendApplicationWithOrg(&application)
+		err = extendApplicationWithOrg(context.Background(), &application)
 		if err != nil {
 			return nil, err
 		}
@@ -232,7 +236,7 @@ func GetApplicationByOrganizationName(organization string) (*Application, error)
 
 func GetApplicationByUser(user *User) (*Application, error) {
 	if user.SignupApplication != "" {
-		return getApplication("admin", user.SignupApplication)
+		return getApplication(context.Background(), "admin", user.SignupApplication)
 	} else {
 		return GetApplicationByOrganizationName(user.Owner)
 	}
@@ -241,7 +245,7 @@ func GetApplicationByUser(user *User) (*Application, error) {
 func GetApplicationByUserId(userId string) (application *Application, err error) {
 	owner, name := util.GetOwnerAndNameFromId(userId)
 	if owner == "app" {
-		application, err = getApplication("admin", name)
+		application, err = getApplication(context.Background(), "admin", name)
 		return
 	}
 
@@ -261,12 +265,12 @@ func GetApplicationByClientId(clientId string) (*Application, error) {
 	}
 
 	if existed {
-		err = extendApplicationWithProviders(&application)
+		err = extendApplicationWithProviders(context.Background(), &application)
 		if err != nil {
 			return nil, err
 		}
 
-		err = extendApplicationWithOrg(&application)
+		err = extendApplicationWithOrg(context.Background(), &application)
 		if err != nil {
 			return nil, err
 		}
@@ -277,9 +281,9 @@ func GetApplicationByClientId(clientId string) (*Application, error) {
 	}
 }
 
-func GetApplication(id string) (*Application, error) {
+func GetApplication(ctx context.Context, id string) (*Application, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	return getApplication(owner, name)
+	return getApplication(ctx, owner, name)
 }
 
 func GetMaskedApplication(application *Application, userId string) *Application {
@@ -312,12 +316,19 @@ func GetMaskedApplication(application *Application, userId string) *Application
 		if application.OrganizationObj.PasswordSalt != "" {
 			application.OrganizationObj.PasswordSalt = "***"
 		}
+		if application.OrganizationObj.PasswordHashConfig != "" {
+			application.OrganizationObj.PasswordHashConfig = "***"
+		}
 	}
 
 	if application.InvitationCodes != nil {
 		application.InvitationCodes = []string{"***"}
 	}
 
+	if application.Provisioning != nil && application.Provisioning.Token != "" {
+		application.Provisioning.Token = "***"
+	}
+
 	return application
 }
 
@@ -334,7 +345,7 @@ func GetMaskedApplications(applications []*Application, userId string) []*Applic
 
 func UpdateApplication(id string, application *Application) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	oldApplication, err := getApplication(owner, name)
+	oldApplication, err := getApplication(context.Background(), owner, name)
 	if oldApplication == nil {
 		return false, err
 	}
@@ -363,6 +374,10 @@ func UpdateApplication(id string, application *Application) (bool, error) {
 		providerItem.Provider = nil
 	}
 
+	if application.Provisioning != nil && application.Provisioning.Token == "***" && oldApplication.Provisioning != nil {
+		application.Provisioning.Token = oldApplication.Provisioning.Token
+	}
+
 	session := ormer.Engine.ID(core.PK{owner, name}).AllCols()
 	if application.ClientSecret == "***" {
 		session.Omit("client_secret")
@@ -0,0 +1,537 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	ApprovalTypePermission = "Permission"
+	ApprovalTypeRole       = "Role"
+)
+
+const (
+	ApprovalStatePending  = "Pending"
+	ApprovalStateApproved = "Approved"
+	ApprovalStateRejected = "Rejected"
+	ApprovalStateCanceled = "Canceled"
+)
+
+const (
+	ApprovalStepManager = "Manager"
+	ApprovalStepOwner   = "Owner"
+)
+
+const (
+	PermissionStatePending  = "Pending"
+	PermissionStateApproved = "Approved"
+)
+
+const approvalInterval = time.Minute
+
+type ApprovalStep struct {
+	Name        string   `json:"name"`
+	Approvers   []string `json:"approvers"`
+	IsEscalated bool     `json:"isEscalated"`
+	State       string   `json:"state"`
+	Approver    string   `json:"approver"`
+	Time        string   `json:"time"`
+	Comment     string   `json:"comment"`
+}
+
+type ApprovalEvent struct {
+	Time    string `json:"time"`
+	User    string `json:"user"`
+	Action  string `json:"action"`
+	Comment string `json:"comment"`
+}
+
+// ApprovalRequest asks for a permission submitted by a user to be enabled, or for the user to be added to a role.
+// It goes through the steps in order: the managers of the user's groups first, then the owners of the target
+type ApprovalRequest struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Type      string `xorm:"varchar(100)" json:"type"`
+	Target    string `xorm:"varchar(100) index" json:"target"`
+	Submitter string `xorm:"varchar(100) index" json:"submitter"`
+	Reason    string `xorm:"varchar(1000)" json:"reason"`
+
+	Steps         []*ApprovalStep  `xorm:"mediumtext" json:"steps"`
+	CurrentStep   int              `json:"currentStep"`
+	StepStartTime string           `xorm:"varchar(100)" json:"stepStartTime"`
+	State         string           `xorm:"varchar(100) index" json:"state"`
+	Message       string           `xorm:"varchar(1000)" json:"message"`
+	History       []*ApprovalEvent `xorm:"mediumtext" json:"history"`
+}
+
+func GetApprovalRequestCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&ApprovalRequest{})
+}
+
+func GetApprovalRequests(owner string) ([]*ApprovalRequest, error) {
+	approvalRequests := []*ApprovalRequest{}
+	err := ormer.Engine.Desc("created_time").Find(&approvalRequests, &ApprovalRequest{Owner: owner})
+	if err != nil {
+		return approvalRequests, err
+	}
+
+	return approvalRequests, nil
+}
+
+func GetPaginationApprovalRequests(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*ApprovalRequest, error) {
+	approvalRequests := []*ApprovalRequest{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&approvalRequests)
+	if err != nil {
+		return approvalRequests, err
+	}
+
+	return approvalRequests, nil
+}
+
+func getApprovalRequest(owner string, name string) (*ApprovalRequest, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	approvalRequest := ApprovalRequest{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&approvalRequest)
+	if err != nil {
+		return &approvalRequest, err
+	}
+
+	if existed {
+		return &approvalRequest, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetApprovalRequest(id string) (*ApprovalRequest, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	return getApprovalRequest(owner, name)
+}
+
+// GetPendingApprovalRequests returns the requests waiting for the user at their current step
+func GetPendingApprovalRequests(user *User) ([]*ApprovalRequest, error) {
+	approvalRequests := []*ApprovalRequest{}
+	err := ormer.Engine.Where("state = ? and steps like ?", ApprovalStatePending, "%\""+user.GetId()+"\"%").Desc("created_time").Find(&approvalRequests)
+	if err != nil {
+		return approvalRequests, err
+	}
+
+	res := []*ApprovalRequest{}
+	for _, approvalRequest := range approvalRequests {
+		if util.InSlice(approvalRequest.getCurrentStep().Approvers, user.GetId()) {
+			res = append(res, approvalRequest)
+		}
+	}
+	return res, nil
+}
+
+func (approvalRequest *ApprovalRequest) GetId() string {
+	return fmt.Sprintf("%s/%s", approvalRequest.Owner, approvalRequest.Name)
+}
+
+func (approvalRequest *ApprovalRequest) getCurrentStep() *ApprovalStep {
+	if approvalRequest.CurrentStep >= len(approvalRequest.Steps) {
+		return &ApprovalStep{}
+	}
+	return approvalRequest.Steps[approvalRequest.CurrentStep]
+}
+
+func (approvalRequest *ApprovalRequest) addEvent(user string, action string, comment string) {
+	approvalRequest.UpdatedTime = util.GetCurrentTime()
+	approvalRequest.History = append(approvalRequest.History, &ApprovalEvent{
+		Time:    approvalRequest.UpdatedTime,
+		User:    user,
+		Action:  action,
+		Comment: comment,
+	})
+}
+
+func (p *Permission) isApproved() bool {
+	return p.State != PermissionStatePending
+}
+
+// getManagerApprovers returns the managers of the groups of the user
+func getManagerApprovers(user *User) ([]string, error) {
+	approvers := []string{}
+	for _, groupId := range user.Groups {
+		group, err := GetGroup(groupId)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil || group.Manager == "" {
+			continue
+		}
+
+		manager := util.GetId(group.Owner, group.Manager)
+		if manager != user.GetId() && !util.InSlice(approvers, manager) {
+			approvers = append(approvers, manager)
+		}
+	}
+	return approvers, nil
+}
+
+// getOwnerApprovers returns the admins of the organization owning the role or the permission
+func getOwnerApprovers(owner string) ([]string, error) {
+	users := []*User{}
+	err := ormer.Engine.Where("owner = ? and is_admin = ? and is_deleted = ?", owner, true, false).Find(&users)
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := []string{}
+	for _, user := range users {
+		approvers = append(approvers, user.GetId())
+	}
+	return approvers, nil
+}
+
+func getApprovalSteps(user *User, owner string) ([]*ApprovalStep, error) {
+	steps := []*ApprovalStep{}
+
+	managers, err := getManagerApprovers(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(managers) != 0 {
+		steps = append(steps, &ApprovalStep{Name: ApprovalStepManager, Approvers: managers, State: ApprovalStatePending})
+	}
+
+	owners, err := getOwnerApprovers(owner)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, &ApprovalStep{Name: ApprovalStepOwner, Approvers: owners, State: ApprovalStatePending})
+	return steps, nil
+}
+
+// SubmitApprovalRequest routes the request of the user to the approvers of its first step
+func SubmitApprovalRequest(approvalRequest *ApprovalRequest, user *User, lang string) (bool, error) {
+	owner, _ := util.GetOwnerAndNameFromIdNoCheck(approvalRequest.Target)
+	switch approvalRequest.Type {
+	case ApprovalTypePermission:
+		permission, err := GetPermission(approvalRequest.Target)
+		if err != nil {
+			return false, err
+		}
+		if permission == nil {
+			return false, fmt.Errorf(i18n.Translate(lang, "assignment:The permission: %s does not exist"), approvalRequest.Target)
+		}
+		if permission.isApproved() {
+			return false, fmt.Errorf(i18n.Translate(lang, "approval:The permission is already approved"))
+		}
+		if permission.Owner != user.Owner || permission.Submitter != user.Name {
+			return false, fmt.Errorf(i18n.Translate(lang, "approval:Only the submitter of the permission can request its approval"))
+		}
+	case ApprovalTypeRole:
+		role, err := GetRole(approvalRequest.Target)
+		if err != nil {
+			return false, err
+		}
+		if role == nil {
+			return false, fmt.Errorf(i18n.Translate(lang, "assignment:The role: %s does not exist"), approvalRequest.Target)
+		}
+		if util.InSlice(role.Users, user.GetId()) {
+			return false, fmt.Errorf(i18n.Translate(lang, "approval:The user already has the role"))
+		}
+	default:
+		return false, fmt.Errorf(i18n.Translate(lang, "approval:Unknown type: %s"), approvalRequest.Type)
+	}
+
+	count, err := ormer.Engine.Where("target = ? and submitter = ? and state = ?", approvalRequest.Target, user.GetId(), ApprovalStatePending).Count(&ApprovalRequest{})
+	if err != nil {
+		return false, err
+	}
+	if count != 0 {
+		return false, fmt.Errorf(i18n.Translate(lang, "approval:A request for this target is already pending"))
+	}
+
+	steps, err := getApprovalSteps(user, owner)
+	if err != nil {
+		return false, err
+	}
+
+	approvalRequest.Owner = owner
+	approvalRequest.Submitter = user.GetId()
+	approvalRequest.Steps = steps
+	approvalRequest.CurrentStep = 0
+	approvalRequest.StepStartTime = util.GetCurrentTime()
+	approvalRequest.State = ApprovalStatePending
+	approvalRequest.History = []*ApprovalEvent{}
+	approvalRequest.addEvent(user.GetId(), "submit", approvalRequest.Reason)
+
+	affected, err := ormer.Engine.Insert(approvalRequest)
+	if err != nil {
+		return false, err
+	}
+
+	notifyApprovalRequest(approvalRequest, approvalRequest.getCurrentStep().Approvers,
+		fmt.Sprintf("%s requests the %s: %s, reason: %s", user.GetId(), strings.ToLower(approvalRequest.Type), approvalRequest.Target, approvalRequest.Reason))
+	return affected != 0, nil
+}
+
+func canReviewApprovalRequest(user *User, approvalRequest *ApprovalRequest) bool {
+	if user.GetId() == approvalRequest.Submitter {
+		return false
+	}
+	if user.IsGlobalAdmin() || (user.IsAdmin && user.Owner == approvalRequest.Owner) {
+		return true
+	}
+	return util.InSlice(approvalRequest.getCurrentStep().Approvers, user.GetId())
+}
+
+// updateApprovalRequest saves the request if it's still at the given step, so that two approvers can't both move it forward
+func updateApprovalRequest(approvalRequest *ApprovalRequest, currentStep int) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{approvalRequest.Owner, approvalRequest.Name}).Where("state = ? and current_step = ?", ApprovalStatePending, currentStep).
+		Cols("steps", "current_step", "step_start_time", "state", "message", "history", "updated_time").Update(approvalRequest)
+	if err != nil {
+		return false, err
+	}
+	return affected != 0, nil
+}
+
+// ReviewApprovalRequest approves or rejects the current step of the request, the target is granted once the last step is approved
+func ReviewApprovalRequest(id string, user *User, approved bool, comment string, lang string) (bool, error) {
+	approvalRequest, err := GetApprovalRequest(id)
+	if err != nil {
+		return false, err
+	}
+	if approvalRequest == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "approval:The approval request: %s does not exist"), id)
+	}
+	if approvalRequest.State != ApprovalStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "approval:The approval request is not pending"))
+	}
+	if !canReviewApprovalRequest(user, approvalRequest) {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	currentStep := approvalRequest.CurrentStep
+	step := approvalRequest.getCurrentStep()
+	step.Approver = user.GetId()
+	step.Time = util.GetCurrentTime()
+	step.Comment = comment
+
+	if !approved {
+		step.State = ApprovalStateRejected
+		approvalRequest.State = ApprovalStateRejected
+		approvalRequest.Message = comment
+		approvalRequest.addEvent(user.GetId(), "reject", comment)
+		affected, err := updateApprovalRequest(approvalRequest, currentStep)
+		if err != nil || !affected {
+			return affected, err
+		}
+
+		notifyApprovalRequest(approvalRequest, []string{approvalRequest.Submitter},
+			fmt.Sprintf("Your request for the %s: %s is rejected by %s, reason: %s", strings.ToLower(approvalRequest.Type), approvalRequest.Target, user.GetId(), comment))
+		return true, nil
+	}
+
+	step.State = ApprovalStateApproved
+	approvalRequest.addEvent(user.GetId(), "approve", comment)
+	approvalRequest.CurrentStep += 1
+	approvalRequest.StepStartTime = util.GetCurrentTime()
+	if approvalRequest.CurrentStep >= len(approvalRequest.Steps) {
+		approvalRequest.State = ApprovalStateApproved
+	}
+
+	affected, err := updateApprovalRequest(approvalRequest, currentStep)
+	if err != nil || !affected {
+		return affected, err
+	}
+
+	if approvalRequest.State != ApprovalStateApproved {
+		notifyApprovalRequest(approvalRequest, approvalRequest.getCurrentStep().Approvers,
+			fmt.Sprintf("%s requests the %s: %s, reason: %s", approvalRequest.Submitter, strings.ToLower(approvalRequest.Type), approvalRequest.Target, approvalRequest.Reason))
+		return true, nil
+	}
+
+	err = grantApprovalRequest(approvalRequest, user)
+	if err != nil {
+		return false, err
+	}
+
+	notifyApprovalRequest(approvalRequest, []string{approvalRequest.Submitter},
+		fmt.Sprintf("Your request for the %s: %s is approved", strings.ToLower(approvalRequest.Type), approvalRequest.Target))
+	return true, nil
+}
+
+// grantApprovalRequest enables the permission, its policies are only added now, or adds the submitter to the role
+func grantApprovalRequest(approvalRequest *ApprovalRequest, approver *User) error {
+	if approvalRequest.Type == ApprovalTypeRole {
+		role, err := GetRole(approvalRequest.Target)
+		if err != nil || role == nil {
+			return err
+		}
+
+		if !util.InSlice(role.Users, approvalRequest.Submitter) {
+			role.Users = append(role.Users, approvalRequest.Submitter)
+			_, err = UpdateRole(role.GetId(), role)
+		}
+		return err
+	}
+
+	permission, err := GetPermission(approvalRequest.Target)
+	if err != nil || permission == nil {
+		return err
+	}
+
+	permission.State = PermissionStateApproved
+	permission.Approver = approver.Name
+	permission.ApproveTime = util.GetCurrentTime()
+	permission.IsEnabled = true
+	_, err = UpdatePermission(permission.GetId(), permission)
+	return err
+}
+
+// CancelApprovalRequest withdraws a pending request, only its submitter can do it
+func CancelApprovalRequest(id string, user *User, lang string) (bool, error) {
+	approvalRequest, err := GetApprovalRequest(id)
+	if err != nil {
+		return false, err
+	}
+	if approvalRequest == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "approval:The approval request: %s does not exist"), id)
+	}
+	if approvalRequest.Submitter != user.GetId() {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	approvalRequest.State = ApprovalStateCanceled
+	approvalRequest.addEvent(user.GetId(), "cancel", "")
+	return updateApprovalRequest(approvalRequest, approvalRequest.CurrentStep)
+}
+
+// notifyApprovalRequest sends the message by email to the given users and to the notification providers of the organization,
+// failures are only logged
+func notifyApprovalRequest(approvalRequest *ApprovalRequest, userIds []string, content string) {
+	providers, err := GetProviders(approvalRequest.Owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the providers of %s, error %s", approvalRequest.Owner, err))
+		return
+	}
+
+	util.SafeGoroutine(func() {
+		var emailProvider *Provider
+		for _, provider := range providers {
+			if provider.Category == "Notification" {
+				err = SendNotification(provider, content)
+				if err != nil {
+					logs.Warning(fmt.Sprintf("failed to notify the approval request: %s, error %s", approvalRequest.GetId(), err))
+				}
+			} else if provider.Category == "Email" && emailProvider == nil {
+				emailProvider = provider
+			}
+		}
+
+		if emailProvider == nil {
+			return
+		}
+
+		title := fmt.Sprintf("Approval request: %s", approvalRequest.Target)
+		for _, userId := range userIds {
+			user, err := GetUser(userId)
+			if err != nil || user == nil || user.Email == "" {
+				continue
+			}
+
+			err = SendEmail(context.Background(), emailProvider, title, content, user.Email, approvalRequest.Owner)
+			if err != nil {
+				logs.Warning(fmt.Sprintf("failed to email the approval request: %s to %s, error %s", approvalRequest.GetId(), userId, err))
+			}
+		}
+	})
+}
+
+// escalateApprovalRequest adds the admins of the organization and the global admins to a step that waited too long
+func escalateApprovalRequest(approvalRequest *ApprovalRequest) error {
+	approvers, err := getOwnerApprovers(approvalRequest.Owner)
+	if err != nil {
+		return err
+	}
+	globalAdmins, err := getOwnerApprovers("built-in")
+	if err != nil {
+		return err
+	}
+
+	step := approvalRequest.getCurrentStep()
+	for _, approver := range append(approvers, globalAdmins...) {
+		if !util.InSlice(step.Approvers, approver) {
+			step.Approvers = append(step.Approvers, approver)
+		}
+	}
+	step.IsEscalated = true
+	approvalRequest.addEvent("", "escalate", "")
+
+	affected, err := updateApprovalRequest(approvalRequest, approvalRequest.CurrentStep)
+	if err != nil || !affected {
+		return err
+	}
+
+	addSystemRecord(approvalRequest.Owner, "", "escalate-approval-request", approvalRequest)
+	notifyApprovalRequest(approvalRequest, step.Approvers,
+		fmt.Sprintf("The request of %s for the %s: %s is escalated to you, reason: %s", approvalRequest.Submitter, strings.ToLower(approvalRequest.Type), approvalRequest.Target, approvalRequest.Reason))
+	return nil
+}
+
+func escalateApprovalRequests() error {
+	approvalRequests := []*ApprovalRequest{}
+	err := ormer.Engine.Where("state = ?", ApprovalStatePending).Find(&approvalRequests)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(getConfigLimit("approvalEscalationTimeout", 48)) * time.Hour
+	for _, approvalRequest := range approvalRequests {
+		stepStartTime, err := time.Parse(time.RFC3339, approvalRequest.StepStartTime)
+		if err != nil || approvalRequest.getCurrentStep().IsEscalated || time.Since(stepStartTime) < timeout {
+			continue
+		}
+
+		err = escalateApprovalRequest(approvalRequest)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to escalate the approval request: %s, error %s", approvalRequest.GetId(), err))
+		}
+	}
+	return nil
+}
+
+// RunApprovalJob escalates the approval requests whose current step has waited longer than the escalation timeout
+func RunApprovalJob() {
+	for {
+		err := escalateApprovalRequests()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("approval job failed, error %s", err))
+		}
+
+		time.Sleep(approvalInterval)
+	}
+}
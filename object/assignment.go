@@ -0,0 +1,510 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	AssignmentTargetRole       = "Role"
+	AssignmentTargetPermission = "Permission"
+)
+
+const (
+	AssignmentMemberUser  = "User"
+	AssignmentMemberGroup = "Group"
+	// a sub-role of a role or a permission, it's only reviewed by access reviews and can't be assigned
+	AssignmentMemberRole = "Role"
+)
+
+const (
+	AssignmentStateRequested = "Requested"
+	AssignmentStateApproved  = "Approved"
+	AssignmentStateRejected  = "Rejected"
+	AssignmentStateActive    = "Active"
+	AssignmentStateExpired   = "Expired"
+	AssignmentStateRevoked   = "Revoked"
+)
+
+const assignmentInterval = 30 * time.Second
+
+// Assignment grants a user or a group a role or a permission for a time window, the member is added to the
+// Users or Groups of the target when the window starts and removed when it ends. A just-in-time assignment is
+// requested by the user and only takes effect once approved
+type Assignment struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	TargetType string `xorm:"varchar(100)" json:"targetType"`
+	Target     string `xorm:"varchar(100) index" json:"target"`
+	MemberType string `xorm:"varchar(100)" json:"memberType"`
+	Member     string `xorm:"varchar(100) index" json:"member"`
+	StartTime  string `xorm:"varchar(100)" json:"startTime"`
+	EndTime    string `xorm:"varchar(100)" json:"endTime"`
+	Reason     string `xorm:"varchar(1000)" json:"reason"`
+	IsJit      bool   `json:"isJit"`
+
+	State         string `xorm:"varchar(100) index" json:"state"`
+	Requester     string `xorm:"varchar(100)" json:"requester"`
+	Approver      string `xorm:"varchar(100)" json:"approver"`
+	ApproveTime   string `xorm:"varchar(100)" json:"approveTime"`
+	Message       string `xorm:"varchar(1000)" json:"message"`
+	ActivatedTime string `xorm:"varchar(100)" json:"activatedTime"`
+	FinishedTime  string `xorm:"varchar(100)" json:"finishedTime"`
+	// the member already belonged to the target before the assignment and the overlapping ones, so it's left there
+	// when the last of them ends
+	AlreadyMember bool `json:"alreadyMember"`
+}
+
+func GetAssignmentCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&Assignment{})
+}
+
+func GetAssignments(owner string) ([]*Assignment, error) {
+	assignments := []*Assignment{}
+	err := ormer.Engine.Desc("created_time").Find(&assignments, &Assignment{Owner: owner})
+	if err != nil {
+		return assignments, err
+	}
+
+	return assignments, nil
+}
+
+func GetPaginationAssignments(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*Assignment, error) {
+	assignments := []*Assignment{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&assignments)
+	if err != nil {
+		return assignments, err
+	}
+
+	return assignments, nil
+}
+
+func getAssignment(owner string, name string) (*Assignment, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	assignment := Assignment{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&assignment)
+	if err != nil {
+		return &assignment, err
+	}
+
+	if existed {
+		return &assignment, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetAssignment(id string) (*Assignment, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	return getAssignment(owner, name)
+}
+
+func (assignment *Assignment) GetId() string {
+	return fmt.Sprintf("%s/%s", assignment.Owner, assignment.Name)
+}
+
+func parseAssignmentTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+func checkAssignmentValid(assignment *Assignment, lang string) error {
+	switch assignment.TargetType {
+	case AssignmentTargetRole:
+		role, err := GetRole(assignment.Target)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The role: %s does not exist"), assignment.Target)
+		}
+		if role.Owner != assignment.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The target: %s does not belong to the organization: %s"), assignment.Target, assignment.Owner)
+		}
+	case AssignmentTargetPermission:
+		permission, err := GetPermission(assignment.Target)
+		if err != nil {
+			return err
+		}
+		if permission == nil {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The permission: %s does not exist"), assignment.Target)
+		}
+		if permission.Owner != assignment.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The target: %s does not belong to the organization: %s"), assignment.Target, assignment.Owner)
+		}
+	default:
+		return fmt.Errorf(i18n.Translate(lang, "assignment:Unknown target type: %s"), assignment.TargetType)
+	}
+
+	switch assignment.MemberType {
+	case AssignmentMemberUser:
+		user, err := GetUser(assignment.Member)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), assignment.Member)
+		}
+		if user.Owner != assignment.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The member: %s does not belong to the organization: %s"), assignment.Member, assignment.Owner)
+		}
+	case AssignmentMemberGroup:
+		group, err := GetGroup(assignment.Member)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The group: %s does not exist"), assignment.Member)
+		}
+		if group.Owner != assignment.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:The member: %s does not belong to the organization: %s"), assignment.Member, assignment.Owner)
+		}
+	default:
+		return fmt.Errorf(i18n.Translate(lang, "assignment:Unknown member type: %s"), assignment.MemberType)
+	}
+
+	if assignment.StartTime == "" {
+		assignment.StartTime = util.GetCurrentTime()
+	}
+	startTime, err := parseAssignmentTime(assignment.StartTime)
+	if err != nil {
+		return err
+	}
+
+	if assignment.EndTime == "" {
+		if assignment.IsJit {
+			return fmt.Errorf(i18n.Translate(lang, "assignment:A just-in-time assignment must have an end time"))
+		}
+		return nil
+	}
+
+	endTime, err := parseAssignmentTime(assignment.EndTime)
+	if err != nil {
+		return err
+	}
+	if !endTime.After(startTime) {
+		return fmt.Errorf(i18n.Translate(lang, "assignment:The end time must be after the start time"))
+	}
+
+	maxHours := getConfigLimit("jitMaxDuration", 8)
+	if assignment.IsJit && endTime.Sub(startTime) > time.Duration(maxHours)*time.Hour {
+		return fmt.Errorf(i18n.Translate(lang, "assignment:A just-in-time assignment can't last more than %d hours"), maxHours)
+	}
+	return nil
+}
+
+// AddAssignment schedules an assignment made by an admin, it's activated right away when its window has started
+func AddAssignment(assignment *Assignment, approver string, lang string) (bool, error) {
+	assignment.IsJit = false
+	err := checkAssignmentValid(assignment, lang)
+	if err != nil {
+		return false, err
+	}
+
+	assignment.State = AssignmentStateApproved
+	assignment.Approver = approver
+	assignment.ApproveTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.Insert(assignment)
+	if err != nil {
+		return false, err
+	}
+
+	err = runAssignment(assignment)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// RequestAssignment files a just-in-time elevation request of the user for themselves
+func RequestAssignment(assignment *Assignment, user *User, lang string) (bool, error) {
+	assignment.Owner = user.Owner
+	assignment.MemberType = AssignmentMemberUser
+	assignment.Member = user.GetId()
+	assignment.Requester = user.GetId()
+	assignment.IsJit = true
+	err := checkAssignmentValid(assignment, lang)
+	if err != nil {
+		return false, err
+	}
+
+	assignment.State = AssignmentStateRequested
+	affected, err := ormer.Engine.Insert(assignment)
+	if err != nil {
+		return false, err
+	}
+
+	addSystemRecord(assignment.Owner, user.Name, "request-assignment", assignment)
+	return affected != 0, nil
+}
+
+func ReviewAssignment(id string, approver string, approved bool, message string, lang string) (bool, error) {
+	assignment, err := GetAssignment(id)
+	if err != nil {
+		return false, err
+	}
+	if assignment == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "assignment:The assignment: %s does not exist"), id)
+	}
+	if assignment.State != AssignmentStateRequested {
+		return false, fmt.Errorf(i18n.Translate(lang, "assignment:The assignment is not requested"))
+	}
+
+	assignment.Approver = approver
+	assignment.ApproveTime = util.GetCurrentTime()
+	assignment.Message = message
+	if approved {
+		assignment.State = AssignmentStateApproved
+	} else {
+		assignment.State = AssignmentStateRejected
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{assignment.Owner, assignment.Name}).Where("state = ?", AssignmentStateRequested).
+		Cols("approver", "approve_time", "message", "state").Update(assignment)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 && approved {
+		err = runAssignment(assignment)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return affected != 0, nil
+}
+
+// RevokeAssignment ends an assignment before its end time, or cancels it if it has not started yet
+func RevokeAssignment(id string, message string, lang string) (bool, error) {
+	assignment, err := GetAssignment(id)
+	if err != nil {
+		return false, err
+	}
+	if assignment == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "assignment:The assignment: %s does not exist"), id)
+	}
+
+	switch assignment.State {
+	case AssignmentStateActive:
+		return finishAssignment(assignment, AssignmentStateRevoked, message)
+	case AssignmentStateRequested, AssignmentStateApproved:
+		assignment.Message = message
+		assignment.FinishedTime = util.GetCurrentTime()
+		assignment.State = AssignmentStateRevoked
+		affected, err := ormer.Engine.ID(core.PK{assignment.Owner, assignment.Name}).In("state", AssignmentStateRequested, AssignmentStateApproved).
+			Cols("message", "finished_time", "state").Update(assignment)
+		return affected != 0, err
+	default:
+		return false, fmt.Errorf(i18n.Translate(lang, "assignment:The assignment has already ended"))
+	}
+}
+
+// updateAssignmentMembership adds the member to the Users, Groups or Roles of the target, or removes it from them,
+// the target is updated through UpdateRole or UpdatePermission so that its Casbin policies follow.
+// It returns whether the member belonged to the target before
+func updateAssignmentMembership(assignment *Assignment, isAdded bool) (bool, error) {
+	update := func(members []string) ([]string, bool) {
+		isMember := util.InSlice(members, assignment.Member)
+		if isAdded && !isMember {
+			members = append(members, assignment.Member)
+		} else if !isAdded && isMember {
+			members = util.DeleteVal(members, assignment.Member)
+		}
+		return members, isMember
+	}
+
+	var isMember bool
+	if assignment.TargetType == AssignmentTargetRole {
+		role, err := GetRole(assignment.Target)
+		if err != nil || role == nil {
+			return false, err
+		}
+
+		if assignment.MemberType == AssignmentMemberGroup {
+			role.Groups, isMember = update(role.Groups)
+		} else if assignment.MemberType == AssignmentMemberRole {
+			role.Roles, isMember = update(role.Roles)
+		} else {
+			role.Users, isMember = update(role.Users)
+		}
+		if isMember != isAdded {
+			_, err = UpdateRole(role.GetId(), role)
+		}
+		return isMember, err
+	}
+
+	permission, err := GetPermission(assignment.Target)
+	if err != nil || permission == nil {
+		return false, err
+	}
+
+	if assignment.MemberType == AssignmentMemberGroup {
+		permission.Groups, isMember = update(permission.Groups)
+	} else if assignment.MemberType == AssignmentMemberRole {
+		permission.Roles, isMember = update(permission.Roles)
+	} else {
+		permission.Users, isMember = update(permission.Users)
+	}
+	if isMember != isAdded {
+		_, err = UpdatePermission(permission.GetId(), permission)
+	}
+	return isMember, err
+}
+
+// getOverlappingAssignments returns the other active assignments of the same member to the same target
+func getOverlappingAssignments(assignment *Assignment) ([]*Assignment, error) {
+	assignments := []*Assignment{}
+	err := ormer.Engine.Where("name <> ? and state = ?", assignment.Name, AssignmentStateActive).
+		Find(&assignments, &Assignment{Owner: assignment.Owner, TargetType: assignment.TargetType, Target: assignment.Target, MemberType: assignment.MemberType, Member: assignment.Member})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+func activateAssignment(assignment *Assignment) error {
+	assignment.State = AssignmentStateActive
+	assignment.ActivatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{assignment.Owner, assignment.Name}).Where("state = ?", AssignmentStateApproved).
+		Cols("state", "activated_time").Update(assignment)
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	isMember, err := updateAssignmentMembership(assignment, true)
+	if err != nil {
+		return err
+	}
+
+	// while other assignments grant the membership, it's only kept afterward if it existed before all of them
+	overlappingAssignments, err := getOverlappingAssignments(assignment)
+	if err != nil {
+		return err
+	}
+	assignment.AlreadyMember = isMember
+	if len(overlappingAssignments) != 0 {
+		assignment.AlreadyMember = overlappingAssignments[0].AlreadyMember
+	}
+
+	_, err = ormer.Engine.ID(core.PK{assignment.Owner, assignment.Name}).Cols("already_member").Update(assignment)
+	if err != nil {
+		return err
+	}
+
+	addSystemRecord(assignment.Owner, assignment.Member, "activate-assignment", assignment)
+	return nil
+}
+
+func finishAssignment(assignment *Assignment, state string, message string) (bool, error) {
+	assignment.State = state
+	assignment.Message = message
+	assignment.FinishedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{assignment.Owner, assignment.Name}).Where("state = ?", AssignmentStateActive).
+		Cols("state", "message", "finished_time").Update(assignment)
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	// the membership is kept while it's granted by another assignment, the last one removes it
+	overlappingAssignments, err := getOverlappingAssignments(assignment)
+	if err != nil {
+		return false, err
+	}
+	if !assignment.AlreadyMember && len(overlappingAssignments) == 0 {
+		_, err = updateAssignmentMembership(assignment, false)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	action := "expire-assignment"
+	if state == AssignmentStateRevoked {
+		action = "revoke-assignment"
+	}
+	addSystemRecord(assignment.Owner, assignment.Member, action, assignment)
+	return true, nil
+}
+
+// runAssignment activates the assignment when its window has started and expires it when its window has ended
+func runAssignment(assignment *Assignment) error {
+	now := time.Now()
+
+	if assignment.State == AssignmentStateApproved {
+		startTime, err := parseAssignmentTime(assignment.StartTime)
+		if err != nil || startTime.After(now) {
+			return err
+		}
+
+		err = activateAssignment(assignment)
+		if err != nil {
+			return err
+		}
+	}
+
+	if assignment.State == AssignmentStateActive && assignment.EndTime != "" {
+		endTime, err := parseAssignmentTime(assignment.EndTime)
+		if err != nil || endTime.After(now) {
+			return err
+		}
+
+		_, err = finishAssignment(assignment, AssignmentStateExpired, "")
+		return err
+	}
+	return nil
+}
+
+func runAssignments() error {
+	assignments := []*Assignment{}
+	err := ormer.Engine.In("state", AssignmentStateApproved, AssignmentStateActive).Find(&assignments)
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		err = runAssignment(assignment)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to run the assignment: %s, error %s", assignment.GetId(), err))
+		}
+	}
+	return nil
+}
+
+// RunAssignmentJob activates the assignments whose window has started and expires the ones whose window has ended
+func RunAssignmentJob() {
+	for {
+		err := runAssignments()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("assignment job failed, error %s", err))
+		}
+
+		time.Sleep(assignmentInterval)
+	}
+}
@@ -16,6 +16,7 @@ package object
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 
@@ -29,7 +30,7 @@ func InitDefaultStorageProvider() {
 	defaultStorageProviderStr := conf.GetConfigString("defaultStorageProvider")
 	if defaultStorageProviderStr != "" {
 		var err error
-		defaultStorageProvider, err = getProvider("admin", defaultStorageProviderStr)
+		defaultStorageProvider, err = getProvider(context.Background(), "admin", defaultStorageProviderStr)
 		if err != nil {
 			panic(err)
 		}
@@ -81,7 +82,7 @@ func DownloadAndUpload(url string, fullFilePath string, lang string) (err error)
 		return
 	}
 
-	_, _, err = UploadFileSafe(defaultStorageProvider, fullFilePath, fileBuffer, lang)
+	_, _, err = UploadFileSafe(context.Background(), defaultStorageProvider, fullFilePath, fileBuffer, lang)
 	if err != nil {
 		return
 	}
@@ -98,7 +99,7 @@ func getPermanentAvatarUrlFromBuffer(organization string, username string, fileB
 	uploadedFileUrl, _ := GetUploadFileUrl(defaultStorageProvider, fullFilePath, false)
 
 	if upload {
-		_, _, err := UploadFileSafe(defaultStorageProvider, fullFilePath, fileBuffer, "en")
+		_, _, err := UploadFileSafe(context.Background(), defaultStorageProvider, fullFilePath, fileBuffer, "en")
 		if err != nil {
 			return "", err
 		}
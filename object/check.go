@@ -15,11 +15,13 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 	"unicode"
 
+	"github.com/beego/beego/logs"
 	"github.com/casdoor/casdoor/cred"
 	"github.com/casdoor/casdoor/form"
 	"github.com/casdoor/casdoor/i18n"
@@ -190,14 +192,21 @@ func CheckPassword(user *User, password string, lang string, options ...bool) st
 	credManager := cred.GetCredManager(passwordType)
 	if credManager != nil {
 		if organization.MasterPassword != "" {
-			if credManager.IsPasswordCorrect(password, organization.MasterPassword, "", organization.PasswordSalt) {
+			if credManager.IsPasswordCorrect(password, organization.MasterPassword, "", organization.getCredSalt(passwordType)) {
 				resetUserSigninErrorTimes(user)
 				return ""
 			}
 		}
 
-		if credManager.IsPasswordCorrect(password, user.Password, user.PasswordSalt, organization.PasswordSalt) {
+		if credManager.IsPasswordCorrect(password, user.Password, user.PasswordSalt, organization.getCredSalt(passwordType)) {
 			resetUserSigninErrorTimes(user)
+
+			// transparently move legacy or weak hashes to the organization's current algorithm, the password
+			// is correct anyway, so a failed upgrade is only logged and retried at the next sign-in
+			err = user.UpgradeUserPasswordHash(organization, password, passwordType)
+			if err != nil {
+				logs.Warning(fmt.Sprintf("failed to upgrade the password hash of user: %s, error: %s", user.GetId(), err.Error()))
+			}
 			return ""
 		}
 
@@ -269,7 +278,7 @@ func CheckUserPassword(organization string, username string, password string, la
 	if len(options) > 0 {
 		enableCaptcha = options[0]
 	}
-	user, err := GetUserByFields(organization, username)
+	user, err := GetUserByFields(context.Background(), organization, username)
 	if err != nil {
 		panic(err)
 	}
@@ -350,7 +359,7 @@ func CheckUserPermission(requestUserId, userId string, strict bool, lang string)
 	return hasPermission, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
 }
 
-func CheckLoginPermission(userId string, application *Application) (bool, error) {
+func CheckLoginPermission(userId string, application *Application, abacContext *AbacContext) (bool, error) {
 	var err error
 	if userId == "built-in/admin" {
 		return true, nil
@@ -374,8 +383,14 @@ func CheckLoginPermission(userId string, application *Application) (bool, error)
 
 		enforcer := getPermissionEnforcer(permission)
 
+		var requests []CasbinRequest
+		requests, err = getAbacRequests(permission, []CasbinRequest{{userId, application.Name, "Read"}}, abacContext)
+		if err != nil {
+			return false, err
+		}
+
 		var isAllowed bool
-		isAllowed, err = enforcer.Enforce(userId, application.Name, "Read")
+		isAllowed, err = enforcer.Enforce(requests[0]...)
 		if err != nil {
 			return false, err
 		}
@@ -447,7 +462,7 @@ func CheckToEnableCaptcha(application *Application, organization, username strin
 		}
 		if providerItem.Provider.Category == "Captcha" {
 			if providerItem.Rule == "Dynamic" {
-				user, err := GetUserByFields(organization, username)
+				user, err := GetUserByFields(context.Background(), organization, username)
 				if err != nil {
 					return false, err
 				}
@@ -58,6 +58,7 @@ func recordSigninErrorInfo(user *User, lang string, options ...bool) string {
 	if user.SigninWrongTimes >= SigninWrongTimesLimit {
 		// record the latest failed login time
 		user.LastSigninWrongTime = time.Now().UTC().Format(time.RFC3339)
+		observeLockout(user)
 	}
 
 	// update user
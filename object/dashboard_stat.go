@@ -0,0 +1,412 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/xorm-io/core"
+)
+
+const (
+	StatLoginSucceeded = "login.succeeded"
+	StatLoginFailed    = "login.failed"
+	StatMfaLogin       = "login.mfa"
+	StatSignup         = "signup"
+	StatActiveUsers    = "activeUsers"
+	StatUsers          = "users"
+	StatMfaUsers       = "mfaUsers"
+	StatProvider       = "provider"
+	StatCountry        = "country"
+)
+
+const (
+	dayPeriodFormat   = "2006-01-02"
+	monthPeriodFormat = "2006-01"
+)
+
+// DashboardStat is a counter of an organization in a day, or in a month for the active users. The counters of
+// an application are kept along with the ones of the whole organization, whose application is ""
+type DashboardStat struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Period      string `xorm:"varchar(20) notnull pk" json:"period"`
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	Metric      string `xorm:"varchar(100) notnull pk" json:"metric"`
+	Value       string `xorm:"varchar(100) notnull pk" json:"value"`
+
+	Count int64 `json:"count"`
+}
+
+// DashboardActivity marks a user as active in a day or a month, so that the user is counted once per period
+type DashboardActivity struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Period      string `xorm:"varchar(20) notnull pk" json:"period"`
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	User        string `xorm:"varchar(100) notnull pk" json:"user"`
+}
+
+// LoginEvent is a login attempt, it's added to the dashboard stats
+type LoginEvent struct {
+	Organization string
+	Application  string
+	User         string
+	Provider     string
+	ClientIp     string
+	MfaType      string
+	IsSucceeded  bool
+	Time         time.Time
+}
+
+type DashboardStats struct {
+	Periods        []string         `json:"periods"`
+	ActiveUsers    []int64          `json:"activeUsers"`
+	Signups        []int64          `json:"signups"`
+	LoginSucceeded []int64          `json:"loginSucceeded"`
+	LoginFailed    []int64          `json:"loginFailed"`
+	MfaLogins      []int64          `json:"mfaLogins"`
+	Users          []int64          `json:"users"`
+	MfaUsers       []int64          `json:"mfaUsers"`
+	Providers      map[string]int64 `json:"providers"`
+	Countries      map[string]int64 `json:"countries"`
+}
+
+// getStatApplications returns the whole organization and the application, whose stats are updated together
+func getStatApplications(application string) []string {
+	if application == "" {
+		return []string{""}
+	}
+	return []string{"", application}
+}
+
+func incrementDashboardStat(owner string, period string, application string, metric string, value string, delta int64) error {
+	var err error
+	for i := 0; i < 2; i++ {
+		var affected int64
+		affected, err = ormer.Engine.ID(core.PK{owner, period, application, metric, value}).Incr("count", delta).Update(&DashboardStat{})
+		if err != nil || affected != 0 {
+			return err
+		}
+
+		// a concurrent login may insert the row in the meantime, then the update is tried again
+		stat := &DashboardStat{Owner: owner, Period: period, Application: application, Metric: metric, Value: value, Count: delta}
+		_, err = ormer.Engine.Insert(stat)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func setDashboardStat(owner string, period string, application string, metric string, count int64) error {
+	affected, err := ormer.Engine.ID(core.PK{owner, period, application, metric, ""}).Cols("count").Update(&DashboardStat{Count: count})
+	if err != nil || affected != 0 {
+		return err
+	}
+
+	existed, err := ormer.Engine.Exist(&DashboardStat{Owner: owner, Period: period, Application: application, Metric: metric})
+	if err != nil || existed {
+		return err
+	}
+
+	_, err = ormer.Engine.Insert(&DashboardStat{Owner: owner, Period: period, Application: application, Metric: metric, Count: count})
+	return err
+}
+
+// addDashboardActivity returns true if the user wasn't active in the period yet
+func addDashboardActivity(activity *DashboardActivity) (bool, error) {
+	existed, err := ormer.Engine.Exist(activity)
+	if err != nil || existed {
+		return false, err
+	}
+
+	_, err = ormer.Engine.Insert(activity)
+	if err != nil {
+		// another login of the user is added at the same time
+		existed, err2 := ormer.Engine.Exist(activity)
+		if err2 == nil && existed {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func addLoginStats(event *LoginEvent) error {
+	day := event.Time.Format(dayPeriodFormat)
+	month := event.Time.Format(monthPeriodFormat)
+
+	metric := StatLoginFailed
+	if event.IsSucceeded {
+		metric = StatLoginSucceeded
+	}
+
+	for _, application := range getStatApplications(event.Application) {
+		err := incrementDashboardStat(event.Organization, day, application, metric, "", 1)
+		if err != nil {
+			return err
+		}
+
+		if !event.IsSucceeded {
+			continue
+		}
+
+		err = incrementDashboardStat(event.Organization, day, application, StatProvider, event.Provider, 1)
+		if err != nil {
+			return err
+		}
+
+		if event.MfaType != "" {
+			err = incrementDashboardStat(event.Organization, day, application, StatMfaLogin, "", 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		if country := getCountryCode(event.ClientIp); country != "" {
+			err = incrementDashboardStat(event.Organization, day, application, StatCountry, country, 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		if event.User == "" {
+			continue
+		}
+
+		for _, period := range []string{day, month} {
+			isNew, err := addDashboardActivity(&DashboardActivity{Owner: event.Organization, Period: period, Application: application, User: event.User})
+			if err != nil {
+				return err
+			}
+
+			if isNew {
+				err = incrementDashboardStat(event.Organization, period, application, StatActiveUsers, "", 1)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// AddLoginStats counts the login in the stats of its day, the stats are updated when a login happens so that
+// the dashboard never scans the users or the records
+func AddLoginStats(event *LoginEvent) {
+	if event.Organization == "" {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	// the names come from the login request, the stats are only kept for an existing organization and application
+	// so that made-up names can't add rows
+	existed, err := ormer.Engine.Exist(&Organization{Owner: "admin", Name: event.Organization})
+	if err != nil || !existed {
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to get the organization of the login stats: %s, error: %s", event.Organization, err.Error()))
+		}
+		return
+	}
+
+	if event.Application != "" {
+		existed, err = ormer.Engine.Exist(&Application{Owner: "admin", Name: event.Application})
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to get the application of the login stats: %s, error: %s", event.Application, err.Error()))
+			return
+		}
+		if !existed {
+			event.Application = ""
+		}
+	}
+
+	err = addLoginStats(event)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to add the login stats of user: %s/%s, error: %s", event.Organization, event.User, err.Error()))
+	}
+}
+
+func AddSignupStats(organization string, application string) {
+	day := time.Now().Format(dayPeriodFormat)
+	for _, app := range getStatApplications(application) {
+		err := incrementDashboardStat(organization, day, app, StatSignup, "", 1)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to add the signup stats of organization: %s, error: %s", organization, err.Error()))
+			return
+		}
+	}
+}
+
+// getStatPeriods returns the last count days or months until now, from the oldest one
+func getStatPeriods(granularity string, count int, now time.Time) []string {
+	periods := make([]string, count)
+	for i := 0; i < count; i++ {
+		if granularity == "month" {
+			// the first day of the month doesn't overflow like AddDate on the 31st
+			month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+			periods[count-1-i] = month.AddDate(0, -i, 0).Format(monthPeriodFormat)
+		} else {
+			periods[count-1-i] = now.AddDate(0, 0, -i).Format(dayPeriodFormat)
+		}
+	}
+	return periods
+}
+
+func newDashboardStats(periods []string) *DashboardStats {
+	return &DashboardStats{
+		Periods:        periods,
+		ActiveUsers:    make([]int64, len(periods)),
+		Signups:        make([]int64, len(periods)),
+		LoginSucceeded: make([]int64, len(periods)),
+		LoginFailed:    make([]int64, len(periods)),
+		MfaLogins:      make([]int64, len(periods)),
+		Users:          make([]int64, len(periods)),
+		MfaUsers:       make([]int64, len(periods)),
+		Providers:      map[string]int64{},
+		Countries:      map[string]int64{},
+	}
+}
+
+// addStat adds a daily counter to the series, the counters of the days of a month are summed up, except the
+// numbers of users that are the ones of the last day
+func (stats *DashboardStats) addStat(stat *DashboardStat, i int) {
+	switch stat.Metric {
+	case StatActiveUsers:
+		stats.ActiveUsers[i] = stat.Count
+	case StatSignup:
+		stats.Signups[i] += stat.Count
+	case StatLoginSucceeded:
+		stats.LoginSucceeded[i] += stat.Count
+	case StatLoginFailed:
+		stats.LoginFailed[i] += stat.Count
+	case StatMfaLogin:
+		stats.MfaLogins[i] += stat.Count
+	case StatUsers:
+		stats.Users[i] = stat.Count
+	case StatMfaUsers:
+		stats.MfaUsers[i] = stat.Count
+	case StatProvider:
+		stats.Providers[stat.Value] += stat.Count
+	case StatCountry:
+		stats.Countries[stat.Value] += stat.Count
+	}
+}
+
+// GetDashboardStats returns the series of the last count days or months of an organization, or of one of its
+// applications
+func GetDashboardStats(owner string, application string, granularity string, count int) (*DashboardStats, error) {
+	if count <= 0 || count > 366 {
+		return nil, fmt.Errorf("the count of periods should be between 1 and 366")
+	}
+
+	periods := getStatPeriods(granularity, count, time.Now())
+	stats := newDashboardStats(periods)
+
+	session := ormer.Engine.Where("owner = ? and application = ?", owner, application)
+	if granularity == "month" {
+		// the daily counters of the months and the monthly active users
+		session = session.And("period >= ?", periods[0])
+	} else {
+		session = session.In("period", periods)
+	}
+
+	dashboardStats := []*DashboardStat{}
+	err := session.Asc("period").Find(&dashboardStats)
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, period := range periods {
+		index[period] = i
+	}
+
+	for _, stat := range dashboardStats {
+		period := stat.Period
+		if granularity == "month" {
+			if len(period) == len(dayPeriodFormat) && stat.Metric == StatActiveUsers {
+				continue
+			}
+			period = period[:len(monthPeriodFormat)]
+		} else if len(period) != len(dayPeriodFormat) {
+			continue
+		}
+
+		if i, ok := index[period]; ok {
+			stats.addStat(stat, i)
+		}
+	}
+	return stats, nil
+}
+
+func updateUserStats() error {
+	organizations := []*Organization{}
+	err := ormer.Engine.Cols("name").Find(&organizations)
+	if err != nil {
+		return err
+	}
+
+	day := time.Now().Format(dayPeriodFormat)
+	for _, organization := range organizations {
+		users, err := ormer.Engine.Where("owner = ? and is_deleted = ?", organization.Name, false).Count(&User{})
+		if err != nil {
+			return err
+		}
+
+		mfaUsers, err := ormer.Engine.Where("owner = ? and is_deleted = ? and preferred_mfa_type <> ?", organization.Name, false, "").Count(&User{})
+		if err != nil {
+			return err
+		}
+
+		err = setDashboardStat(organization.Name, day, "", StatUsers, users)
+		if err != nil {
+			return err
+		}
+
+		err = setDashboardStat(organization.Name, day, "", StatMfaUsers, mfaUsers)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExpiredDashboardActivities keeps the activities of the current and the last month, the older ones aren't
+// needed to count the active users anymore
+func deleteExpiredDashboardActivities() error {
+	now := time.Now()
+	cutoff := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0).Format(monthPeriodFormat)
+	_, err := ormer.Engine.Where("period < ?", cutoff).Delete(&DashboardActivity{})
+	return err
+}
+
+// RunDashboardStatJob takes a snapshot of the numbers of users and MFA users every hour, they're the stats that
+// can't be counted by the logins
+func RunDashboardStatJob() {
+	for {
+		err := updateUserStats()
+		if err == nil {
+			err = deleteExpiredDashboardActivities()
+		}
+		if err != nil {
+			logs.Warning(fmt.Sprintf("dashboard stat job failed, error %s", err))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
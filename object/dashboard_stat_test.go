@@ -0,0 +1,94 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+	_ "modernc.org/sqlite"
+)
+
+func TestGetStatPeriods(t *testing.T) {
+	now := time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []string{"2023-03-29", "2023-03-30", "2023-03-31"}, getStatPeriods("day", 3, now))
+	assert.Equal(t, []string{"2023-01", "2023-02", "2023-03"}, getStatPeriods("month", 3, now))
+}
+
+func TestDashboardStats(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(DashboardStat), new(DashboardActivity), new(Organization), new(Application)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	now := time.Now()
+	events := []*LoginEvent{
+		{Organization: "org", Application: "app1", User: "alice", Provider: "password", IsSucceeded: true, Time: now},
+		{Organization: "org", Application: "app1", User: "alice", Provider: "GitHub", MfaType: TotpType, IsSucceeded: true, Time: now},
+		{Organization: "org", Application: "app2", User: "bob", Provider: "password", IsSucceeded: true, Time: now},
+		{Organization: "org", Application: "app2", User: "bob", Provider: "password", IsSucceeded: false, Time: now},
+		{Organization: "org", Application: "app1", User: "alice", Provider: "password", IsSucceeded: true, Time: now.AddDate(0, 0, -1)},
+	}
+	for _, event := range events {
+		assert.Nil(t, addLoginStats(event))
+	}
+	AddSignupStats("org", "app1")
+
+	stats, err := GetDashboardStats("org", "", "day", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2}, stats.ActiveUsers)
+	assert.Equal(t, []int64{1, 3}, stats.LoginSucceeded)
+	assert.Equal(t, []int64{0, 1}, stats.LoginFailed)
+	assert.Equal(t, []int64{0, 1}, stats.MfaLogins)
+	assert.Equal(t, []int64{0, 1}, stats.Signups)
+	assert.Equal(t, map[string]int64{"password": 3, "GitHub": 1}, stats.Providers)
+
+	stats, err = GetDashboardStats("org", "app1", "day", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1}, stats.ActiveUsers)
+	assert.Equal(t, []int64{2}, stats.LoginSucceeded)
+
+	// the users active on both days are counted once in the month
+	stats, err = GetDashboardStats("org", "", "month", 1)
+	assert.Nil(t, err)
+	if now.Day() > 1 {
+		assert.Equal(t, []int64{2}, stats.ActiveUsers)
+		assert.Equal(t, []int64{4}, stats.LoginSucceeded)
+	}
+
+	// the stats of a made-up organization are dropped and a made-up application only counts in the organization
+	_, err = engine.Insert(&Organization{Owner: "admin", Name: "org"})
+	assert.Nil(t, err)
+	AddLoginStats(&LoginEvent{Organization: "unknown", Application: "app1", Provider: "password", IsSucceeded: true, Time: now})
+	AddLoginStats(&LoginEvent{Organization: "org", Application: "unknown", Provider: "password", IsSucceeded: true, Time: now})
+
+	stats, err = GetDashboardStats("unknown", "", "day", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{0}, stats.LoginSucceeded)
+	stats, err = GetDashboardStats("org", "unknown", "day", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{0}, stats.LoginSucceeded)
+	stats, err = GetDashboardStats("org", "", "day", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{4}, stats.LoginSucceeded)
+}
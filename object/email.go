@@ -17,10 +17,13 @@
 package object
 
 import (
+	"context"
 	"crypto/tls"
 
 	"github.com/casdoor/casdoor/email"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/gomail/v2"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func getDialer(provider *Provider) *gomail.Dialer {
@@ -35,7 +38,14 @@ func getDialer(provider *Provider) *gomail.Dialer {
 	return dialer
 }
 
-func SendEmail(provider *Provider, title string, content string, dest string, sender string) error {
+func SendEmail(ctx context.Context, provider *Provider, title string, content string, dest string, sender string) error {
+	_, span := telemetry.StartSpan(ctx, "email.Send", attribute.String("provider.type", provider.Type))
+	err := sendEmail(provider, title, content, dest, sender)
+	telemetry.EndSpan(span, err)
+	return err
+}
+
+func sendEmail(provider *Provider, title string, content string, dest string, sender string) error {
 	emailProvider := email.GetEmailProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.Host, provider.Port, provider.DisableSsl)
 
 	fromAddress := provider.ClientId2
@@ -0,0 +1,242 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+)
+
+// the policies are tested one by one to find all of the matching ones, so a huge policy set is only partially explained
+const maxExplainedPolicies = 1000
+
+type ExplainedPolicy struct {
+	Policy     []string `json:"policy"`
+	Effect     string   `json:"effect"`
+	Permission string   `json:"permission"`
+}
+
+type RoleLink struct {
+	Member string `json:"member"`
+	Role   string `json:"role"`
+}
+
+// EnforceExplanation tells why a request is allowed or denied by an enforcer
+type EnforceExplanation struct {
+	Source          string             `json:"source"`
+	Allowed         bool               `json:"allowed"`
+	DecidingPolicy  *ExplainedPolicy   `json:"decidingPolicy"`
+	DenyPolicy      *ExplainedPolicy   `json:"denyPolicy"`
+	MatchedPolicies []*ExplainedPolicy `json:"matchedPolicies"`
+	RoleChain       []*RoleLink        `json:"roleChain"`
+	Groups          []string           `json:"groups"`
+	IsTruncated     bool               `json:"isTruncated"`
+}
+
+func getPolicyEffectIndex(m model.Model) int {
+	assertion, ok := m["p"]["p"]
+	if !ok {
+		return -1
+	}
+
+	for i, token := range assertion.Tokens {
+		if token == "p_eft" {
+			return i
+		}
+	}
+	return -1
+}
+
+func newExplainedPolicy(policy []string, effectIndex int, isPermission bool) *ExplainedPolicy {
+	res := &ExplainedPolicy{Policy: policy, Effect: "allow"}
+	if effectIndex >= 0 && effectIndex < len(policy) && policy[effectIndex] != "" {
+		res.Effect = strings.ToLower(policy[effectIndex])
+	}
+	// the policies of a permission keep its id in V5
+	if isPermission && len(policy) > builtInAvailableField {
+		res.Permission = policy[builtInAvailableField]
+	}
+	return res
+}
+
+// getRoleChain walks up the role inheritance of the subject, every traversed link is returned
+func getRoleChain(enforcer *casbin.Enforcer, subject string, domain []string) []*RoleLink {
+	links := []*RoleLink{}
+	if enforcer.GetModel()["g"] == nil {
+		return links
+	}
+
+	visited := map[string]bool{subject: true}
+	queue := []string{subject}
+	for len(queue) != 0 {
+		member := queue[0]
+		queue = queue[1:]
+
+		roles, err := enforcer.GetRolesForUser(member, domain...)
+		if err != nil {
+			return links
+		}
+
+		for _, role := range roles {
+			links = append(links, &RoleLink{Member: member, Role: role})
+			if !visited[role] {
+				visited[role] = true
+				queue = append(queue, role)
+			}
+		}
+	}
+	return links
+}
+
+// getMatchedPolicies tests every policy alone in an in-memory copy of the enforcer that keeps the role links
+func getMatchedPolicies(enforcer *casbin.Enforcer, request []interface{}) ([][]string, bool, error) {
+	m, err := model.NewModelFromString(enforcer.GetModel().ToText())
+	if err != nil {
+		return nil, false, err
+	}
+
+	tester, err := casbin.NewEnforcer(m)
+	if err != nil {
+		return nil, false, err
+	}
+	tester.AddFunction("userProperty", userPropertyFunction)
+
+	if m["g"] != nil {
+		for ptype := range m["g"] {
+			groupingPolicies := enforcer.GetNamedGroupingPolicy(ptype)
+			if len(groupingPolicies) == 0 {
+				continue
+			}
+
+			_, err = tester.AddNamedGroupingPolicies(ptype, groupingPolicies)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	policies := enforcer.GetPolicy()
+	isTruncated := len(policies) > maxExplainedPolicies
+	if isTruncated {
+		policies = policies[:maxExplainedPolicies]
+	}
+
+	matched := [][]string{}
+	for _, policy := range policies {
+		_, err = tester.AddPolicy(policy)
+		if err != nil {
+			return nil, false, err
+		}
+
+		_, explain, err := tester.EnforceEx(request...)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(explain) != 0 {
+			matched = append(matched, policy)
+		}
+
+		_, err = tester.RemovePolicy(policy)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return matched, isTruncated, nil
+}
+
+func explainEnforce(enforcer *casbin.Enforcer, source string, request []interface{}, isPermission bool) (*EnforceExplanation, error) {
+	allowed, explain, err := enforcer.EnforceEx(request...)
+	if err != nil {
+		return nil, err
+	}
+
+	effectIndex := getPolicyEffectIndex(enforcer.GetModel())
+	res := &EnforceExplanation{
+		Source:          source,
+		Allowed:         allowed,
+		MatchedPolicies: []*ExplainedPolicy{},
+		Groups:          []string{},
+	}
+	if len(explain) != 0 {
+		res.DecidingPolicy = newExplainedPolicy(explain, effectIndex, isPermission)
+		if !allowed {
+			res.DenyPolicy = res.DecidingPolicy
+		}
+	}
+
+	matched, isTruncated, err := getMatchedPolicies(enforcer, request)
+	if err != nil {
+		return nil, err
+	}
+	res.IsTruncated = isTruncated
+	for _, policy := range matched {
+		res.MatchedPolicies = append(res.MatchedPolicies, newExplainedPolicy(policy, effectIndex, isPermission))
+	}
+
+	if len(request) == 0 {
+		return res, nil
+	}
+
+	subject, ok := request[0].(string)
+	if abacSubject, isAbac := request[0].(*AbacSubject); isAbac {
+		subject, ok = abacSubject.Id, true
+	}
+	if !ok {
+		return res, nil
+	}
+
+	// a request like [sub, dom, obj, act] is checked against the roles of the domain
+	domain := []string{}
+	if assertion, ok := enforcer.GetModel()["r"]["r"]; ok && len(assertion.Tokens) == 4 && len(request) == 4 {
+		if dom, ok := request[1].(string); ok {
+			domain = append(domain, dom)
+		}
+	}
+	res.RoleChain = getRoleChain(enforcer, subject, domain)
+
+	if userEnforcer != nil {
+		groups, err := userEnforcer.GetGroupsForUser(subject)
+		if err == nil {
+			res.Groups = groups
+		}
+	}
+	return res, nil
+}
+
+// ExplainPermissionEnforce explains the decision of the enforcer of the permission, or of the permissions sharing its model and adapter
+func ExplainPermissionEnforce(permission *Permission, request *CasbinRequest, abacContext *AbacContext, permissionIds ...string) (*EnforceExplanation, error) {
+	enforcer := getPermissionEnforcer(permission, permissionIds...)
+
+	requests, err := getAbacRequests(permission, []CasbinRequest{*request}, abacContext)
+	if err != nil {
+		return nil, err
+	}
+
+	source := permission.GetId()
+	if len(permissionIds) != 0 {
+		source = strings.Join(permissionIds, ",")
+	}
+	return explainEnforce(enforcer, source, requests[0], true)
+}
+
+func ExplainEnforcerEnforce(enforcer *Enforcer, request *CasbinRequest) (*EnforceExplanation, error) {
+	if enforcer.Enforcer == nil {
+		return nil, fmt.Errorf("the enforcer: %s is not initialized", enforcer.GetId())
+	}
+	return explainEnforce(enforcer.Enforcer, enforcer.GetId(), *request, false)
+}
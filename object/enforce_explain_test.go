@@ -0,0 +1,81 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const explainModelText = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act, eft
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow)) && !some(where (p.eft == deny))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act`
+
+func TestExplainEnforce(t *testing.T) {
+	m, err := model.NewModelFromString(explainModelText)
+	assert.Nil(t, err)
+	enforcer, err := casbin.NewEnforcer(m)
+	assert.Nil(t, err)
+
+	_, _ = enforcer.AddPolicies([][]string{
+		{"role-dev", "repo", "read", "allow"},
+		{"role-dev", "repo", "write", "allow"},
+		{"role-oncall", "prod", "write", "allow"},
+		{"alice", "repo", "write", "deny"},
+	})
+	_, _ = enforcer.AddGroupingPolicies([][]string{
+		{"alice", "role-oncall"},
+		{"role-oncall", "role-dev"},
+	})
+
+	scenarios := []struct {
+		request  []interface{}
+		allowed  bool
+		deny     []string
+		matched  int
+		chainLen int
+	}{
+		{[]interface{}{"alice", "repo", "read"}, true, nil, 1, 2},
+		{[]interface{}{"alice", "repo", "write"}, false, []string{"alice", "repo", "write", "deny"}, 2, 2},
+		{[]interface{}{"bob", "prod", "write"}, false, nil, 0, 0},
+	}
+	for _, scenery := range scenarios {
+		explanation, err := explainEnforce(enforcer, "test", scenery.request, false)
+		assert.Nil(t, err)
+		assert.Equal(t, scenery.allowed, explanation.Allowed, scenery.request)
+		assert.Equal(t, scenery.matched, len(explanation.MatchedPolicies), scenery.request)
+		assert.Equal(t, scenery.chainLen, len(explanation.RoleChain), scenery.request)
+		if scenery.deny == nil {
+			assert.Nil(t, explanation.DenyPolicy, scenery.request)
+		} else {
+			assert.Equal(t, scenery.deny, explanation.DenyPolicy.Policy)
+			assert.Equal(t, "deny", explanation.DenyPolicy.Effect)
+		}
+	}
+}
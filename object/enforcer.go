@@ -37,6 +37,7 @@ type Enforcer struct {
 
 	ModelCfg map[string]string `xorm:"-" json:"modelCfg"`
 	*casbin.Enforcer
+	adapter *Adapter
 }
 
 func GetEnforcerCount(owner, field, value string) (int64, error) {
@@ -96,11 +97,21 @@ func UpdateEnforcer(id string, enforcer *Enforcer) (bool, error) {
 		return false, nil
 	}
 
+	addPolicyBaselines(PolicyVersionTypeEnforcer, id)
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(enforcer)
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		// a renamed enforcer leaves an empty version under its old id
+		if id != enforcer.GetId() {
+			addPolicyVersions(PolicyVersionTypeEnforcer, "rename", id)
+		}
+		addPolicyVersions(PolicyVersionTypeEnforcer, "update", enforcer.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -110,15 +121,25 @@ func AddEnforcer(enforcer *Enforcer) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeEnforcer, "add", enforcer.GetId())
+	}
+
 	return affected != 0, nil
 }
 
 func DeleteEnforcer(enforcer *Enforcer) (bool, error) {
+	addPolicyBaselines(PolicyVersionTypeEnforcer, enforcer.GetId())
+
 	affected, err := ormer.Engine.ID(core.PK{enforcer.Owner, enforcer.Name}).Delete(&Enforcer{})
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeEnforcer, "delete", enforcer.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -167,6 +188,7 @@ func (enforcer *Enforcer) InitEnforcer() error {
 	}
 
 	enforcer.Enforcer = casbinEnforcer
+	enforcer.adapter = a
 	return nil
 }
 
@@ -185,6 +207,17 @@ func GetInitializedEnforcer(enforcerId string) (*Enforcer, error) {
 	return enforcer, nil
 }
 
+// GetInitializedSyncedEnforcer returns the enforcer guarded by a lock, for the long-lived enforcers whose policies
+// are reloaded by the policy watcher while requests are being enforced with them
+func GetInitializedSyncedEnforcer(enforcerId string) (*casbin.SyncedEnforcer, error) {
+	enforcer, err := GetInitializedEnforcer(enforcerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return casbin.NewSyncedEnforcer(enforcer.GetModel(), enforcer.GetAdapter())
+}
+
 func GetPolicies(id string) ([]*xormadapter.CasbinRule, error) {
 	enforcer, err := GetInitializedEnforcer(id)
 	if err != nil {
@@ -209,11 +242,28 @@ func UpdatePolicy(id string, ptype string, oldPolicy []string, newPolicy []strin
 		return false, err
 	}
 
+	addPolicyBaselines(PolicyVersionTypeEnforcer, id)
+
+	var affected bool
 	if ptype == "p" {
-		return enforcer.UpdatePolicy(oldPolicy, newPolicy)
+		affected, err = enforcer.UpdatePolicy(oldPolicy, newPolicy)
 	} else {
-		return enforcer.UpdateGroupingPolicy(oldPolicy, newPolicy)
+		affected, err = enforcer.UpdateGroupingPolicy(oldPolicy, newPolicy)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		addPolicyVersions(PolicyVersionTypeEnforcer, "update-policy", id)
+
+		err = notifyPolicyChange(id)
+		if err != nil {
+			return false, err
+		}
 	}
+
+	return affected, nil
 }
 
 func AddPolicy(id string, ptype string, policy []string) (bool, error) {
@@ -222,11 +272,28 @@ func AddPolicy(id string, ptype string, policy []string) (bool, error) {
 		return false, err
 	}
 
+	addPolicyBaselines(PolicyVersionTypeEnforcer, id)
+
+	var affected bool
 	if ptype == "p" {
-		return enforcer.AddPolicy(policy)
+		affected, err = enforcer.AddPolicy(policy)
 	} else {
-		return enforcer.AddGroupingPolicy(policy)
+		affected, err = enforcer.AddGroupingPolicy(policy)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		addPolicyVersions(PolicyVersionTypeEnforcer, "add-policy", id)
+
+		err = notifyPolicyChange(id)
+		if err != nil {
+			return false, err
+		}
 	}
+
+	return affected, nil
 }
 
 func RemovePolicy(id string, ptype string, policy []string) (bool, error) {
@@ -235,11 +302,28 @@ func RemovePolicy(id string, ptype string, policy []string) (bool, error) {
 		return false, err
 	}
 
+	addPolicyBaselines(PolicyVersionTypeEnforcer, id)
+
+	var affected bool
 	if ptype == "p" {
-		return enforcer.RemovePolicy(policy)
+		affected, err = enforcer.RemovePolicy(policy)
 	} else {
-		return enforcer.RemoveGroupingPolicy(policy)
+		affected, err = enforcer.RemoveGroupingPolicy(policy)
+	}
+	if err != nil {
+		return false, err
 	}
+
+	if affected {
+		addPolicyVersions(PolicyVersionTypeEnforcer, "remove-policy", id)
+
+		err = notifyPolicyChange(id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return affected, nil
 }
 
 func (enforcer *Enforcer) LoadModelCfg() error {
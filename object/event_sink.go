@@ -0,0 +1,260 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	EventSinkTypeSyslog = "Syslog"
+	EventSinkTypeKafka  = "Kafka"
+	EventSinkTypeNats   = "NATS"
+	EventSinkTypeHttp   = "HTTP"
+)
+
+const (
+	EventSinkFormatJson = "JSON"
+	EventSinkFormatCef  = "CEF"
+	EventSinkFormatLeef = "LEEF"
+)
+
+// EventSink streams every record of its organizations to a log pipeline, the records are buffered in memory and
+// sent in batches by a worker of the sink
+type EventSink struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Organization string `xorm:"varchar(100) index" json:"organization"`
+	Type         string `xorm:"varchar(100)" json:"type"`
+	Format       string `xorm:"varchar(100)" json:"format"`
+
+	// Endpoint is "host:port" for Syslog, the comma-separated brokers for Kafka, the server URL for NATS and the URL for HTTP
+	Endpoint string    `xorm:"varchar(1000)" json:"endpoint"`
+	Network  string    `xorm:"varchar(100)" json:"network"`
+	Topic    string    `xorm:"varchar(100)" json:"topic"`
+	Headers  []*Header `xorm:"mediumtext" json:"headers"`
+
+	Events        []string `xorm:"varchar(1000)" json:"events"`
+	Filter        string   `xorm:"varchar(1000)" json:"filter"`
+	BufferSize    int      `json:"bufferSize"`
+	BatchSize     int      `json:"batchSize"`
+	FlushInterval int      `json:"flushInterval"`
+	IsEnabled     bool     `json:"isEnabled"`
+
+	Status *EventSinkStatus `xorm:"-" json:"status"`
+}
+
+func GetEventSinkCount(owner, organization, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&EventSink{Organization: organization})
+}
+
+func GetEventSinks(owner string, organization string) ([]*EventSink, error) {
+	eventSinks := []*EventSink{}
+	err := ormer.Engine.Desc("created_time").Find(&eventSinks, &EventSink{Owner: owner, Organization: organization})
+	if err != nil {
+		return eventSinks, err
+	}
+
+	return eventSinks, nil
+}
+
+func GetPaginationEventSinks(owner, organization string, offset, limit int, field, value, sortField, sortOrder string) ([]*EventSink, error) {
+	eventSinks := []*EventSink{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&eventSinks, &EventSink{Organization: organization})
+	if err != nil {
+		return nil, err
+	}
+
+	return eventSinks, nil
+}
+
+func getEventSink(owner string, name string) (*EventSink, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	eventSink := EventSink{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&eventSink)
+	if err != nil {
+		return &eventSink, err
+	}
+
+	if existed {
+		eventSink.Status = getEventSinkStatus(eventSink.GetId())
+		return &eventSink, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetEventSink(id string) (*EventSink, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getEventSink(owner, name)
+}
+
+func checkEventSink(eventSink *EventSink) error {
+	// only the sinks of the admin can stream the records of every organization
+	if eventSink.Owner != "admin" && eventSink.Organization != eventSink.Owner {
+		return fmt.Errorf("the organization of the event sink should be its owner: %s", eventSink.Owner)
+	}
+
+	switch eventSink.Type {
+	case EventSinkTypeSyslog:
+		if eventSink.Network != "udp" && eventSink.Network != "tcp" && eventSink.Network != "tls" {
+			return fmt.Errorf("the network of a Syslog sink should be udp, tcp or tls")
+		}
+	case EventSinkTypeKafka, EventSinkTypeNats:
+		if eventSink.Topic == "" {
+			return fmt.Errorf("the topic of a %s sink should not be empty", eventSink.Type)
+		}
+	case EventSinkTypeHttp:
+	default:
+		return fmt.Errorf("unsupported event sink type: %s", eventSink.Type)
+	}
+
+	switch eventSink.Format {
+	case "", EventSinkFormatJson, EventSinkFormatCef, EventSinkFormatLeef:
+	default:
+		return fmt.Errorf("unsupported event sink format: %s", eventSink.Format)
+	}
+
+	if eventSink.Endpoint == "" {
+		return fmt.Errorf("the endpoint of the event sink should not be empty")
+	}
+
+	_, err := evaluateEventFilter(eventSink.Filter, &WebhookPayload{})
+	return err
+}
+
+func UpdateEventSink(id string, eventSink *EventSink) (bool, error) {
+	err := checkEventSink(eventSink)
+	if err != nil {
+		return false, err
+	}
+
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if s, err := getEventSink(owner, name); err != nil {
+		return false, err
+	} else if s == nil {
+		return false, nil
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(eventSink)
+	if err != nil {
+		return false, err
+	}
+
+	stopEventSinkWorker(id)
+	startEventSinkWorker(eventSink)
+	return affected != 0, nil
+}
+
+func AddEventSink(eventSink *EventSink) (bool, error) {
+	err := checkEventSink(eventSink)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(eventSink)
+	if err != nil {
+		return false, err
+	}
+
+	startEventSinkWorker(eventSink)
+	return affected != 0, nil
+}
+
+func DeleteEventSink(eventSink *EventSink) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{eventSink.Owner, eventSink.Name}).Delete(&EventSink{})
+	if err != nil {
+		return false, err
+	}
+
+	stopEventSinkWorker(eventSink.GetId())
+	return affected != 0, nil
+}
+
+func (eventSink *EventSink) GetId() string {
+	return fmt.Sprintf("%s/%s", eventSink.Owner, eventSink.Name)
+}
+
+// InitEventSinks starts the workers of the enabled sinks, every replica streams the records it adds
+func InitEventSinks() {
+	err := syncEventSinkWorkers()
+	if err != nil {
+		panic(err)
+	}
+}
+
+// syncEventSinkWorkers starts, restarts and stops the workers of this replica to match the sinks in the database, so
+// that a sink added, updated or deleted through another replica also gets the records of this one
+func syncEventSinkWorkers() error {
+	eventSinks := []*EventSink{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Find(&eventSinks)
+	if err != nil {
+		return err
+	}
+
+	enabledSinks := map[string]*EventSink{}
+	for _, eventSink := range eventSinks {
+		enabledSinks[eventSink.GetId()] = eventSink
+	}
+
+	staleIds := []string{}
+	eventSinkWorkersMutex.RLock()
+	for id, worker := range eventSinkWorkers {
+		if eventSink, ok := enabledSinks[id]; ok && getEventSinkFingerprint(eventSink) == getEventSinkFingerprint(worker.eventSink) {
+			delete(enabledSinks, id)
+		} else {
+			staleIds = append(staleIds, id)
+		}
+	}
+	eventSinkWorkersMutex.RUnlock()
+
+	for _, id := range staleIds {
+		stopEventSinkWorker(id)
+	}
+	for _, eventSink := range enabledSinks {
+		startEventSinkWorker(eventSink)
+	}
+	return nil
+}
+
+func getEventSinkFingerprint(eventSink *EventSink) string {
+	s := *eventSink
+	s.Status = nil
+	return util.StructToJson(s)
+}
+
+func RunEventSinkJob() {
+	for {
+		time.Sleep(time.Duration(getConfigLimit("eventSinkSyncInterval", 30)) * time.Second)
+
+		err := syncEventSinkWorkers()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("event sink sync failed, error %s", err))
+		}
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/nats-io/nats.go"
+)
+
+const eventSinkTimeout = 10 * time.Second
+
+type eventSinkMessage struct {
+	Key      string
+	Severity int
+	MsgId    string
+	Time     string
+	Data     []byte
+}
+
+// eventSinkClient connects lazily and connects again after a failed send, so that a sink that is down when
+// Casdoor starts still gets the records later
+type eventSinkClient interface {
+	send(messages []*eventSinkMessage) error
+	close()
+}
+
+func newEventSinkClient(eventSink *EventSink) eventSinkClient {
+	switch eventSink.Type {
+	case EventSinkTypeSyslog:
+		return &syslogEventSinkClient{network: eventSink.Network, address: eventSink.Endpoint}
+	case EventSinkTypeKafka:
+		return &kafkaEventSinkClient{brokers: strings.Split(eventSink.Endpoint, ","), topic: eventSink.Topic}
+	case EventSinkTypeNats:
+		return &natsEventSinkClient{url: eventSink.Endpoint, subject: eventSink.Topic}
+	default:
+		isJson := eventSink.Format == "" || eventSink.Format == EventSinkFormatJson
+		return &httpEventSinkClient{url: eventSink.Endpoint, headers: eventSink.Headers, isJson: isJson}
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefEscaper         = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// formatEventSinkMessage formats a record as JSON, as CEF ("CEF:0|Casdoor|Casdoor|1.0|<type>|<action>|<severity>|...")
+// or as LEEF 1.0 with tab-separated attributes
+func formatEventSinkMessage(eventSink *EventSink, record *Record) (*eventSinkMessage, error) {
+	message := &eventSinkMessage{Key: record.Organization, Severity: 6, MsgId: record.Type, Time: record.CreatedTime}
+	if record.Result == "error" {
+		message.Severity = 4
+	}
+
+	switch eventSink.Format {
+	case EventSinkFormatCef:
+		severity := 3
+		if record.Result == "error" {
+			severity = 7
+		}
+
+		extensions := [][2]string{
+			{"rt", record.CreatedTime}, {"externalId", record.Name}, {"src", record.ClientIp}, {"suser", record.User},
+			{"cs1Label", "organization"}, {"cs1", record.Organization}, {"cs2Label", "target"}, {"cs2", record.Target},
+			{"requestMethod", record.Method}, {"request", record.RequestUri}, {"act", record.Action},
+			{"outcome", record.Result}, {"reason", record.Reason},
+		}
+		tokens := []string{}
+		for i, extension := range extensions {
+			// a label is only written with its value
+			if strings.HasSuffix(extension[0], "Label") && extensions[i+1][1] == "" {
+				continue
+			}
+			if extension[1] != "" {
+				tokens = append(tokens, extension[0]+"="+cefExtensionEscaper.Replace(extension[1]))
+			}
+		}
+
+		message.Data = []byte(fmt.Sprintf("CEF:0|Casdoor|Casdoor|1.0|%s|%s|%d|%s",
+			cefHeaderEscaper.Replace(record.Type), cefHeaderEscaper.Replace(record.Action), severity, strings.Join(tokens, " ")))
+	case EventSinkFormatLeef:
+		attributes := [][2]string{
+			{"devTime", record.CreatedTime}, {"devTimeFormat", "yyyy-MM-dd'T'HH:mm:ssX"}, {"cat", record.Type},
+			{"src", record.ClientIp}, {"usrName", record.User}, {"identSrc", record.ClientIp}, {"organization", record.Organization},
+			{"target", record.Target}, {"action", record.Action}, {"outcome", record.Result}, {"reason", record.Reason},
+			{"url", record.RequestUri},
+		}
+		tokens := []string{}
+		for _, attribute := range attributes {
+			if attribute[1] != "" {
+				tokens = append(tokens, attribute[0]+"="+leefEscaper.Replace(attribute[1]))
+			}
+		}
+
+		message.Data = []byte(fmt.Sprintf("LEEF:1.0|Casdoor|Casdoor|1.0|%s|%s", leefEscaper.Replace(record.Type), strings.Join(tokens, "\t")))
+	default:
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		message.Data = data
+	}
+	return message, nil
+}
+
+type syslogEventSinkClient struct {
+	network string
+	address string
+	conn    net.Conn
+}
+
+// getSyslogMessage formats the message as RFC 5424 with the authpriv facility
+func getSyslogMessage(message *eventSinkMessage) string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	msgId := strings.ReplaceAll(message.MsgId, " ", "_")
+	if msgId == "" {
+		msgId = "-"
+	} else if len(msgId) > 32 {
+		msgId = msgId[:32]
+	}
+
+	timestamp := message.Time
+	if timestamp == "" {
+		timestamp = "-"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s casdoor - %s - %s", 10*8+message.Severity, timestamp, hostname, msgId, string(message.Data))
+}
+
+func (c *syslogEventSinkClient) send(messages []*eventSinkMessage) error {
+	if c.conn == nil {
+		var err error
+		switch c.network {
+		case "tls":
+			dialer := &net.Dialer{Timeout: eventSinkTimeout}
+			c.conn, err = tls.DialWithDialer(dialer, "tcp", c.address, &tls.Config{})
+		default:
+			c.conn, err = net.DialTimeout(c.network, c.address, eventSinkTimeout)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.conn.SetWriteDeadline(time.Now().Add(eventSinkTimeout))
+	if err == nil {
+		for _, message := range messages {
+			syslogMessage := getSyslogMessage(message)
+			if c.network != "udp" {
+				// octet counting of RFC 6587, the messages may have line breaks
+				syslogMessage = fmt.Sprintf("%d %s", len(syslogMessage), syslogMessage)
+			}
+
+			_, err = c.conn.Write([]byte(syslogMessage))
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		c.close()
+	}
+	return err
+}
+
+func (c *syslogEventSinkClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+type kafkaEventSinkClient struct {
+	brokers  []string
+	topic    string
+	producer sarama.SyncProducer
+}
+
+func (c *kafkaEventSinkClient) send(messages []*eventSinkMessage) error {
+	if c.producer == nil {
+		config := sarama.NewConfig()
+		config.Producer.Return.Successes = true
+		config.Producer.Timeout = eventSinkTimeout
+		config.Net.DialTimeout = eventSinkTimeout
+
+		producer, err := sarama.NewSyncProducer(c.brokers, config)
+		if err != nil {
+			return err
+		}
+		c.producer = producer
+	}
+
+	producerMessages := []*sarama.ProducerMessage{}
+	for _, message := range messages {
+		// the records of an organization keep their order in a partition
+		producerMessages = append(producerMessages, &sarama.ProducerMessage{
+			Topic: c.topic,
+			Key:   sarama.StringEncoder(message.Key),
+			Value: sarama.ByteEncoder(message.Data),
+		})
+	}
+
+	err := c.producer.SendMessages(producerMessages)
+	if err != nil {
+		c.close()
+	}
+	return err
+}
+
+func (c *kafkaEventSinkClient) close() {
+	if c.producer != nil {
+		c.producer.Close()
+		c.producer = nil
+	}
+}
+
+type natsEventSinkClient struct {
+	url     string
+	subject string
+	conn    *nats.Conn
+}
+
+func (c *natsEventSinkClient) send(messages []*eventSinkMessage) error {
+	if c.conn == nil {
+		conn, err := nats.Connect(c.url, nats.Name("casdoor"), nats.Timeout(eventSinkTimeout))
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+
+	var err error
+	for _, message := range messages {
+		err = c.conn.Publish(c.subject, message.Data)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = c.conn.FlushTimeout(eventSinkTimeout)
+	}
+
+	if err != nil {
+		c.close()
+	}
+	return err
+}
+
+func (c *natsEventSinkClient) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// httpEventSinkClient posts a batch as a JSON array, or as one message per line for CEF and LEEF
+type httpEventSinkClient struct {
+	url     string
+	headers []*Header
+	isJson  bool
+}
+
+func (c *httpEventSinkClient) send(messages []*eventSinkMessage) error {
+	var body bytes.Buffer
+	contentType := "text/plain"
+	if c.isJson {
+		contentType = "application/json"
+		body.WriteString("[")
+	}
+	for i, message := range messages {
+		if i > 0 {
+			if c.isJson {
+				body.WriteString(",")
+			} else {
+				body.WriteString("\n")
+			}
+		}
+		body.Write(message.Data)
+	}
+	if c.isJson {
+		body.WriteString("]")
+	}
+
+	req, err := http.NewRequest("POST", c.url, &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	for _, header := range c.headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	client := &http.Client{Timeout: eventSinkTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the event sink responded with status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *httpEventSinkClient) close() {}
@@ -0,0 +1,239 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+var eventSinkTestRecord = &Record{
+	Name: "record1", CreatedTime: "2023-01-01T00:00:00Z", Organization: "built-in", ClientIp: "127.0.0.1", User: "alice",
+	Method: "POST", RequestUri: "/api/login", Action: "login", Type: "login.failed", Result: "error", Reason: "wrong|password=1",
+}
+
+func TestFormatEventSinkMessage(t *testing.T) {
+	scenarios := []struct {
+		format   string
+		expected string
+	}{
+		{EventSinkFormatCef, `CEF:0|Casdoor|Casdoor|1.0|login.failed|login|7|rt=2023-01-01T00:00:00Z externalId=record1 src=127.0.0.1 suser=alice cs1Label=organization cs1=built-in requestMethod=POST request=/api/login act=login outcome=error reason=wrong|password\=1`},
+		{EventSinkFormatLeef, "LEEF:1.0|Casdoor|Casdoor|1.0|login.failed|devTime=2023-01-01T00:00:00Z\tdevTimeFormat=yyyy-MM-dd'T'HH:mm:ssX\tcat=login.failed\tsrc=127.0.0.1\tusrName=alice\tidentSrc=127.0.0.1\torganization=built-in\taction=login\toutcome=error\treason=wrong|password=1\turl=/api/login"},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.format, func(t *testing.T) {
+			message, err := formatEventSinkMessage(&EventSink{Format: scenario.format}, eventSinkTestRecord)
+			assert.Nil(t, err)
+			assert.Equal(t, scenario.expected, string(message.Data))
+			assert.Equal(t, 4, message.Severity)
+		})
+	}
+
+	message, err := formatEventSinkMessage(&EventSink{}, eventSinkTestRecord)
+	assert.Nil(t, err)
+	assert.True(t, json.Valid(message.Data))
+	assert.True(t, strings.HasPrefix(getSyslogMessage(message), "<84>1 2023-01-01T00:00:00Z "))
+}
+
+func sendTestMessages(t *testing.T, client eventSinkClient, count int) {
+	messages := []*eventSinkMessage{}
+	for i := 0; i < count; i++ {
+		messages = append(messages, &eventSinkMessage{Key: "built-in", MsgId: "login.failed", Data: []byte(fmt.Sprintf("message%d", i))})
+	}
+
+	assert.Nil(t, client.send(messages))
+	client.close()
+}
+
+func TestSyslogEventSinkClient(t *testing.T) {
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer udpConn.Close()
+
+	sendTestMessages(t, &syslogEventSinkClient{network: "udp", address: udpConn.LocalAddr().String()}, 1)
+	buffer := make([]byte, 1024)
+	_ = udpConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := udpConn.ReadFrom(buffer)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(string(buffer[:n]), " casdoor - login.failed - message0"))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	sendTestMessages(t, &syslogEventSinkClient{network: "tcp", address: listener.Addr().String()}, 2)
+	conn, err := listener.Accept()
+	assert.Nil(t, err)
+	data, err := io.ReadAll(bufio.NewReader(conn))
+	assert.Nil(t, err)
+
+	// every message is prefixed with its length
+	messages := strings.SplitN(string(data), " ", 2)
+	assert.Equal(t, fmt.Sprintf("%d", len(getSyslogMessage(&eventSinkMessage{MsgId: "login.failed", Data: []byte("message0")}))), messages[0])
+	assert.Contains(t, string(data), "message1")
+}
+
+func TestHttpEventSinkClient(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	sendTestMessages(t, &httpEventSinkClient{url: server.URL}, 2)
+	assert.Equal(t, "message0\nmessage1", body)
+}
+
+func TestKafkaEventSinkClient(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("casdoor", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	sendTestMessages(t, &kafkaEventSinkClient{brokers: []string{broker.Addr()}, topic: "casdoor"}, 2)
+}
+
+// serveTestNats speaks the part of the NATS protocol that the client needs to publish: INFO, CONNECT, PING and PUB
+func serveTestNats(t *testing.T, listener net.Listener, payloads chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("INFO {\"server_id\":\"test\",\"version\":\"2.5.0\",\"max_payload\":1048576,\"proto\":1}\r\n"))
+	assert.Nil(t, err)
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			_, err = conn.Write([]byte("PONG\r\n"))
+			assert.Nil(t, err)
+		case "PUB":
+			size, err := strconv.Atoi(fields[len(fields)-1])
+			assert.Nil(t, err)
+			payload := make([]byte, size+2)
+			_, err = io.ReadFull(reader, payload)
+			assert.Nil(t, err)
+			assert.Equal(t, "casdoor", fields[1])
+			payloads <- string(payload[:size])
+		}
+	}
+}
+
+func TestNatsEventSinkClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	payloads := make(chan string, 2)
+	go serveTestNats(t, listener, payloads)
+
+	sendTestMessages(t, &natsEventSinkClient{url: "nats://" + listener.Addr().String(), subject: "casdoor"}, 2)
+	for i := 0; i < 2; i++ {
+		select {
+		case payload := <-payloads:
+			assert.Equal(t, fmt.Sprintf("message%d", i), payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the message wasn't published")
+		}
+	}
+}
+
+type failingEventSinkClient struct{}
+
+func (c *failingEventSinkClient) send(messages []*eventSinkMessage) error {
+	return fmt.Errorf("the sink is down")
+}
+
+func (c *failingEventSinkClient) close() {}
+
+func TestEventSinkWorkerBackPressure(t *testing.T) {
+	worker := &eventSinkWorker{
+		eventSink: &EventSink{Owner: "admin", Name: "sink1", BatchSize: 1},
+		client:    &failingEventSinkClient{},
+		queue:     make(chan *Record, 2),
+		stop:      make(chan struct{}),
+	}
+
+	// the worker isn't running, so the queue only takes 2 records
+	for i := 0; i < 5; i++ {
+		worker.enqueue(eventSinkTestRecord)
+	}
+	assert.Equal(t, int64(3), worker.status.Dropped)
+
+	go worker.run()
+	time.Sleep(100 * time.Millisecond)
+	close(worker.stop)
+	time.Sleep(100 * time.Millisecond)
+
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	assert.Equal(t, int64(0), worker.status.Sent)
+	assert.Equal(t, "the sink is down", worker.status.LastError)
+}
+
+func TestEventSinkOrganization(t *testing.T) {
+	record := &Record{Organization: "org1", Action: "login"}
+	scenarios := []struct {
+		owner        string
+		organization string
+		isMatched    bool
+	}{
+		{"admin", "", true},
+		{"admin", "org1", true},
+		{"admin", "org2", false},
+		{"org1", "org1", true},
+		{"org2", "", false},
+		{"org2", "org1", false},
+	}
+	for _, scenario := range scenarios {
+		worker := &eventSinkWorker{eventSink: &EventSink{Owner: scenario.owner, Organization: scenario.organization}}
+		assert.Equal(t, scenario.isMatched, worker.isMatched(record), scenario)
+	}
+
+	eventSink := &EventSink{Owner: "org2", Type: EventSinkTypeHttp, Endpoint: "http://localhost"}
+	assert.NotNil(t, checkEventSink(eventSink))
+	eventSink.Organization = "org2"
+	assert.Nil(t, checkEventSink(eventSink))
+}
@@ -0,0 +1,266 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+)
+
+const eventSinkMaxBackoff = 30 * time.Second
+
+// EventSinkStatus is the state of the worker of a sink in this replica
+type EventSinkStatus struct {
+	Queued       int    `json:"queued"`
+	Sent         int64  `json:"sent"`
+	Dropped      int64  `json:"dropped"`
+	Failures     int64  `json:"failures"`
+	LastError    string `json:"lastError"`
+	LastSentTime string `json:"lastSentTime"`
+}
+
+// eventSinkWorker sends the queued records of a sink in batches. While a batch can't be sent, it's retried with a
+// backoff and the queue fills up, the records that don't fit in the queue anymore are dropped and counted, so that
+// a slow sink never blocks the requests
+type eventSinkWorker struct {
+	eventSink *EventSink
+	client    eventSinkClient
+	queue     chan *Record
+	stop      chan struct{}
+
+	mutex  sync.Mutex
+	status EventSinkStatus
+}
+
+var (
+	eventSinkWorkers      = map[string]*eventSinkWorker{}
+	eventSinkWorkersMutex sync.RWMutex
+)
+
+func startEventSinkWorker(eventSink *EventSink) {
+	if !eventSink.IsEnabled {
+		return
+	}
+
+	bufferSize := eventSink.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+
+	worker := &eventSinkWorker{
+		eventSink: eventSink,
+		client:    newEventSinkClient(eventSink),
+		queue:     make(chan *Record, bufferSize),
+		stop:      make(chan struct{}),
+	}
+
+	// the sync job and an update can start the same sink at the same time, the replaced worker is stopped
+	eventSinkWorkersMutex.Lock()
+	oldWorker, ok := eventSinkWorkers[eventSink.GetId()]
+	eventSinkWorkers[eventSink.GetId()] = worker
+	eventSinkWorkersMutex.Unlock()
+
+	if ok {
+		close(oldWorker.stop)
+	}
+
+	util.SafeGoroutine(worker.run)
+}
+
+func stopEventSinkWorker(id string) {
+	eventSinkWorkersMutex.Lock()
+	worker, ok := eventSinkWorkers[id]
+	delete(eventSinkWorkers, id)
+	eventSinkWorkersMutex.Unlock()
+
+	if ok {
+		close(worker.stop)
+	}
+}
+
+func getEventSinkStatus(id string) *EventSinkStatus {
+	eventSinkWorkersMutex.RLock()
+	worker, ok := eventSinkWorkers[id]
+	eventSinkWorkersMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+
+	status := worker.status
+	status.Queued = len(worker.queue)
+	return &status
+}
+
+func (w *eventSinkWorker) isMatched(record *Record) bool {
+	if w.eventSink.Owner != "admin" && w.eventSink.Owner != record.Organization {
+		return false
+	}
+	if w.eventSink.Organization != "" && w.eventSink.Organization != record.Organization {
+		return false
+	}
+	if len(w.eventSink.Events) == 0 {
+		return true
+	}
+
+	for _, event := range w.eventSink.Events {
+		if record.Action == event || record.Type == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *eventSinkWorker) enqueue(record *Record) {
+	select {
+	case w.queue <- record:
+	default:
+		w.mutex.Lock()
+		w.status.Dropped += 1
+		w.mutex.Unlock()
+	}
+}
+
+func (w *eventSinkWorker) run() {
+	batchSize := w.eventSink.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushInterval := time.Duration(w.eventSink.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	defer w.client.close()
+
+	batch := []*Record{}
+	for {
+		select {
+		case record := <-w.queue:
+			batch = append(batch, record)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-w.stop:
+			if len(batch) != 0 {
+				w.send(batch)
+			}
+			return
+		}
+
+		if !w.flush(batch) {
+			return
+		}
+		batch = []*Record{}
+	}
+}
+
+// flush sends the batch until it succeeds, it returns false if the worker is stopped in the meantime
+func (w *eventSinkWorker) flush(batch []*Record) bool {
+	backoff := time.Second
+	for {
+		if w.send(batch) == nil {
+			return true
+		}
+
+		select {
+		case <-w.stop:
+			w.mutex.Lock()
+			w.status.Dropped += int64(len(batch))
+			w.mutex.Unlock()
+			return false
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > eventSinkMaxBackoff {
+			backoff = eventSinkMaxBackoff
+		}
+	}
+}
+
+func (w *eventSinkWorker) send(batch []*Record) error {
+	messages := []*eventSinkMessage{}
+	for _, record := range batch {
+		message, err := formatEventSinkMessage(w.eventSink, record)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	}
+
+	err := w.client.send(messages)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err != nil {
+		w.status.Failures += 1
+		w.status.LastError = err.Error()
+		logs.Warning(fmt.Sprintf("failed to send %d records to event sink: %s, error: %s", len(batch), w.eventSink.GetId(), err.Error()))
+		return err
+	}
+
+	w.status.Sent += int64(len(batch))
+	w.status.LastSentTime = util.GetCurrentTime()
+	return nil
+}
+
+// sendEventSinks queues the record to the sinks of its organization, the user is only loaded for the sinks with a filter
+func sendEventSinks(record *Record) {
+	eventSinkWorkersMutex.RLock()
+	workers := []*eventSinkWorker{}
+	for _, worker := range eventSinkWorkers {
+		if worker.isMatched(record) {
+			workers = append(workers, worker)
+		}
+	}
+	eventSinkWorkersMutex.RUnlock()
+
+	var payload *WebhookPayload
+	for _, worker := range workers {
+		if worker.eventSink.Filter != "" {
+			if payload == nil {
+				user, err := getUser(record.Organization, record.User)
+				if err != nil {
+					logs.Warning(fmt.Sprintf("failed to get the user of record: %s, error: %s", record.Name, err.Error()))
+				}
+				payload = &WebhookPayload{Record: *record, ExtendedUser: user}
+			}
+
+			matched, err := evaluateEventFilter(worker.eventSink.Filter, payload)
+			if err != nil {
+				logs.Warning(fmt.Sprintf("failed to evaluate the filter of event sink: %s, error: %s", worker.eventSink.GetId(), err.Error()))
+				continue
+			}
+			if !matched {
+				continue
+			}
+		}
+
+		worker.enqueue(record)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var (
+	geoIpReader *maxminddb.Reader
+	geoIpOnce   sync.Once
+)
+
+type geoIpRecord struct {
+	Country struct {
+		IsoCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// getGeoIpReader opens the offline GeoIP database of "geoIpDatabase" in app.conf, like a GeoLite2-Country.mmdb,
+// the IPs aren't located if it isn't configured
+func getGeoIpReader() *maxminddb.Reader {
+	geoIpOnce.Do(func() {
+		path := conf.GetConfigString("geoIpDatabase")
+		if path == "" {
+			return
+		}
+
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to open the GeoIP database: %s, error: %s", path, err.Error()))
+			return
+		}
+		geoIpReader = reader
+	})
+	return geoIpReader
+}
+
+// getCountryCode returns the ISO code of the country of the IP, or "" if it's unknown
+func getCountryCode(clientIp string) string {
+	reader := getGeoIpReader()
+	if reader == nil {
+		return ""
+	}
+
+	ip := net.ParseIP(clientIp)
+	if ip == nil {
+		return ""
+	}
+
+	var record geoIpRecord
+	err := reader.Lookup(ip, &record)
+	if err != nil {
+		return ""
+	}
+	return record.Country.IsoCode
+}
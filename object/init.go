@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"encoding/gob"
 	"fmt"
 	"os"
@@ -82,7 +83,7 @@ func getBuiltInAccountItems() []*AccountItem {
 }
 
 func initBuiltInOrganization() bool {
-	organization, err := getOrganization("admin", "built-in")
+	organization, err := getOrganization(context.Background(), "admin", "built-in")
 	if err != nil {
 		panic(err)
 	}
@@ -157,7 +158,7 @@ func initBuiltInUser() {
 }
 
 func initBuiltInApplication() {
-	application, err := getApplication("admin", "app-built-in")
+	application, err := getApplication(context.Background(), "admin", "app-built-in")
 	if err != nil {
 		panic(err)
 	}
@@ -274,7 +275,7 @@ func initBuiltInLdap() {
 }
 
 func initBuiltInProvider() {
-	provider, err := GetProvider(util.GetId("admin", "provider_captcha_default"))
+	provider, err := GetProvider(context.Background(), util.GetId("admin", "provider_captcha_default"))
 	if err != nil {
 		panic(err)
 	}
@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/util"
 )
@@ -189,7 +190,7 @@ func readInitDataFromFile(filePath string) (*InitData, error) {
 }
 
 func initDefinedOrganization(organization *Organization) {
-	existed, err := getOrganization(organization.Owner, organization.Name)
+	existed, err := getOrganization(context.Background(), organization.Owner, organization.Name)
 	if err != nil {
 		panic(err)
 	}
@@ -207,7 +208,7 @@ func initDefinedOrganization(organization *Organization) {
 }
 
 func initDefinedApplication(application *Application) {
-	existed, err := getApplication(application.Owner, application.Name)
+	existed, err := getApplication(context.Background(), application.Owner, application.Name)
 	if err != nil {
 		panic(err)
 	}
@@ -271,7 +272,7 @@ func initDefinedLdap(ldap *Ldap) {
 }
 
 func initDefinedProvider(provider *Provider) {
-	existed, err := GetProvider(util.GetId("admin", provider.Name))
+	existed, err := GetProvider(context.Background(), util.GetId("admin", provider.Name))
 	if err != nil {
 		panic(err)
 	}
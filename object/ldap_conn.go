@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -260,7 +261,7 @@ func SyncLdapUsers(owner string, syncUsers []LdapUser, ldapId string) (existUser
 		uuids = append(uuids, user.Uuid)
 	}
 
-	organization, err := getOrganization("admin", owner)
+	organization, err := getOrganization(context.Background(), "admin", owner)
 	if err != nil {
 		panic(err)
 	}
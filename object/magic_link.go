@@ -0,0 +1,232 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	defaultMagicLinkTitle   = "Sign in to %s"
+	defaultMagicLinkContent = "You have requested to sign in at %s. Click the link to continue: <a href=\"%s\">%s</a>, the link expires in %d minutes and can only be used once."
+)
+
+type MagicLink struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User        string `xorm:"varchar(100) index" json:"user"`
+	Application string `xorm:"varchar(100)" json:"application"`
+	Provider    string `xorm:"varchar(100)" json:"provider"`
+	Receiver    string `xorm:"varchar(100)" json:"receiver"`
+	RemoteAddr  string `xorm:"varchar(100)" json:"remoteAddr"`
+	SessionId   string `xorm:"varchar(100)" json:"-"`
+	AuthForm    string `xorm:"mediumtext" json:"-"`
+	Query       string `xorm:"mediumtext" json:"-"`
+	ExpireTime  int64  `json:"expireTime"`
+	IsUsed      bool   `json:"isUsed"`
+}
+
+func getMagicLinkTimeout() int64 {
+	timeout, err := conf.GetConfigInt64("verificationCodeTimeout")
+	if err != nil || timeout <= 0 {
+		timeout = 10
+	}
+	return timeout
+}
+
+// the link is signed with the application's client secret, so a leaked database row
+// without the secret is not enough to forge a working link
+func getMagicLinkSignature(application *Application, id string, expireTime int64) string {
+	mac := hmac.New(sha256.New, []byte(application.ClientSecret))
+	mac.Write([]byte(fmt.Sprintf("%s.%s.%d", application.GetId(), id, expireTime)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isAllowSendMagicLink allows one magic link per minute for the user and one per minute from the IP address, the
+// two limits are separate so that changing the IP address doesn't allow more links to the same inbox
+func isAllowSendMagicLink(ctx context.Context, user *User, remoteAddr string) error {
+	conditions := []MagicLink{{User: user.GetId()}}
+	if remoteAddr != "" {
+		conditions = append(conditions, MagicLink{RemoteAddr: remoteAddr})
+	}
+
+	for _, condition := range conditions {
+		magicLink := condition
+		has, err := ormer.Engine.Context(ctx).Desc("created_time").Get(&magicLink)
+		if err != nil {
+			return err
+		}
+
+		createdTime, _ := time.Parse(time.RFC3339, magicLink.CreatedTime)
+		if has && time.Since(createdTime) < time.Minute {
+			return errors.New("you can only send one code in 60s")
+		}
+	}
+
+	return nil
+}
+
+func SendMagicLinkToEmail(ctx context.Context, organization *Organization, application *Application, user *User, provider *Provider, remoteAddr string, sessionId string, host string, authForm string, query string) error {
+	if provider == nil {
+		return fmt.Errorf("please set an Email provider first")
+	}
+	if application.ClientSecret == "" {
+		return fmt.Errorf("the application: %s has no client secret to sign the magic link", application.GetId())
+	}
+
+	// magic links share the 60s limit and the daily quotas with verification codes
+	if err := IsAllowSend(ctx, user, remoteAddr, provider.Category, user.Email); err != nil {
+		return err
+	}
+	if err := isAllowSendMagicLink(ctx, user, remoteAddr); err != nil {
+		return err
+	}
+
+	timeout := getMagicLinkTimeout()
+	magicLink := &MagicLink{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        user.GetId(),
+		Application: application.Name,
+		Provider:    provider.Name,
+		Receiver:    user.Email,
+		RemoteAddr:  remoteAddr,
+		SessionId:   sessionId,
+		AuthForm:    authForm,
+		Query:       query,
+		ExpireTime:  time.Now().Add(time.Duration(timeout) * time.Minute).Unix(),
+	}
+
+	_, originBackend := getOriginFromHost(host)
+	params := url.Values{}
+	params.Set("id", magicLink.GetId())
+	params.Set("expires", fmt.Sprintf("%d", magicLink.ExpireTime))
+	params.Set("signature", getMagicLinkSignature(application, magicLink.GetId(), magicLink.ExpireTime))
+	link := fmt.Sprintf("%s/api/login/magic-link?%s", originBackend, params.Encode())
+
+	sender := organization.DisplayName
+	title := provider.MagicLinkTitle
+	if title == "" {
+		title = fmt.Sprintf(defaultMagicLinkTitle, application.DisplayName)
+	}
+
+	var content string
+	if provider.MagicLinkContent != "" {
+		// "Click the link to sign in at Casdoor: %link%, it expires in %timeout% minutes."
+		content = strings.NewReplacer(
+			"%link%", link,
+			"%application%", application.DisplayName,
+			"%user%", user.Name,
+			"%timeout%", fmt.Sprintf("%d", timeout),
+		).Replace(provider.MagicLinkContent)
+	} else {
+		content = fmt.Sprintf(defaultMagicLinkContent, application.DisplayName, link, link, timeout)
+	}
+
+	if err := SendEmail(ctx, provider, title, content, user.Email, sender); err != nil {
+		return err
+	}
+
+	_, err := ormer.Engine.Insert(magicLink)
+	return err
+}
+
+func getMagicLink(owner string, name string) (*MagicLink, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	magicLink := MagicLink{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&magicLink)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &magicLink, nil
+	}
+	return nil, nil
+}
+
+// ConsumeMagicLink validates a clicked magic link and marks it as used. The link must carry a valid
+// signature, must not be expired or used before, and must be opened in the browser session that requested it.
+func ConsumeMagicLink(id string, expires string, signature string, sessionId string, lang string) (*MagicLink, *Application, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	magicLink, err := getMagicLink(owner, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if magicLink == nil || fmt.Sprintf("%d", magicLink.ExpireTime) != expires {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link is invalid"))
+	}
+
+	application, err := getApplication(context.Background(), "admin", magicLink.Application)
+	if err != nil {
+		return nil, nil, err
+	}
+	if application == nil {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), magicLink.Application)
+	}
+
+	expectedSignature := getMagicLinkSignature(application, magicLink.GetId(), magicLink.ExpireTime)
+	if !hmac.Equal([]byte(expectedSignature), []byte(signature)) {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link is invalid"))
+	}
+
+	if magicLink.IsUsed {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link has already been used"))
+	}
+
+	if time.Now().Unix() > magicLink.ExpireTime {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link has expired"))
+	}
+
+	if magicLink.SessionId == "" || magicLink.SessionId != sessionId {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link must be opened in the browser that requested it"))
+	}
+
+	// conditional update so that two concurrent clicks can't both succeed
+	affected, err := ormer.Engine.ID(core.PK{magicLink.Owner, magicLink.Name}).Where("is_used = ?", false).Cols("is_used").Update(&MagicLink{IsUsed: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	if affected == 0 {
+		return nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The magic link has already been used"))
+	}
+
+	magicLink.IsUsed = true
+	return magicLink, application, nil
+}
+
+func (magicLink *MagicLink) GetId() string {
+	return fmt.Sprintf("%s/%s", magicLink.Owner, magicLink.Name)
+}
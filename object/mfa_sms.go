@@ -64,7 +64,7 @@ func (mfa *SmsMfa) SetupVerify(ctx *context.Context, passCode string) error {
 		dest, _ = util.GetE164Number(dest, countryCode)
 	}
 
-	if result := CheckVerificationCode(dest, passCode, "en"); result.Code != VerificationSuccess {
+	if result := CheckVerificationCode(dest, passCode, VerifyPurposeMfaSetup, "en"); result.Code != VerificationSuccess {
 		return errors.New(result.Msg)
 	}
 	return nil
@@ -118,7 +118,7 @@ func (mfa *SmsMfa) Verify(passCode string) error {
 	if !util.IsEmailValid(mfa.Config.Secret) {
 		mfa.Config.Secret, _ = util.GetE164Number(mfa.Config.Secret, mfa.Config.CountryCode)
 	}
-	if result := CheckVerificationCode(mfa.Config.Secret, passCode, "en"); result.Code != VerificationSuccess {
+	if result := CheckVerificationCode(mfa.Config.Secret, passCode, VerifyPurposeMfaAuth, "en"); result.Code != VerificationSuccess {
 		return errors.New(result.Msg)
 	}
 	return nil
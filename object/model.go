@@ -29,7 +29,8 @@ type Model struct {
 	DisplayName string `xorm:"varchar(100)" json:"displayName"`
 	Description string `xorm:"varchar(100)" json:"description"`
 
-	ModelText string `xorm:"mediumtext" json:"modelText"`
+	ModelText      string   `xorm:"mediumtext" json:"modelText"`
+	AbacAttributes []string `xorm:"mediumtext" json:"abacAttributes"`
 
 	model.Model `xorm:"-" json:"-"`
 }
@@ -89,6 +90,11 @@ func UpdateModelWithCheck(id string, modelObj *Model) error {
 	if err != nil {
 		return err
 	}
+
+	err = checkAbacAttributes(modelObj.AbacAttributes)
+	if err != nil {
+		return err
+	}
 	_, err = UpdateModel(id, modelObj)
 	if err != nil {
 		return err
@@ -105,6 +111,8 @@ func UpdateModel(id string, modelObj *Model) (bool, error) {
 		return false, nil
 	}
 
+	addPolicyBaselines(PolicyVersionTypeModel, id)
+
 	if name != modelObj.Name {
 		err := modelChangeTrigger(name, modelObj.Name)
 		if err != nil {
@@ -117,24 +125,47 @@ func UpdateModel(id string, modelObj *Model) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		// a renamed model leaves an empty version under its old id
+		if id != modelObj.GetId() {
+			addPolicyVersions(PolicyVersionTypeModel, "rename", id)
+		}
+		addPolicyVersions(PolicyVersionTypeModel, "update", modelObj.GetId())
+	}
+
 	return affected != 0, err
 }
 
 func AddModel(model *Model) (bool, error) {
+	err := checkAbacAttributes(model.AbacAttributes)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(model)
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeModel, "add", model.GetId())
+	}
+
 	return affected != 0, nil
 }
 
 func DeleteModel(model *Model) (bool, error) {
+	addPolicyBaselines(PolicyVersionTypeModel, model.GetId())
+
 	affected, err := ormer.Engine.ID(core.PK{model.Owner, model.Name}).Delete(&Model{})
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypeModel, "delete", model.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -15,6 +15,9 @@
 package object
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 
@@ -51,22 +54,25 @@ type Organization struct {
 	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
 	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
 
-	DisplayName        string     `xorm:"varchar(100)" json:"displayName"`
-	WebsiteUrl         string     `xorm:"varchar(100)" json:"websiteUrl"`
-	Favicon            string     `xorm:"varchar(100)" json:"favicon"`
-	PasswordType       string     `xorm:"varchar(100)" json:"passwordType"`
-	PasswordSalt       string     `xorm:"varchar(100)" json:"passwordSalt"`
-	PasswordOptions    []string   `xorm:"varchar(100)" json:"passwordOptions"`
-	CountryCodes       []string   `xorm:"varchar(200)"  json:"countryCodes"`
-	DefaultAvatar      string     `xorm:"varchar(200)" json:"defaultAvatar"`
-	DefaultApplication string     `xorm:"varchar(100)" json:"defaultApplication"`
-	Tags               []string   `xorm:"mediumtext" json:"tags"`
-	Languages          []string   `xorm:"varchar(255)" json:"languages"`
-	ThemeData          *ThemeData `xorm:"json" json:"themeData"`
-	MasterPassword     string     `xorm:"varchar(100)" json:"masterPassword"`
-	InitScore          int        `json:"initScore"`
-	EnableSoftDeletion bool       `json:"enableSoftDeletion"`
-	IsProfilePublic    bool       `json:"isProfilePublic"`
+	DisplayName         string     `xorm:"varchar(100)" json:"displayName"`
+	WebsiteUrl          string     `xorm:"varchar(100)" json:"websiteUrl"`
+	Favicon             string     `xorm:"varchar(100)" json:"favicon"`
+	PasswordType        string     `xorm:"varchar(100)" json:"passwordType"`
+	PasswordSalt        string     `xorm:"varchar(100)" json:"passwordSalt"`
+	PasswordHashConfig  string     `xorm:"varchar(500)" json:"passwordHashConfig"`
+	PasswordOptions     []string   `xorm:"varchar(100)" json:"passwordOptions"`
+	CountryCodes        []string   `xorm:"varchar(200)"  json:"countryCodes"`
+	DefaultAvatar       string     `xorm:"varchar(200)" json:"defaultAvatar"`
+	DefaultApplication  string     `xorm:"varchar(100)" json:"defaultApplication"`
+	Tags                []string   `xorm:"mediumtext" json:"tags"`
+	Languages           []string   `xorm:"varchar(255)" json:"languages"`
+	ThemeData           *ThemeData `xorm:"json" json:"themeData"`
+	MasterPassword      string     `xorm:"varchar(100)" json:"masterPassword"`
+	ScimToken           string     `xorm:"varchar(100) index" json:"scimToken"`
+	InitScore           int        `json:"initScore"`
+	EnableSoftDeletion  bool       `json:"enableSoftDeletion"`
+	IsProfilePublic     bool       `json:"isProfilePublic"`
+	RecordRetentionDays int        `json:"recordRetentionDays"`
 
 	MfaItems     []*MfaItem     `xorm:"varchar(300)" json:"mfaItems"`
 	AccountItems []*AccountItem `xorm:"varchar(5000)" json:"accountItems"`
@@ -120,13 +126,13 @@ func GetPaginationOrganizations(owner string, name string, offset, limit int, fi
 	return organizations, nil
 }
 
-func getOrganization(owner string, name string) (*Organization, error) {
+func getOrganization(ctx context.Context, owner string, name string) (*Organization, error) {
 	if owner == "" || name == "" {
 		return nil, nil
 	}
 
 	organization := Organization{Owner: owner, Name: name}
-	existed, err := ormer.Engine.Get(&organization)
+	existed, err := ormer.Engine.Context(ctx).Get(&organization)
 	if err != nil {
 		return nil, err
 	}
@@ -138,9 +144,9 @@ func getOrganization(owner string, name string) (*Organization, error) {
 	return nil, nil
 }
 
-func GetOrganization(id string) (*Organization, error) {
+func GetOrganization(ctx context.Context, id string) (*Organization, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	return getOrganization(owner, name)
+	return getOrganization(ctx, owner, name)
 }
 
 func GetMaskedOrganization(organization *Organization, errs ...error) (*Organization, error) {
@@ -155,6 +161,12 @@ func GetMaskedOrganization(organization *Organization, errs ...error) (*Organiza
 	if organization.MasterPassword != "" {
 		organization.MasterPassword = "***"
 	}
+	if organization.ScimToken != "" {
+		organization.ScimToken = "***"
+	}
+	if organization.PasswordHashConfig != "" {
+		organization.PasswordHashConfig = "***"
+	}
 	return organization, nil
 }
 
@@ -176,7 +188,7 @@ func GetMaskedOrganizations(organizations []*Organization, errs ...error) ([]*Or
 
 func UpdateOrganization(id string, organization *Organization) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if org, err := getOrganization(owner, name); err != nil {
+	if org, err := getOrganization(context.Background(), owner, name); err != nil {
 		return false, err
 	} else if org == nil {
 		return false, nil
@@ -196,15 +208,25 @@ func UpdateOrganization(id string, organization *Organization) (bool, error) {
 	if organization.MasterPassword != "" && organization.MasterPassword != "***" {
 		credManager := cred.GetCredManager(organization.PasswordType)
 		if credManager != nil {
-			hashedPassword := credManager.GetHashedPassword(organization.MasterPassword, "", organization.PasswordSalt)
+			hashedPassword := credManager.GetHashedPassword(organization.MasterPassword, "", organization.getCredSalt(organization.PasswordType))
 			organization.MasterPassword = hashedPassword
 		}
 	}
 
+	if organization.ScimToken != "" && organization.ScimToken != "***" {
+		organization.ScimToken = getScimTokenHash(organization.ScimToken)
+	}
+
 	session := ormer.Engine.ID(core.PK{owner, name}).AllCols()
 	if organization.MasterPassword == "***" {
 		session.Omit("master_password")
 	}
+	if organization.ScimToken == "***" {
+		session.Omit("scim_token")
+	}
+	if organization.PasswordHashConfig == "***" {
+		session.Omit("password_hash_config")
+	}
 	affected, err := session.Update(organization)
 	if err != nil {
 		return false, err
@@ -214,6 +236,10 @@ func UpdateOrganization(id string, organization *Organization) (bool, error) {
 }
 
 func AddOrganization(organization *Organization) (bool, error) {
+	if organization.ScimToken != "" {
+		organization.ScimToken = getScimTokenHash(organization.ScimToken)
+	}
+
 	affected, err := ormer.Engine.Insert(organization)
 	if err != nil {
 		return false, err
@@ -240,7 +266,7 @@ func GetOrganizationByUser(user *User) (*Organization, error) {
 		return nil, nil
 	}
 
-	return getOrganization("admin", user.Owner)
+	return getOrganization(context.Background(), "admin", user.Owner)
 }
 
 func GetAccountItemByName(name string, organization *Organization) *AccountItem {
@@ -276,7 +302,7 @@ func CheckAccountItemModifyRule(accountItem *AccountItem, isAdmin bool, lang str
 }
 
 func GetDefaultApplication(id string) (*Application, error) {
-	organization, err := GetOrganization(id)
+	organization, err := GetOrganization(context.Background(), id)
 	if err != nil {
 		return nil, err
 	}
@@ -286,7 +312,7 @@ func GetDefaultApplication(id string) (*Application, error) {
 	}
 
 	if organization.DefaultApplication != "" {
-		defaultApplication, err := getApplication("admin", organization.DefaultApplication)
+		defaultApplication, err := getApplication(context.Background(), "admin", organization.DefaultApplication)
 		if err != nil {
 			return nil, err
 		}
@@ -316,12 +342,12 @@ func GetDefaultApplication(id string) (*Application, error) {
 		}
 	}
 
-	err = extendApplicationWithProviders(defaultApplication)
+	err = extendApplicationWithProviders(context.Background(), defaultApplication)
 	if err != nil {
 		return nil, err
 	}
 
-	err = extendApplicationWithOrg(defaultApplication)
+	err = extendApplicationWithOrg(context.Background(), defaultApplication)
 	if err != nil {
 		return nil, err
 	}
@@ -495,3 +521,35 @@ func (org *Organization) GetInitScore() (int, error) {
 		return strconv.Atoi(conf.GetConfigString("initScore"))
 	}
 }
+
+// the SCIM bearer token is stored as a hash like a password, it can only be seen when it is set
+func getScimTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func GetOrganizationByScimToken(token string) (*Organization, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	organization := Organization{Owner: "admin", ScimToken: getScimTokenHash(token)}
+	existed, err := ormer.Engine.Get(&organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &organization, nil
+	}
+	return nil, nil
+}
+
+// getCredSalt returns the organization salt given to the cred manager of the password type, the Firebase hashes
+// take the hash config of the Firebase project instead, so that it doesn't change the salt of the other types
+func (org *Organization) getCredSalt(passwordType string) string {
+	if passwordType == "firebase-scrypt" {
+		return org.PasswordHashConfig
+	}
+	return org.PasswordSalt
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/beego/beego"
 	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
 	xormadapter "github.com/casdoor/xorm-adapter/v3"
 	_ "github.com/denisenkom/go-mssqldb" // db = mssql
@@ -217,6 +218,8 @@ func (a *Ormer) open() error {
 		}
 	}
 
+	engine.AddHook(&telemetry.XormHook{DbSystem: a.driverName})
+
 	a.Engine = engine
 	return nil
 }
@@ -359,4 +362,94 @@ func (a *Ormer) createTable() {
 	if err != nil {
 		panic(err)
 	}
+
+	err = a.Engine.Sync2(new(MagicLink))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecoveryRequest))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisioningTask))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ProvisionedUser))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Assignment))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(ApprovalRequest))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(AccessReview))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(AccessReviewItem))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(PolicyVersion))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(PolicyRevision))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(Record))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordChainHead))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(RecordCheckpoint))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(WebhookDelivery))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(EventSink))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(DashboardStat))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(DashboardActivity))
+	if err != nil {
+		panic(err)
+	}
+
+	err = a.Engine.Sync2(new(SigninActivity))
+	if err != nil {
+		panic(err)
+	}
 }
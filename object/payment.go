@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/casdoor/casdoor/pp"
@@ -162,7 +163,7 @@ func notifyPayment(body []byte, owner string, paymentName string) (*Payment, *pp
 		return nil, nil, err
 	}
 
-	provider, err := getProvider(owner, payment.Provider)
+	provider, err := getProvider(context.Background(), owner, payment.Provider)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -221,7 +222,7 @@ func NotifyPayment(body []byte, owner string, paymentName string) (*Payment, err
 }
 
 func invoicePayment(payment *Payment) (string, error) {
-	provider, err := getProvider(payment.Owner, payment.Provider)
+	provider, err := getProvider(context.Background(), payment.Owner, payment.Provider)
 	if err != nil {
 		panic(err)
 	}
@@ -149,6 +149,8 @@ func UpdatePermission(id string, permission *Permission) (bool, error) {
 		return false, nil
 	}
 
+	addPolicyBaselines(PolicyVersionTypePermission, id)
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(permission)
 	if err != nil {
 		return false, err
@@ -166,8 +168,16 @@ func UpdatePermission(id string, permission *Permission) (bool, error) {
 				}
 			}
 		}
-		addGroupingPolicies(permission)
-		addPolicies(permission)
+		if permission.isApproved() {
+			addGroupingPolicies(permission)
+			addPolicies(permission)
+		}
+
+		// a renamed permission leaves an empty version under its old id
+		if id != permission.GetId() {
+			addPolicyVersions(PolicyVersionTypePermission, "rename", id)
+		}
+		addPolicyVersions(PolicyVersionTypePermission, "update", permission.GetId())
 	}
 
 	return affected != 0, nil
@@ -179,11 +189,16 @@ func AddPermission(permission *Permission) (bool, error) {
 		return false, err
 	}
 
-	if affected != 0 {
+	// a pending permission only gets its policies once approved
+	if affected != 0 && permission.isApproved() {
 		addGroupingPolicies(permission)
 		addPolicies(permission)
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypePermission, "add", permission.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -201,10 +216,13 @@ func AddPermissions(permissions []*Permission) bool {
 
 	for _, permission := range permissions {
 		// add using for loop
-		if affected != 0 {
+		if affected != 0 && permission.isApproved() {
 			addGroupingPolicies(permission)
 			addPolicies(permission)
 		}
+		if affected != 0 {
+			addPolicyVersions(PolicyVersionTypePermission, "add", permission.GetId())
+		}
 	}
 	return affected != 0
 }
@@ -236,6 +254,8 @@ func AddPermissionsInBatch(permissions []*Permission) bool {
 }
 
 func DeletePermission(permission *Permission) (bool, error) {
+	addPolicyBaselines(PolicyVersionTypePermission, permission.GetId())
+
 	affected, err := ormer.Engine.ID(core.PK{permission.Owner, permission.Name}).Delete(&Permission{})
 	if err != nil {
 		return false, err
@@ -253,6 +273,8 @@ func DeletePermission(permission *Permission) (bool, error) {
 				}
 			}
 		}
+
+		addPolicyVersions(PolicyVersionTypePermission, "delete", permission.GetId())
 	}
 
 	return affected != 0, nil
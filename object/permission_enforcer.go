@@ -109,6 +109,8 @@ func (p *Permission) setEnforcerModel(enforcer *casbin.Enforcer) error {
 	if err != nil {
 		return err
 	}
+
+	enforcer.AddFunction("userProperty", userPropertyFunction)
 	return nil
 }
 
@@ -136,10 +138,15 @@ func getPolicies(permission *Permission) [][]string {
 	return policies
 }
 
-func getRolesInRole(roleId string, visited map[string]struct{}) ([]*Role, error) {
-	role, err := GetRole(roleId)
-	if err != nil {
-		return []*Role{}, err
+// getRolesInRoleWithOverrides reads the roles in the overrides instead of the database, it's used to simulate a role change
+func getRolesInRoleWithOverrides(roleId string, visited map[string]struct{}, overrides map[string]*Role) ([]*Role, error) {
+	role, ok := overrides[roleId]
+	var err error
+	if !ok {
+		role, err = GetRole(roleId)
+		if err != nil {
+			return []*Role{}, err
+		}
 	}
 
 	if role == nil {
@@ -150,7 +157,7 @@ func getRolesInRole(roleId string, visited map[string]struct{}) ([]*Role, error)
 	roles := []*Role{role}
 	for _, subRole := range role.Roles {
 		if _, ok := visited[subRole]; !ok {
-			r, err := getRolesInRole(subRole, visited)
+			r, err := getRolesInRoleWithOverrides(subRole, visited, overrides)
 			if err != nil {
 				return []*Role{}, err
 			}
@@ -163,6 +170,10 @@ func getRolesInRole(roleId string, visited map[string]struct{}) ([]*Role, error)
 }
 
 func getGroupingPolicies(permission *Permission) [][]string {
+	return getGroupingPoliciesWithOverrides(permission, nil)
+}
+
+func getGroupingPoliciesWithOverrides(permission *Permission, overrides map[string]*Role) [][]string {
 	var groupingPolicies [][]string
 
 	domainExist := len(permission.Domains) > 0
@@ -170,7 +181,7 @@ func getGroupingPolicies(permission *Permission) [][]string {
 
 	for _, roleId := range permission.Roles {
 		visited := map[string]struct{}{}
-		rolesInRole, err := getRolesInRole(roleId, visited)
+		rolesInRole, err := getRolesInRoleWithOverrides(roleId, visited, overrides)
 		if err != nil {
 			panic(err)
 		}
@@ -247,14 +258,28 @@ func removePolicies(permission *Permission) {
 
 type CasbinRequest = []interface{}
 
-func Enforce(permission *Permission, request *CasbinRequest, permissionIds ...string) (bool, error) {
+func Enforce(permission *Permission, request *CasbinRequest, abacContext *AbacContext, permissionIds ...string) (bool, error) {
 	enforcer := getPermissionEnforcer(permission, permissionIds...)
-	return enforcer.Enforce(*request...)
+	recordEnforceRequests(getEnforcedPermissionIds(permission, permissionIds), abacContext, *request)
+
+	requests, err := getAbacRequests(permission, []CasbinRequest{*request}, abacContext)
+	if err != nil {
+		return false, err
+	}
+
+	return enforcer.Enforce(requests[0]...)
 }
 
-func BatchEnforce(permission *Permission, requests *[]CasbinRequest, permissionIds ...string) ([]bool, error) {
+func BatchEnforce(permission *Permission, requests *[]CasbinRequest, abacContext *AbacContext, permissionIds ...string) ([]bool, error) {
 	enforcer := getPermissionEnforcer(permission, permissionIds...)
-	return enforcer.BatchEnforce(*requests)
+	recordEnforceRequests(getEnforcedPermissionIds(permission, permissionIds), abacContext, *requests...)
+
+	abacRequests, err := getAbacRequests(permission, *requests, abacContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return enforcer.BatchEnforce(abacRequests)
 }
 
 func getAllValues(userId string, fn func(enforcer *casbin.Enforcer) []string) []string {
@@ -0,0 +1,354 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/log"
+)
+
+// PolicySimulation is a proposed change of a permission, a role or a model, and the requests to check it against.
+// When no request is given, the last real enforce requests of the affected permissions are replayed
+type PolicySimulation struct {
+	Permission  *Permission     `json:"permission"`
+	Role        *Role           `json:"role"`
+	Model       *Model          `json:"model"`
+	Requests    []CasbinRequest `json:"requests"`
+	RecentCount int             `json:"recentCount"`
+}
+
+type SimulationResult struct {
+	Request       CasbinRequest `json:"request"`
+	PermissionIds []string      `json:"permissionIds"`
+	Before        bool          `json:"before"`
+	After         bool          `json:"after"`
+	IsFlipped     bool          `json:"isFlipped"`
+}
+
+type enforceHistoryEntry struct {
+	permissionIds []string
+	request       CasbinRequest
+	abacContext   *AbacContext
+}
+
+// the last enforce requests of the permissions are kept in memory so that a change can be simulated against real traffic
+var (
+	enforceHistory      []*enforceHistoryEntry
+	enforceHistoryIndex int
+	enforceHistoryMutex sync.Mutex
+)
+
+func getEnforcedPermissionIds(permission *Permission, permissionIds []string) []string {
+	if len(permissionIds) != 0 {
+		return permissionIds
+	}
+	return []string{permission.GetId()}
+}
+
+func recordEnforceRequests(permissionIds []string, abacContext *AbacContext, requests ...CasbinRequest) {
+	size := int(getConfigLimit("enforceHistorySize", 1000))
+	if size <= 0 {
+		return
+	}
+
+	enforceHistoryMutex.Lock()
+	defer enforceHistoryMutex.Unlock()
+
+	for _, request := range requests {
+		entry := &enforceHistoryEntry{permissionIds: permissionIds, request: request, abacContext: abacContext}
+		if len(enforceHistory) < size {
+			enforceHistory = append(enforceHistory, entry)
+		} else {
+			enforceHistory[enforceHistoryIndex%size] = entry
+		}
+		enforceHistoryIndex = (enforceHistoryIndex + 1) % size
+	}
+}
+
+// getRecentEnforceRequests returns up to count of the latest requests that involved one of the permissions, newest first
+func getRecentEnforceRequests(permissionIds map[string]bool, count int) []*enforceHistoryEntry {
+	enforceHistoryMutex.Lock()
+	defer enforceHistoryMutex.Unlock()
+
+	res := []*enforceHistoryEntry{}
+	size := len(enforceHistory)
+	for i := 1; i <= size && len(res) < count; i++ {
+		entry := enforceHistory[(enforceHistoryIndex-i+size)%size]
+		for _, permissionId := range entry.permissionIds {
+			if permissionIds[permissionId] {
+				res = append(res, entry)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// simulationState is the set of objects seen by a simulated enforcer, the proposed change replaces the stored objects
+type simulationState struct {
+	permissions map[string]*Permission
+	roles       map[string]*Role
+	model       *Model
+}
+
+func (state *simulationState) getPermission(id string) (*Permission, error) {
+	if permission, ok := state.permissions[id]; ok {
+		return permission, nil
+	}
+	return GetPermission(id)
+}
+
+// getSimulationEnforcer builds an in-memory enforcer like getPermissionEnforcer, but with the policies computed
+// from the given state instead of loaded from the adapter, nothing is persisted
+func getSimulationEnforcer(state *simulationState, permissionIds []string) (*casbin.Enforcer, error) {
+	permissions := []*Permission{}
+	for _, permissionId := range permissionIds {
+		permission, err := state.getPermission(permissionId)
+		if err != nil {
+			return nil, err
+		}
+		if permission != nil {
+			permissions = append(permissions, permission)
+		}
+	}
+	if len(permissions) == 0 {
+		return nil, nil
+	}
+
+	enforcer, err := casbin.NewEnforcer(&log.DefaultLogger{}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	first := permissions[0]
+	if state.model != nil && state.model.Owner == first.Owner && state.model.Name == first.Model {
+		m, err := GetBuiltInModel(state.model.ModelText)
+		if err != nil {
+			return nil, err
+		}
+
+		err = enforcer.InitWithModelAndAdapter(m, nil)
+		if err != nil {
+			return nil, err
+		}
+		enforcer.AddFunction("userProperty", userPropertyFunction)
+	} else {
+		err = first.setEnforcerModel(enforcer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hasRoleDefinition := HasRoleDefinition(enforcer.GetModel())
+	for _, permission := range permissions {
+		if !permission.isApproved() {
+			continue
+		}
+
+		policies := getPolicies(permission)
+		if len(policies) != 0 {
+			_, err = enforcer.AddPolicies(policies)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !hasRoleDefinition {
+			continue
+		}
+
+		groupingPolicies := getGroupingPoliciesWithOverrides(permission, state.roles)
+		if len(groupingPolicies) != 0 {
+			_, err = enforcer.AddGroupingPolicies(groupingPolicies)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return enforcer, nil
+}
+
+// getAffectedPermissionGroups returns the permissions whose decisions may change, grouped the same way
+// as the enforce API groups them by model and adapter
+func getAffectedPermissionGroups(simulation *PolicySimulation) ([][]string, error) {
+	permissions := []*Permission{}
+	if simulation.Permission != nil {
+		permissions = append(permissions, simulation.Permission)
+	}
+
+	if simulation.Role != nil {
+		roleId := simulation.Role.GetId()
+		rolePermissions, err := GetPermissionsByRole(roleId)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, rolePermissions...)
+
+		ancestorRoles, err := GetAncestorRoles(roleId)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range ancestorRoles {
+			rolePermissions, err = GetPermissionsByRole(role.GetId())
+			if err != nil {
+				return nil, err
+			}
+			permissions = append(permissions, rolePermissions...)
+		}
+	}
+
+	if simulation.Model != nil {
+		modelPermissions, err := GetPermissionsByModel(simulation.Model.Owner, simulation.Model.Name)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, modelPermissions...)
+	}
+
+	visited := map[string]bool{}
+	uniquePermissions := []*Permission{}
+	for _, permission := range permissions {
+		if !visited[permission.GetId()] {
+			visited[permission.GetId()] = true
+			uniquePermissions = append(uniquePermissions, permission)
+		}
+	}
+
+	groups := [][]string{}
+	for _, permissionIds := range GroupPermissionsByModelAdapter(uniquePermissions) {
+		groups = append(groups, permissionIds)
+	}
+	return groups, nil
+}
+
+// getSimulationRequest resolves the subject of the request like Enforce() does, with the attributes exposed by the
+// proposed model when it is the model of the permissions
+func getSimulationRequest(state *simulationState, permissionIds []string, request CasbinRequest, abacContext *AbacContext) (CasbinRequest, error) {
+	permission, err := state.getPermission(permissionIds[0])
+	if err != nil || permission == nil {
+		return request, err
+	}
+
+	if state.model != nil && state.model.Owner == permission.Owner && state.model.Name == permission.Model {
+		requests, err := getAbacRequestsByAttributes(permission.Owner, state.model.AbacAttributes, []CasbinRequest{request}, abacContext)
+		if err != nil {
+			return nil, err
+		}
+		return requests[0], nil
+	}
+
+	requests, err := getAbacRequests(permission, []CasbinRequest{request}, abacContext)
+	if err != nil {
+		return nil, err
+	}
+	return requests[0], nil
+}
+
+func enforceSimulation(state *simulationState, permissionIds []string, request CasbinRequest, abacContext *AbacContext) (bool, error) {
+	enforcer, err := getSimulationEnforcer(state, permissionIds)
+	if err != nil || enforcer == nil {
+		return false, err
+	}
+
+	request, err = getSimulationRequest(state, permissionIds, request, abacContext)
+	if err != nil {
+		return false, err
+	}
+	return enforcer.Enforce(request...)
+}
+
+// SimulatePolicyChange reports the decisions that the proposed change would flip, without persisting anything. The
+// given requests are checked with the given context, the replayed requests with the context they were enforced in
+func SimulatePolicyChange(simulation *PolicySimulation, abacContext *AbacContext) ([]*SimulationResult, error) {
+	if simulation.Permission == nil && simulation.Role == nil && simulation.Model == nil {
+		return nil, fmt.Errorf("the proposed permission, role or model is missing")
+	}
+
+	if simulation.Model != nil {
+		_, err := GetBuiltInModel(simulation.Model.ModelText)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	before := &simulationState{}
+	after := &simulationState{permissions: map[string]*Permission{}, roles: map[string]*Role{}, model: simulation.Model}
+	if simulation.Permission != nil {
+		after.permissions[simulation.Permission.GetId()] = simulation.Permission
+	}
+	if simulation.Role != nil {
+		after.roles[simulation.Role.GetId()] = simulation.Role
+	}
+
+	groups, err := getAffectedPermissionGroups(simulation)
+	if err != nil {
+		return nil, err
+	}
+
+	type simulationCase struct {
+		permissionIds []string
+		request       CasbinRequest
+		abacContext   *AbacContext
+	}
+	cases := []*simulationCase{}
+	if len(simulation.Requests) != 0 {
+		for _, permissionIds := range groups {
+			for _, request := range simulation.Requests {
+				cases = append(cases, &simulationCase{permissionIds: permissionIds, request: request, abacContext: abacContext})
+			}
+		}
+	} else {
+		affected := map[string]bool{}
+		for _, permissionIds := range groups {
+			for _, permissionId := range permissionIds {
+				affected[permissionId] = true
+			}
+		}
+
+		count := simulation.RecentCount
+		if count <= 0 {
+			count = 100
+		}
+		for _, entry := range getRecentEnforceRequests(affected, count) {
+			cases = append(cases, &simulationCase{permissionIds: entry.permissionIds, request: entry.request, abacContext: entry.abacContext})
+		}
+	}
+
+	results := []*SimulationResult{}
+	for _, c := range cases {
+		beforeResult, err := enforceSimulation(before, c.permissionIds, c.request, c.abacContext)
+		if err != nil {
+			return nil, err
+		}
+
+		afterResult, err := enforceSimulation(after, c.permissionIds, c.request, c.abacContext)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &SimulationResult{
+			Request:       c.request,
+			PermissionIds: c.permissionIds,
+			Before:        beforeResult,
+			After:         afterResult,
+			IsFlipped:     beforeResult != afterResult,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecentEnforceRequests(t *testing.T) {
+	recordEnforceRequests([]string{"built-in/p1"}, nil, CasbinRequest{"alice", "data1", "read"}, CasbinRequest{"bob", "data1", "read"})
+	recordEnforceRequests([]string{"built-in/p2"}, nil, CasbinRequest{"alice", "data2", "write"})
+	recordEnforceRequests([]string{"built-in/p1", "built-in/p3"}, nil, CasbinRequest{"carol", "data1", "read"})
+
+	scenarios := []struct {
+		description   string
+		permissionIds map[string]bool
+		count         int
+		expected      []CasbinRequest
+	}{
+		{"newest first", map[string]bool{"built-in/p1": true}, 10, []CasbinRequest{{"carol", "data1", "read"}, {"bob", "data1", "read"}, {"alice", "data1", "read"}}},
+		{"limited by count", map[string]bool{"built-in/p1": true}, 1, []CasbinRequest{{"carol", "data1", "read"}}},
+		{"grouped permissions", map[string]bool{"built-in/p3": true}, 10, []CasbinRequest{{"carol", "data1", "read"}}},
+		{"unknown permission", map[string]bool{"built-in/p4": true}, 10, []CasbinRequest{}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			requests := []CasbinRequest{}
+			for _, entry := range getRecentEnforceRequests(scenario.permissionIds, scenario.count) {
+				requests = append(requests, entry.request)
+			}
+			assert.Equal(t, scenario.expected, requests)
+		})
+	}
+}
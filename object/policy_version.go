@@ -0,0 +1,548 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+)
+
+const (
+	PolicyVersionTypeModel      = "Model"
+	PolicyVersionTypeAdapter    = "Adapter"
+	PolicyVersionTypeEnforcer   = "Enforcer"
+	PolicyVersionTypePermission = "Permission"
+)
+
+// PolicyVersion is a snapshot of a model, an adapter, an enforcer or a permission taken after each change, with a
+// baseline taken before the first one. The snapshot is kept as sorted lines so that any two versions can be diffed,
+// the policies of an enforcer are also kept as rules to be able to roll it back. The user who made a change is in
+// the records, User is only set for a rollback
+type PolicyVersion struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	ObjectType string     `xorm:"varchar(100) index" json:"objectType"`
+	ObjectId   string     `xorm:"varchar(100) index" json:"objectId"`
+	Version    int        `json:"version"`
+	User       string     `xorm:"varchar(100)" json:"user"`
+	Action     string     `xorm:"varchar(100)" json:"action"`
+	Lines      []string   `xorm:"mediumtext" json:"lines"`
+	Policies   [][]string `xorm:"mediumtext" json:"policies"`
+	Added      []string   `xorm:"mediumtext" json:"added"`
+	Removed    []string   `xorm:"mediumtext" json:"removed"`
+}
+
+type PolicyVersionDiff struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+func GetPolicyVersionCount(owner, objectType, objectId, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&PolicyVersion{ObjectType: objectType, ObjectId: objectId})
+}
+
+func GetPolicyVersions(owner, objectType, objectId string) ([]*PolicyVersion, error) {
+	versions := []*PolicyVersion{}
+	err := ormer.Engine.Desc("version").Find(&versions, &PolicyVersion{Owner: owner, ObjectType: objectType, ObjectId: objectId})
+	if err != nil {
+		return versions, err
+	}
+
+	return versions, nil
+}
+
+func GetPaginationPolicyVersions(owner, objectType, objectId string, offset, limit int, field, value, sortField, sortOrder string) ([]*PolicyVersion, error) {
+	versions := []*PolicyVersion{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&versions, &PolicyVersion{ObjectType: objectType, ObjectId: objectId})
+	if err != nil {
+		return versions, err
+	}
+
+	return versions, nil
+}
+
+func getPolicyVersion(owner string, name string) (*PolicyVersion, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	version := PolicyVersion{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&version)
+	if err != nil {
+		return &version, err
+	}
+
+	if existed {
+		return &version, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetPolicyVersion(id string) (*PolicyVersion, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getPolicyVersion(owner, name)
+}
+
+func getLatestPolicyVersion(objectType string, objectId string) (*PolicyVersion, error) {
+	version := PolicyVersion{}
+	existed, err := ormer.Engine.Desc("version").Where("object_type = ? and object_id = ?", objectType, objectId).Get(&version)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &version, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func (version *PolicyVersion) GetId() string {
+	return fmt.Sprintf("%s/%s", version.Owner, version.Name)
+}
+
+// diffLines returns the lines only in newLines and the lines only in oldLines, duplicated lines are counted
+func diffLines(oldLines []string, newLines []string) ([]string, []string) {
+	counts := map[string]int{}
+	for _, line := range oldLines {
+		counts[line]++
+	}
+
+	added := []string{}
+	for _, line := range newLines {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			added = append(added, line)
+		}
+	}
+
+	removed := []string{}
+	for _, line := range oldLines {
+		if counts[line] > 0 {
+			counts[line]--
+			removed = append(removed, line)
+		}
+	}
+	return added, removed
+}
+
+// getObjectLines turns the json fields of the object into "key = value" lines
+func getObjectLines(obj interface{}, excludedKeys ...string) ([]string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := map[string]bool{"createdTime": true, "updatedTime": true}
+	for _, key := range excludedKeys {
+		excluded[key] = true
+	}
+
+	lines := []string{}
+	for key, value := range fields {
+		if !excluded[key] {
+			lines = append(lines, fmt.Sprintf("%s = %s", key, string(value)))
+		}
+	}
+	return lines, nil
+}
+
+func getPolicyLines(policies [][]string) []string {
+	lines := []string{}
+	for _, policy := range policies {
+		lines = append(lines, strings.Join(policy, ", "))
+	}
+	return lines
+}
+
+func getModelSnapshot(id string) ([]string, [][]string, error) {
+	m, err := GetModel(id)
+	if err != nil || m == nil {
+		return []string{}, nil, err
+	}
+
+	lines, err := getObjectLines(m, "modelText")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, line := range strings.Split(m.ModelText, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil, nil
+}
+
+func getAdapterSnapshot(id string) ([]string, [][]string, error) {
+	adapter, err := GetAdapter(id)
+	if err != nil || adapter == nil {
+		return []string{}, nil, err
+	}
+
+	// the password itself is never kept in the history, only whether it is set
+	if adapter.Password != "" {
+		adapter.Password = "***"
+	}
+
+	lines, err := getObjectLines(adapter)
+	return lines, nil, err
+}
+
+func getEnforcerSnapshot(id string) ([]string, [][]string, error) {
+	enforcer, err := GetEnforcer(id)
+	if err != nil || enforcer == nil {
+		return []string{}, nil, err
+	}
+
+	lines := []string{
+		fmt.Sprintf("displayName = %s", enforcer.DisplayName),
+		fmt.Sprintf("description = %s", enforcer.Description),
+		fmt.Sprintf("model = %s", enforcer.Model),
+		fmt.Sprintf("adapter = %s", enforcer.Adapter),
+	}
+
+	err = enforcer.InitEnforcer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// every named policy and grouping policy is kept with its ptype in front, like the rows of the adapter
+	policies := [][]string{}
+	m := enforcer.GetModel()
+	for _, sec := range []string{"p", "g"} {
+		for ptype, assertion := range m[sec] {
+			for _, rule := range assertion.Policy {
+				policies = append(policies, append([]string{ptype}, rule...))
+			}
+		}
+	}
+
+	lines = append(lines, getPolicyLines(policies)...)
+	return lines, policies, nil
+}
+
+func getPermissionSnapshot(id string) ([]string, [][]string, error) {
+	permission, err := GetPermission(id)
+	if err != nil || permission == nil {
+		return []string{}, nil, err
+	}
+
+	lines, err := getObjectLines(permission)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the members of the groups are part of the state because a user joining or leaving a group changes who is granted
+	for _, groupId := range permission.Groups {
+		users, err := GetGroupUsers(groupId)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, user := range users {
+			lines = append(lines, fmt.Sprintf("member, %s, %s", user.GetId(), groupId))
+		}
+	}
+
+	policies := [][]string{}
+	for _, policy := range getPolicies(permission) {
+		policies = append(policies, append([]string{"p"}, policy...))
+	}
+	for _, policy := range getGroupingPolicies(permission) {
+		policies = append(policies, append([]string{"g"}, policy...))
+	}
+
+	lines = append(lines, getPolicyLines(policies)...)
+	return lines, policies, nil
+}
+
+func getPolicySnapshot(objectType string, objectId string) ([]string, [][]string, error) {
+	switch objectType {
+	case PolicyVersionTypeModel:
+		return getModelSnapshot(objectId)
+	case PolicyVersionTypeAdapter:
+		return getAdapterSnapshot(objectId)
+	case PolicyVersionTypeEnforcer:
+		return getEnforcerSnapshot(objectId)
+	case PolicyVersionTypePermission:
+		return getPermissionSnapshot(objectId)
+	default:
+		return nil, nil, fmt.Errorf("unknown object type: %s", objectType)
+	}
+}
+
+// AddPolicyVersion snapshots the current state of the object and stores it as a new version if it has changed,
+// a deleted object is stored as an empty version
+func AddPolicyVersion(objectType string, objectId string, user string, action string) (bool, error) {
+	lines, policies, err := getPolicySnapshot(objectType, objectId)
+	if err != nil {
+		return false, err
+	}
+	sort.Strings(lines)
+
+	latest, err := getLatestPolicyVersion(objectType, objectId)
+	if err != nil {
+		return false, err
+	}
+
+	version := 1
+	oldLines := []string{}
+	if latest != nil {
+		version = latest.Version + 1
+		oldLines = latest.Lines
+	}
+
+	added, removed := diffLines(oldLines, lines)
+	if latest != nil && len(added) == 0 && len(removed) == 0 {
+		return false, nil
+	}
+
+	owner, _ := util.GetOwnerAndNameFromIdNoCheck(objectId)
+	policyVersion := &PolicyVersion{
+		Owner:       owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		ObjectType:  objectType,
+		ObjectId:    objectId,
+		Version:     version,
+		User:        user,
+		Action:      action,
+		Lines:       lines,
+		Policies:    policies,
+		Added:       added,
+		Removed:     removed,
+	}
+
+	affected, err := ormer.Engine.Insert(policyVersion)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// addPolicyBaselines snapshots the objects that have no version yet before their first change, so that the
+// first change can be diffed and rolled back like the later ones
+func addPolicyBaselines(objectType string, objectIds ...string) {
+	for _, objectId := range objectIds {
+		latest, err := getLatestPolicyVersion(objectType, objectId)
+		if err == nil && latest == nil {
+			_, err = AddPolicyVersion(objectType, objectId, "", "baseline")
+		}
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to add the baseline policy version of %s: %s, error: %s", objectType, objectId, err.Error()))
+		}
+	}
+}
+
+// addPolicyVersions records the new state of the objects after a change, a failure doesn't undo the change
+func addPolicyVersions(objectType string, action string, objectIds ...string) {
+	for _, objectId := range objectIds {
+		_, err := AddPolicyVersion(objectType, objectId, "", action)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to add the policy version of %s: %s, error: %s", objectType, objectId, err.Error()))
+		}
+	}
+}
+
+// getPermissionIdsByRoles returns the permissions whose policies are derived from the roles, the permissions
+// of the ancestor roles included
+func getPermissionIdsByRoles(roleIds ...string) ([]string, error) {
+	res := []string{}
+	if len(roleIds) == 0 {
+		return res, nil
+	}
+
+	roles, err := GetAncestorRoles(roleIds...)
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[string]bool{}
+	for _, role := range roles {
+		permissions, err := GetPermissionsByRole(role.GetId())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, permission := range permissions {
+			if permissionId := permission.GetId(); !visited[permissionId] {
+				visited[permissionId] = true
+				res = append(res, permissionId)
+			}
+		}
+	}
+	return res, nil
+}
+
+// getChangedGroups returns the groups that are only in one of oldGroups and newGroups
+func getChangedGroups(oldGroups []string, newGroups []string) []string {
+	added, removed := diffLines(oldGroups, newGroups)
+	return append(added, removed...)
+}
+
+// getPermissionIdsByGroups returns the permissions granted to any of the groups
+func getPermissionIdsByGroups(groupIds ...string) ([]string, error) {
+	res := []string{}
+	visited := map[string]bool{}
+	for _, groupId := range groupIds {
+		permissions := []*Permission{}
+		err := ormer.Engine.Where(ormer.Engine.Quote("groups")+" like ?", "%"+groupId+"\"%").Find(&permissions)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, permission := range permissions {
+			if permissionId := permission.GetId(); util.InSlice(permission.Groups, groupId) && !visited[permissionId] {
+				visited[permissionId] = true
+				res = append(res, permissionId)
+			}
+		}
+	}
+	return res, nil
+}
+
+func DiffPolicyVersions(fromId string, toId string) (*PolicyVersionDiff, error) {
+	from, err := GetPolicyVersion(fromId)
+	if err != nil {
+		return nil, err
+	} else if from == nil {
+		return nil, fmt.Errorf("the policy version: %s is not found", fromId)
+	}
+
+	to, err := GetPolicyVersion(toId)
+	if err != nil {
+		return nil, err
+	} else if to == nil {
+		return nil, fmt.Errorf("the policy version: %s is not found", toId)
+	}
+
+	if from.ObjectType != to.ObjectType || from.ObjectId != to.ObjectId {
+		return nil, fmt.Errorf("the policy versions: %s and %s don't belong to the same object", fromId, toId)
+	}
+
+	added, removed := diffLines(from.Lines, to.Lines)
+	return &PolicyVersionDiff{From: fromId, To: toId, Added: added, Removed: removed}, nil
+}
+
+// RollbackEnforcer replaces all the policies of the enforcer with the policies of the version in one transaction
+func RollbackEnforcer(enforcerId string, versionId string, user string) (bool, error) {
+	version, err := GetPolicyVersion(versionId)
+	if err != nil {
+		return false, err
+	} else if version == nil {
+		return false, fmt.Errorf("the policy version: %s is not found", versionId)
+	}
+
+	if version.ObjectType != PolicyVersionTypeEnforcer || version.ObjectId != enforcerId {
+		return false, fmt.Errorf("the policy version: %s doesn't belong to the enforcer: %s", versionId, enforcerId)
+	}
+
+	enforcer, err := GetInitializedEnforcer(enforcerId)
+	if err != nil {
+		return false, err
+	}
+
+	err = enforcer.replacePolicies(version.Policies)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = AddPolicyVersion(PolicyVersionTypeEnforcer, enforcerId, user, fmt.Sprintf("rollback to version %d", version.Version))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (enforcer *Enforcer) replacePolicies(policies [][]string) error {
+	adapter := enforcer.adapter
+	if adapter == nil || adapter.engine == nil {
+		return fmt.Errorf("the adapter for enforcer: %s is not initialized", enforcer.GetId())
+	}
+
+	rules := []*xormadapter.CasbinRule{}
+	for _, policy := range policies {
+		if len(policy) == 0 {
+			continue
+		}
+
+		rule := &xormadapter.CasbinRule{Ptype: policy[0]}
+		values := []*string{&rule.V0, &rule.V1, &rule.V2, &rule.V3, &rule.V4, &rule.V5}
+		for i, value := range policy[1:] {
+			if i < len(values) {
+				*values[i] = value
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	session := adapter.engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Table(adapter.tableName).Where("1 = 1").Delete(&xormadapter.CasbinRule{})
+	if err != nil {
+		session.Rollback()
+		return err
+	}
+
+	if len(rules) != 0 {
+		_, err = session.Table(adapter.tableName).Insert(&rules)
+		if err != nil {
+			session.Rollback()
+			return err
+		}
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return err
+	}
+
+	err = enforcer.LoadPolicy()
+	if err != nil {
+		return err
+	}
+
+	return notifyPolicyChange(enforcer.GetId())
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+	_ "modernc.org/sqlite"
+)
+
+func TestDiffLines(t *testing.T) {
+	scenarios := []struct {
+		description     string
+		oldLines        []string
+		newLines        []string
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{"first version", []string{}, []string{"p, alice, data1, read"}, []string{"p, alice, data1, read"}, []string{}},
+		{"unchanged", []string{"p, alice, data1, read"}, []string{"p, alice, data1, read"}, []string{}, []string{}},
+		{"updated policy", []string{"p, alice, data1, read", "g, alice, admin"}, []string{"p, alice, data1, write", "g, alice, admin"}, []string{"p, alice, data1, write"}, []string{"p, alice, data1, read"}},
+		{"duplicated line", []string{"p, bob, data2, read", "p, bob, data2, read"}, []string{"p, bob, data2, read"}, []string{}, []string{"p, bob, data2, read"}},
+		{"deleted object", []string{"model = m1", "adapter = a1"}, []string{}, []string{}, []string{"model = m1", "adapter = a1"}},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			added, removed := diffLines(scenario.oldLines, scenario.newLines)
+			assert.Equal(t, scenario.expectedAdded, added)
+			assert.Equal(t, scenario.expectedRemoved, removed)
+		})
+	}
+}
+
+func TestPolicyVersionBaseline(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(Model), new(PolicyVersion)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	// the model existed before versioning, so its state before the first change must be kept
+	model := &Model{Owner: "built-in", Name: "model", ModelText: "[request_definition]\nr = sub, obj, act"}
+	_, err = engine.Insert(model)
+	assert.Nil(t, err)
+
+	model.ModelText = "[request_definition]\nr = sub, dom, obj, act"
+	affected, err := UpdateModel(model.GetId(), model)
+	assert.Nil(t, err)
+	assert.True(t, affected)
+
+	versions, err := GetPolicyVersions("built-in", PolicyVersionTypeModel, model.GetId())
+	assert.Nil(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "update", versions[0].Action)
+	assert.Equal(t, []string{"r = sub, dom, obj, act"}, versions[0].Added)
+	assert.Equal(t, []string{"r = sub, obj, act"}, versions[0].Removed)
+	assert.Equal(t, "baseline", versions[1].Action)
+
+	affected, err = DeleteModel(model)
+	assert.Nil(t, err)
+	assert.True(t, affected)
+
+	versions, err = GetPolicyVersions("built-in", PolicyVersionTypeModel, model.GetId())
+	assert.Nil(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, "delete", versions[0].Action)
+	assert.Empty(t, versions[0].Lines)
+}
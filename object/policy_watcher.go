@@ -0,0 +1,198 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casbin/casbin/v2"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+)
+
+// The enforcers of the permissions are built from the database on every request, only the long-lived enforcers
+// like the API enforcer and the user-group enforcer keep their policies in memory. When the policies of such an
+// enforcer are changed by one replica, the watcher backend tells the other replicas to reload it
+type policyWatcherBackend interface {
+	publish(enforcerId string) error
+	run(dispatch func(enforcerId string))
+}
+
+var (
+	watcherInstanceId  = util.GenerateId()
+	watcherBackend     policyWatcherBackend
+	policyWatchers     = map[string][]*PolicyWatcher{}
+	policyWatcherMutex sync.RWMutex
+)
+
+// PolicyWatcher is the Casbin watcher of one enforcer, the message passed to the callback is the enforcer id
+type PolicyWatcher struct {
+	enforcerId string
+	callback   func(string)
+}
+
+func (w *PolicyWatcher) SetUpdateCallback(callback func(string)) error {
+	policyWatcherMutex.Lock()
+	defer policyWatcherMutex.Unlock()
+
+	if w.callback == nil {
+		policyWatchers[w.enforcerId] = append(policyWatchers[w.enforcerId], w)
+	}
+	w.callback = callback
+	return nil
+}
+
+func (w *PolicyWatcher) Update() error {
+	return notifyPolicyChange(w.enforcerId)
+}
+
+func (w *PolicyWatcher) Close() {
+	policyWatcherMutex.Lock()
+	defer policyWatcherMutex.Unlock()
+
+	watchers := []*PolicyWatcher{}
+	for _, watcher := range policyWatchers[w.enforcerId] {
+		if watcher != w {
+			watchers = append(watchers, watcher)
+		}
+	}
+	policyWatchers[w.enforcerId] = watchers
+}
+
+func InitPolicyWatcher() {
+	watcherType := conf.GetConfigString("policyWatcher")
+	switch watcherType {
+	case "":
+		return
+	case "Database":
+		watcherBackend = &dbPolicyWatcherBackend{}
+	case "Redis":
+		backend, err := newRedisPolicyWatcherBackend(conf.GetConfigString("redisEndpoint"))
+		if err != nil {
+			panic(err)
+		}
+		watcherBackend = backend
+	default:
+		panic(fmt.Errorf("unsupported policy watcher: %s", watcherType))
+	}
+
+	util.SafeGoroutine(func() { watcherBackend.run(dispatchPolicyChange) })
+}
+
+// WatchEnforcer makes the enforcer reload its policies when they are changed by another replica
+func WatchEnforcer(enforcerId string, enforcer *casbin.SyncedEnforcer) error {
+	if watcherBackend == nil {
+		return nil
+	}
+
+	watcher := &PolicyWatcher{enforcerId: enforcerId}
+	err := enforcer.SetWatcher(watcher)
+	if err != nil {
+		return err
+	}
+
+	// the callback set by Casbin reloads the inner enforcer without the lock, so a request could be enforced
+	// against the half rebuilt role links, the reload must hold the write lock of the synced enforcer instead
+	return watcher.SetUpdateCallback(func(string) {
+		err := enforcer.LoadPolicy()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to reload the policies of enforcer: %s, error: %s", enforcerId, err.Error()))
+		}
+	})
+}
+
+func notifyPolicyChange(enforcerId string) error {
+	if watcherBackend == nil {
+		return nil
+	}
+	return watcherBackend.publish(enforcerId)
+}
+
+func dispatchPolicyChange(enforcerId string) {
+	policyWatcherMutex.RLock()
+	watchers := policyWatchers[enforcerId]
+	policyWatcherMutex.RUnlock()
+
+	for _, watcher := range watchers {
+		watcher.callback(enforcerId)
+	}
+}
+
+// PolicyRevision is bumped on every change of the policies of an enforcer, the database watcher polls it
+type PolicyRevision struct {
+	Id          string `xorm:"varchar(100) notnull pk" json:"id"`
+	Revision    int64  `json:"revision"`
+	Instance    string `xorm:"varchar(100)" json:"instance"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+}
+
+type dbPolicyWatcherBackend struct{}
+
+func (b *dbPolicyWatcherBackend) publish(enforcerId string) error {
+	revision := &PolicyRevision{Id: enforcerId, Instance: watcherInstanceId, UpdatedTime: util.GetCurrentTime()}
+	affected, err := ormer.Engine.ID(enforcerId).Incr("revision").Cols("instance", "updated_time").Update(revision)
+	if err != nil {
+		return err
+	}
+	if affected != 0 {
+		return nil
+	}
+
+	revision.Revision = 1
+	_, err = ormer.Engine.Insert(revision)
+	if err != nil {
+		// another replica has inserted the revision in the meantime
+		_, err = ormer.Engine.ID(enforcerId).Incr("revision").Cols("instance", "updated_time").Update(revision)
+	}
+	return err
+}
+
+func (b *dbPolicyWatcherBackend) run(dispatch func(enforcerId string)) {
+	interval := time.Duration(getConfigLimit("policyWatcherInterval", 5)) * time.Second
+
+	var seen map[string]int64
+	for {
+		revisions := []*PolicyRevision{}
+		err := ormer.Engine.Find(&revisions)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to poll the policy revisions: %s", err.Error()))
+			time.Sleep(interval)
+			continue
+		}
+
+		isFirstPoll := seen == nil
+		if isFirstPoll {
+			seen = map[string]int64{}
+		}
+
+		for _, revision := range revisions {
+			last, ok := seen[revision.Id]
+			seen[revision.Id] = revision.Revision
+			if isFirstPoll || revision.Revision == last {
+				continue
+			}
+
+			// the change of this replica is skipped, unless another replica has changed it too in the meantime
+			if revision.Instance != watcherInstanceId || (ok && revision.Revision-last > 1) {
+				dispatch(revision.Id)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/gomodule/redigo/redis"
+)
+
+type policyWatcherMessage struct {
+	Instance   string `json:"instance"`
+	EnforcerId string `json:"enforcerId"`
+}
+
+type redisPolicyWatcherBackend struct {
+	pool    *redis.Pool
+	channel string
+}
+
+// newRedisPolicyWatcherBackend uses the same endpoint format as the redis session provider: "address,poolSize,password,dbNum"
+func newRedisPolicyWatcherBackend(endpoint string) (*redisPolicyWatcherBackend, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("the redis endpoint should not be empty for the Redis policy watcher")
+	}
+
+	options := []redis.DialOption{}
+	tokens := strings.Split(endpoint, ",")
+	address := tokens[0]
+	if len(tokens) > 2 && tokens[2] != "" {
+		options = append(options, redis.DialPassword(tokens[2]))
+	}
+	if len(tokens) > 3 {
+		db, err := strconv.Atoi(tokens[3])
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, redis.DialDatabase(db))
+	}
+
+	channel := conf.GetConfigString("policyWatcherChannel")
+	if channel == "" {
+		channel = "casdoor-policy"
+	}
+
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", address, options...)
+		},
+	}
+	return &redisPolicyWatcherBackend{pool: pool, channel: channel}, nil
+}
+
+func (b *redisPolicyWatcherBackend) publish(enforcerId string) error {
+	data, err := json.Marshal(&policyWatcherMessage{Instance: watcherInstanceId, EnforcerId: enforcerId})
+	if err != nil {
+		return err
+	}
+
+	conn := b.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("PUBLISH", b.channel, data)
+	return err
+}
+
+func (b *redisPolicyWatcherBackend) run(dispatch func(enforcerId string)) {
+	for {
+		err := b.subscribe(dispatch)
+		logs.Warning(fmt.Sprintf("the policy watcher is disconnected from redis, error: %v", err))
+		time.Sleep(5 * time.Second)
+	}
+}
+
+func (b *redisPolicyWatcherBackend) subscribe(dispatch func(enforcerId string)) error {
+	conn := redis.PubSubConn{Conn: b.pool.Get()}
+	defer conn.Close()
+
+	err := conn.Subscribe(b.channel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		switch v := conn.Receive().(type) {
+		case redis.Message:
+			message := policyWatcherMessage{}
+			err = json.Unmarshal(v.Data, &message)
+			if err != nil {
+				logs.Warning(fmt.Sprintf("invalid policy watcher message: %s", string(v.Data)))
+				continue
+			}
+
+			if message.Instance != watcherInstanceId {
+				dispatch(message.EnforcerId)
+			}
+		case error:
+			return v
+		}
+	}
+}
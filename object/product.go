@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/casdoor/casdoor/pp"
@@ -142,7 +143,7 @@ func (product *Product) isValidProvider(provider *Provider) bool {
 }
 
 func (product *Product) getProvider(providerName string) (*Provider, error) {
-	provider, err := getProvider(product.Owner, providerName)
+	provider, err := getProvider(context.Background(), product.Owner, providerName)
 	if err != nil {
 		return nil, err
 	}
@@ -256,7 +257,7 @@ func ExtendProductWithProviders(product *Product) error {
 
 	product.ProviderObjs = []*Provider{}
 
-	m, err := getProviderMap(product.Owner)
+	m, err := getProviderMap(context.Background(), product.Owner)
 	if err != nil {
 		return err
 	}
@@ -21,7 +21,7 @@ package object
 //	InitConfig()
 //
 //	product, _ := GetProduct("admin/product_123")
-//	provider, _ := getProvider(product.Owner, "provider_pay_alipay")
+//	provider, _ := getProvider(context.Background(), product.Owner, "provider_pay_alipay")
 //	cert, _ := getCert(product.Owner, "cert-pay-alipay")
 //	pProvider, err := pp.GetPaymentProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.Host, cert.Certificate, cert.PrivateKey, cert.AuthorityPublicKey, cert.AuthorityRootPublicKey, provider.ClientId2)
 //	if err != nil {
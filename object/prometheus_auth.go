@@ -0,0 +1,182 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	LoginCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_login_total",
+		Help: "The logins by organization, application, provider and result",
+	}, []string{"organization", "application", "provider", "result"})
+
+	MfaChallengeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_mfa_challenge_total",
+		Help: "The MFA challenges by organization, MFA type and result",
+	}, []string{"organization", "mfa_type", "result"})
+
+	TokenIssuedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_token_issued_total",
+		Help: "The tokens issued by organization, application and grant type",
+	}, []string{"organization", "application", "grant_type"})
+
+	TokenRefreshFailureCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_token_refresh_failure_total",
+		Help: "The failed token refreshes by error",
+	}, []string{"error"})
+
+	LockoutCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_lockout_total",
+		Help: "The users locked out after too many wrong passwords or codes",
+	}, []string{"organization"})
+
+	VerificationCodeCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_verification_code_total",
+		Help: "The verification codes sent by organization, channel, provider type and result",
+	}, []string{"organization", "channel", "provider", "result"})
+
+	WebhookDeliveryCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_webhook_delivery_total",
+		Help: "The webhook delivery attempts by organization and result",
+	}, []string{"organization", "result"})
+
+	SyncerRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "casdoor_syncer_run_duration_seconds",
+		Help:    "The duration of syncer runs in seconds",
+		Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"syncer"})
+
+	SyncerErrorCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_syncer_error_total",
+		Help: "The failed syncer runs",
+	}, []string{"syncer"})
+
+	LdapRequestCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_ldap_request_total",
+		Help: "The requests to the LDAP server by operation and result",
+	}, []string{"operation", "result"})
+
+	RadiusRequestCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_radius_request_total",
+		Help: "The requests to the RADIUS server by code and result",
+	}, []string{"code", "result"})
+)
+
+// metricLabel keeps the number of values of a label bounded, the values after the first "prometheusLabelLimit"
+// ones are counted as "other"
+type metricLabel struct {
+	once   sync.Once
+	mutex  sync.Mutex
+	limit  int
+	values map[string]bool
+}
+
+var (
+	organizationLabel = &metricLabel{}
+	applicationLabel  = &metricLabel{}
+	providerLabel     = &metricLabel{}
+	syncerLabel       = &metricLabel{}
+)
+
+func (l *metricLabel) get(value string) string {
+	if value == "" {
+		return "none"
+	}
+
+	l.once.Do(func() {
+		l.limit = int(getConfigLimit("prometheusLabelLimit", 100))
+		l.values = map[string]bool{}
+	})
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.values[value] {
+		return value
+	}
+	if len(l.values) >= l.limit {
+		return "other"
+	}
+
+	l.values[value] = true
+	return value
+}
+
+func getEnumLabel(value string, values ...string) string {
+	if value == "" {
+		return "none"
+	}
+
+	for _, v := range values {
+		if value == v {
+			return value
+		}
+	}
+	return "other"
+}
+
+func getResultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+func ObserveLogin(organization string, application string, provider string, result string) {
+	LoginCounter.WithLabelValues(organizationLabel.get(organization), applicationLabel.get(application), providerLabel.get(provider), result).Inc()
+}
+
+func ObserveMfaChallenge(organization string, mfaType string, result string) {
+	MfaChallengeCounter.WithLabelValues(organizationLabel.get(organization), getEnumLabel(mfaType, SmsType, EmailType, TotpType, "recovery"), result).Inc()
+}
+
+func observeTokenIssued(application *Application, grantType string) {
+	grantType = getEnumLabel(grantType, "authorization_code", "password", "client_credentials", "refresh_token", "implicit", "wechat_miniprogram")
+	TokenIssuedCounter.WithLabelValues(organizationLabel.get(application.Organization), applicationLabel.get(application.Name), grantType).Inc()
+}
+
+func observeTokenRefresh(res interface{}, err error) {
+	if err != nil {
+		TokenRefreshFailureCounter.WithLabelValues("internal_error").Inc()
+	} else if tokenError, ok := res.(*TokenError); ok {
+		TokenRefreshFailureCounter.WithLabelValues(getEnumLabel(tokenError.Error, InvalidClient, InvalidGrant, UnsupportedGrantType, EndpointError)).Inc()
+	}
+}
+
+func observeLockout(user *User) {
+	LockoutCounter.WithLabelValues(organizationLabel.get(user.Owner)).Inc()
+}
+
+func observeVerificationCode(organization *Organization, channel string, provider *Provider, result string) {
+	VerificationCodeCounter.WithLabelValues(organizationLabel.get(organization.Name), channel, providerLabel.get(provider.Type), result).Inc()
+}
+
+func observeWebhookDelivery(organization string, err error) {
+	WebhookDeliveryCounter.WithLabelValues(organizationLabel.get(organization), getResultLabel(err)).Inc()
+}
+
+func observeSyncerRun(syncer *Syncer, startTime time.Time, err error) {
+	name := syncerLabel.get(syncer.GetId())
+	SyncerRunDuration.WithLabelValues(name).Observe(time.Since(startTime).Seconds())
+	if err != nil {
+		SyncerErrorCounter.WithLabelValues(name).Inc()
+	}
+}
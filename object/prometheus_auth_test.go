@@ -0,0 +1,56 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricLabel(t *testing.T) {
+	label := &metricLabel{}
+	label.once.Do(func() {
+		label.limit = 2
+		label.values = map[string]bool{}
+	})
+
+	scenarios := []struct {
+		value    string
+		expected string
+	}{
+		{"", "none"},
+		{"org1", "org1"},
+		{"org2", "org2"},
+		{"org3", "other"},
+		{"org1", "org1"},
+	}
+	for _, scenario := range scenarios {
+		assert.Equal(t, scenario.expected, label.get(scenario.value))
+	}
+
+	assert.Equal(t, "other", getEnumLabel("foo", "password", "refresh_token"))
+	assert.Equal(t, "password", getEnumLabel("password", "password", "refresh_token"))
+}
+
+func TestObserveTokenRefresh(t *testing.T) {
+	observeTokenRefresh(&TokenError{Error: InvalidGrant}, nil)
+	observeTokenRefresh(&TokenError{Error: "unknown"}, nil)
+	observeTokenRefresh(&TokenWrapper{}, nil)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(TokenRefreshFailureCounter.WithLabelValues(InvalidGrant)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(TokenRefreshFailureCounter.WithLabelValues("other")))
+}
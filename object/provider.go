@@ -15,10 +15,11 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/beego/beego/context"
+	beegocontext "github.com/beego/beego/context"
 	"github.com/casdoor/casdoor/i18n"
 	"github.com/casdoor/casdoor/idp"
 	"github.com/casdoor/casdoor/pp"
@@ -55,6 +56,9 @@ type Provider struct {
 	Content    string `xorm:"varchar(1000)" json:"content"` // If provider type is WeChat, Content means QRCode string by Base64 encoding
 	Receiver   string `xorm:"varchar(100)" json:"receiver"`
 
+	MagicLinkTitle   string `xorm:"varchar(100)" json:"magicLinkTitle"`
+	MagicLinkContent string `xorm:"varchar(1000)" json:"magicLinkContent"`
+
 	RegionId     string `xorm:"varchar(100)" json:"regionId"`
 	SignName     string `xorm:"varchar(100)" json:"signName"`
 	TemplateCode string `xorm:"varchar(100)" json:"templateCode"`
@@ -118,8 +122,12 @@ func GetGlobalProviderCount(field, value string) (int64, error) {
 }
 
 func GetProviders(owner string) ([]*Provider, error) {
+	return getProviders(context.Background(), owner)
+}
+
+func getProviders(ctx context.Context, owner string) ([]*Provider, error) {
 	providers := []*Provider{}
-	err := ormer.Engine.Where("owner = ? or owner = ? ", "admin", owner).Desc("created_time").Find(&providers, &Provider{})
+	err := ormer.Engine.Context(ctx).Where("owner = ? or owner = ? ", "admin", owner).Desc("created_time").Find(&providers, &Provider{})
 	if err != nil {
 		return providers, err
 	}
@@ -159,13 +167,13 @@ func GetPaginationGlobalProviders(offset, limit int, field, value, sortField, so
 	return providers, nil
 }
 
-func getProvider(owner string, name string) (*Provider, error) {
+func getProvider(ctx context.Context, owner string, name string) (*Provider, error) {
 	if owner == "" || name == "" {
 		return nil, nil
 	}
 
 	provider := Provider{Name: name}
-	existed, err := ormer.Engine.Get(&provider)
+	existed, err := ormer.Engine.Context(ctx).Get(&provider)
 	if err != nil {
 		return &provider, err
 	}
@@ -177,9 +185,9 @@ func getProvider(owner string, name string) (*Provider, error) {
 	}
 }
 
-func GetProvider(id string) (*Provider, error) {
+func GetProvider(ctx context.Context, id string) (*Provider, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	return getProvider(owner, name)
+	return getProvider(ctx, owner, name)
 }
 
 func GetWechatMiniProgramProvider(application *Application) *Provider {
@@ -194,7 +202,7 @@ func GetWechatMiniProgramProvider(application *Application) *Provider {
 
 func UpdateProvider(id string, provider *Provider) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if p, err := getProvider(owner, name); err != nil {
+	if p, err := getProvider(context.Background(), owner, name); err != nil {
 		return false, err
 	} else if p == nil {
 		return false, nil
@@ -339,7 +347,7 @@ func GetCaptchaProviderByApplication(applicationId, isCurrentProvider, lang stri
 	if isCurrentProvider == "true" {
 		return GetCaptchaProviderByOwnerName(applicationId, lang)
 	}
-	application, err := GetApplication(applicationId)
+	application, err := GetApplication(context.Background(), applicationId)
 	if err != nil {
 		return nil, err
 	}
@@ -396,7 +404,7 @@ func providerChangeTrigger(oldName string, newName string) error {
 	return session.Commit()
 }
 
-func FromProviderToIdpInfo(ctx *context.Context, provider *Provider) *idp.ProviderInfo {
+func FromProviderToIdpInfo(ctx *beegocontext.Context, provider *Provider) *idp.ProviderInfo {
 	providerInfo := &idp.ProviderInfo{
 		Type:         provider.Type,
 		SubType:      provider.SubType,
@@ -0,0 +1,479 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/provision"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	ProvisioningActionUpsert = "upsert"
+	ProvisioningActionDelete = "delete"
+)
+
+const (
+	ProvisioningStatePending   = "Pending"
+	ProvisioningStateSucceeded = "Succeeded"
+	ProvisioningStateFailed    = "Failed"
+)
+
+const (
+	provisioningInterval    = 10 * time.Second
+	provisioningBatchSize   = 100
+	provisioningBaseBackoff = 30
+	// a claimed task is retried by another worker if the one processing it dies
+	provisioningClaimTimeout = 300
+)
+
+// the user columns that are pushed downstream, updating other columns doesn't trigger a provisioning
+var provisionedColumns = []string{
+	"name", "display_name", "first_name", "last_name", "email", "phone", "title", "language",
+	"avatar", "affiliation", "properties", "groups", "is_forbidden", "is_deleted",
+}
+
+// ProvisioningConfig is the outbound SCIM provisioning connector of an application
+type ProvisioningConfig struct {
+	Enabled    bool                 `json:"enabled"`
+	Endpoint   string               `json:"endpoint"`
+	Token      string               `json:"token"`
+	Mappings   []*provision.Mapping `json:"mappings"`
+	SyncGroups bool                 `json:"syncGroups"`
+}
+
+// ProvisioningTask is a pending push of a user to an application, it's kept afterward as the status and error log of the target
+type ProvisioningTask struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Application string `xorm:"varchar(100) index" json:"application"`
+	User        string `xorm:"varchar(100)" json:"user"`
+	UserId      string `xorm:"varchar(100) index" json:"userId"`
+	Action      string `xorm:"varchar(100)" json:"action"`
+	State       string `xorm:"varchar(100) index" json:"state"`
+	Attempts    int    `json:"attempts"`
+	NextTime    int64  `xorm:"index" json:"nextTime"`
+	Message     string `xorm:"varchar(1000)" json:"message"`
+	RemoteId    string `xorm:"varchar(100)" json:"remoteId"`
+}
+
+// ProvisionedUser is a downstream user that Casdoor pushed to an application, with the group memberships it added,
+// only the users created by Casdoor are deleted downstream and only the added memberships are removed
+type ProvisionedUser struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	UserId      string `xorm:"varchar(100) notnull pk" json:"userId"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	User           string   `xorm:"varchar(100)" json:"user"`
+	RemoteId       string   `xorm:"varchar(100)" json:"remoteId"`
+	IsCreated      bool     `json:"isCreated"`
+	RemoteGroupIds []string `xorm:"mediumtext" json:"remoteGroupIds"`
+}
+
+func getProvisionedUser(owner string, application string, userId string) (*ProvisionedUser, error) {
+	provisionedUser := ProvisionedUser{Owner: owner, Application: application, UserId: userId}
+	existed, err := ormer.Engine.Get(&provisionedUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &provisionedUser, nil
+	}
+	return nil, nil
+}
+
+func getProvisionedUsers(owner string, application string) ([]*ProvisionedUser, error) {
+	provisionedUsers := []*ProvisionedUser{}
+	err := ormer.Engine.Find(&provisionedUsers, &ProvisionedUser{Owner: owner, Application: application})
+	if err != nil {
+		return provisionedUsers, err
+	}
+
+	return provisionedUsers, nil
+}
+
+func saveProvisionedUser(provisionedUser *ProvisionedUser) error {
+	provisionedUser.UpdatedTime = util.GetCurrentTime()
+	existed, err := ormer.Engine.Exist(&ProvisionedUser{Owner: provisionedUser.Owner, Application: provisionedUser.Application, UserId: provisionedUser.UserId})
+	if err != nil {
+		return err
+	}
+
+	if existed {
+		_, err = ormer.Engine.ID(core.PK{provisionedUser.Owner, provisionedUser.Application, provisionedUser.UserId}).
+			Cols("updated_time", "user", "remote_id", "is_created", "remote_group_ids").Update(provisionedUser)
+		return err
+	}
+
+	provisionedUser.CreatedTime = provisionedUser.UpdatedTime
+	_, err = ormer.Engine.Insert(provisionedUser)
+	return err
+}
+
+func GetProvisioningTaskCount(owner, application, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	if application != "" {
+		session = session.And("application = ?", application)
+	}
+	return session.Count(&ProvisioningTask{})
+}
+
+func GetProvisioningTasks(owner string, application string) ([]*ProvisioningTask, error) {
+	provisioningTasks := []*ProvisioningTask{}
+	err := ormer.Engine.Desc("updated_time").Find(&provisioningTasks, &ProvisioningTask{Owner: owner, Application: application})
+	if err != nil {
+		return provisioningTasks, err
+	}
+
+	return provisioningTasks, nil
+}
+
+func GetPaginationProvisioningTasks(owner, application string, offset, limit int, field, value, sortField, sortOrder string) ([]*ProvisioningTask, error) {
+	provisioningTasks := []*ProvisioningTask{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	if application != "" {
+		session = session.And("application = ?", application)
+	}
+	err := session.Find(&provisioningTasks)
+	if err != nil {
+		return provisioningTasks, err
+	}
+
+	return provisioningTasks, nil
+}
+
+func (config *ProvisioningConfig) isEnabled() bool {
+	return config != nil && config.Enabled && config.Endpoint != ""
+}
+
+func (config *ProvisioningConfig) getClient() *provision.Client {
+	return provision.NewClient(config.Endpoint, config.Token)
+}
+
+func getProvisioningApplications(organization string) ([]*Application, error) {
+	applications, err := GetOrganizationApplications("admin", organization)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*Application{}
+	for _, application := range applications {
+		if application.Provisioning.isEnabled() {
+			res = append(res, application)
+		}
+	}
+	return res, nil
+}
+
+// addProvisioningTask queues the action, a task still pending for the same user and application is reused
+// so that a burst of changes is pushed only once
+func addProvisioningTask(application *Application, owner string, userName string, userId string, action string) error {
+	currentTime := util.GetCurrentTime()
+
+	task := ProvisioningTask{}
+	existed, err := ormer.Engine.Where("owner = ? and application = ? and user_id = ? and state = ?", owner, application.Name, userId, ProvisioningStatePending).Get(&task)
+	if err != nil {
+		return err
+	}
+
+	if existed {
+		task.User = userName
+		task.Action = action
+		task.Attempts = 0
+		task.NextTime = 0
+		task.UpdatedTime = currentTime
+		_, err = ormer.Engine.ID(core.PK{task.Owner, task.Name}).Cols("user", "action", "attempts", "next_time", "updated_time").Update(&task)
+		return err
+	}
+
+	task = ProvisioningTask{
+		Owner:       owner,
+		Name:        util.GenerateId(),
+		CreatedTime: currentTime,
+		UpdatedTime: currentTime,
+		Application: application.Name,
+		User:        userName,
+		UserId:      userId,
+		Action:      action,
+		State:       ProvisioningStatePending,
+	}
+	_, err = ormer.Engine.Insert(&task)
+	return err
+}
+
+// enqueueProvisioning queues the user change for every application of the organization with provisioning enabled,
+// failures are only logged as they must not fail the change itself
+func enqueueProvisioning(user *User, action string) {
+	if user == nil || user.Id == "" {
+		return
+	}
+
+	applications, err := getProvisioningApplications(user.Owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the provisioning applications of %s, error %s", user.Owner, err))
+		return
+	}
+
+	if user.IsDeleted {
+		action = ProvisioningActionDelete
+	}
+
+	for _, application := range applications {
+		err = addProvisioningTask(application, user.Owner, user.Name, user.Id, action)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to queue the provisioning of %s to %s, error %s", user.GetId(), application.Name, err))
+		}
+	}
+}
+
+func enqueueProvisioningForColumns(user *User, columns []string) {
+	for _, column := range columns {
+		if util.InSlice(provisionedColumns, column) {
+			enqueueProvisioning(user, ProvisioningActionUpsert)
+			return
+		}
+	}
+}
+
+func getProvisioningUserFields(user *User) (map[string]interface{}, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"password", "passwordSalt", "accessSecret", "totpSecret", "recoveryCodes", "securityQuestions"} {
+		delete(fields, field)
+	}
+	fields["active"] = !user.IsForbidden
+	return fields, nil
+}
+
+func processProvisioningTask(task *ProvisioningTask) (string, error) {
+	application, err := getApplication(context.Background(), "admin", task.Application)
+	if err != nil {
+		return "", err
+	}
+	if application == nil || !application.Provisioning.isEnabled() {
+		return "", fmt.Errorf("the provisioning of the application: %s is not enabled", task.Application)
+	}
+
+	config := application.Provisioning
+	client := config.getClient()
+
+	provisionedUser, err := getProvisionedUser(task.Owner, task.Application, task.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	var user *User
+	if task.Action == ProvisioningActionUpsert {
+		user, err = getUserById(task.Owner, task.UserId)
+		if err != nil {
+			return "", err
+		}
+	}
+	if user == nil || user.IsDeleted {
+		return "", deleteProvisionedUser(client, provisionedUser)
+	}
+
+	fields, err := getProvisioningUserFields(user)
+	if err != nil {
+		return "", err
+	}
+
+	remoteId, isCreated, err := client.UpsertUser(user.Id, provision.BuildUser(user.Id, fields, config.Mappings))
+	if err != nil {
+		return "", err
+	}
+
+	if provisionedUser == nil || provisionedUser.RemoteId != remoteId {
+		provisionedUser = &ProvisionedUser{Owner: task.Owner, Application: task.Application, UserId: task.UserId, RemoteId: remoteId, RemoteGroupIds: []string{}}
+	}
+	provisionedUser.User = user.Name
+	provisionedUser.IsCreated = provisionedUser.IsCreated || isCreated
+
+	if config.SyncGroups {
+		groupNames := []string{}
+		for _, groupId := range user.Groups {
+			_, groupName := util.GetOwnerAndNameFromIdNoCheck(groupId)
+			groupNames = append(groupNames, groupName)
+		}
+
+		provisionedUser.RemoteGroupIds, err = client.SyncGroupMemberships(remoteId, groupNames, provisionedUser.RemoteGroupIds)
+		if err != nil {
+			if saveErr := saveProvisionedUser(provisionedUser); saveErr != nil {
+				logs.Warning(fmt.Sprintf("failed to save the provisioned user: %s, error %s", task.UserId, saveErr))
+			}
+			return remoteId, err
+		}
+	}
+
+	return remoteId, saveProvisionedUser(provisionedUser)
+}
+
+// deleteProvisionedUser deletes the downstream user if Casdoor created it, a user that existed downstream before
+// the provisioning is left there
+func deleteProvisionedUser(client *provision.Client, provisionedUser *ProvisionedUser) error {
+	if provisionedUser == nil {
+		return nil
+	}
+
+	if provisionedUser.IsCreated {
+		err := client.DeleteUser(provisionedUser.RemoteId)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := ormer.Engine.Delete(&ProvisionedUser{Owner: provisionedUser.Owner, Application: provisionedUser.Application, UserId: provisionedUser.UserId})
+	return err
+}
+
+func getProvisioningMaxAttempts() int {
+	return int(getConfigLimit("provisioningMaxAttempts", 8))
+}
+
+func runProvisioningTask(task *ProvisioningTask) error {
+	// claim the task so that the other replicas skip it
+	now := time.Now().Unix()
+	affected, err := ormer.Engine.ID(core.PK{task.Owner, task.Name}).Where("state = ? and next_time = ?", ProvisioningStatePending, task.NextTime).
+		Cols("next_time").Update(&ProvisioningTask{NextTime: now + provisioningClaimTimeout})
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	remoteId, err := processProvisioningTask(task)
+
+	task.Attempts += 1
+	task.UpdatedTime = util.GetCurrentTime()
+	if remoteId != "" {
+		task.RemoteId = remoteId
+	}
+	if err == nil {
+		task.State = ProvisioningStateSucceeded
+		task.Message = ""
+	} else {
+		task.Message = err.Error()
+		if task.Attempts >= getProvisioningMaxAttempts() {
+			task.State = ProvisioningStateFailed
+		} else {
+			task.NextTime = time.Now().Unix() + provisioningBaseBackoff<<(task.Attempts-1)
+		}
+	}
+
+	// the task may have been reused for a newer change in the meantime, it's then left pending to be pushed again
+	session := ormer.Engine.ID(core.PK{task.Owner, task.Name}).Where("next_time = ?", now+provisioningClaimTimeout)
+	if task.State == ProvisioningStatePending {
+		_, err = session.Cols("attempts", "next_time", "message", "remote_id", "updated_time").Update(task)
+	} else {
+		_, err = session.Cols("attempts", "state", "message", "remote_id", "updated_time").Update(task)
+	}
+	return err
+}
+
+func runProvisioningTasks() error {
+	tasks := []*ProvisioningTask{}
+	err := ormer.Engine.Where("state = ? and next_time <= ?", ProvisioningStatePending, time.Now().Unix()).
+		Asc("created_time").Limit(provisioningBatchSize).Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		err = runProvisioningTask(task)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunProvisioningJob pushes the queued user changes to the downstream applications, failed pushes are retried with an exponential backoff
+func RunProvisioningJob() {
+	for {
+		err := runProvisioningTasks()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("provisioning failed, error %s", err))
+		}
+
+		time.Sleep(provisioningInterval)
+	}
+}
+
+// ReconcileProvisioning queues a push of every user of the organization, and a deletion of the downstream users
+// that Casdoor provisioned for users that don't exist anymore, it returns the numbers of queued pushes and deletions
+func ReconcileProvisioning(application *Application) (int, int, error) {
+	if !application.Provisioning.isEnabled() {
+		return 0, 0, fmt.Errorf("the provisioning of the application: %s is not enabled", application.Name)
+	}
+
+	users, err := GetUsers(application.Organization)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userIds := map[string]bool{}
+	upsertCount := 0
+	for _, user := range users {
+		if user.IsDeleted {
+			continue
+		}
+
+		userIds[user.Id] = true
+		err = addProvisioningTask(application, user.Owner, user.Name, user.Id, ProvisioningActionUpsert)
+		if err != nil {
+			return upsertCount, 0, err
+		}
+		upsertCount += 1
+	}
+
+	// only the downstream users recorded as provisioned are considered, the others aren't managed by Casdoor
+	provisionedUsers, err := getProvisionedUsers(application.Organization, application.Name)
+	if err != nil {
+		return upsertCount, 0, err
+	}
+
+	deleteCount := 0
+	for _, provisionedUser := range provisionedUsers {
+		if userIds[provisionedUser.UserId] {
+			continue
+		}
+
+		err = addProvisioningTask(application, application.Organization, provisionedUser.User, provisionedUser.UserId, ProvisioningActionDelete)
+		if err != nil {
+			return upsertCount, deleteCount, err
+		}
+		deleteCount += 1
+	}
+	return upsertCount, deleteCount, nil
+}
@@ -17,11 +17,14 @@ package object
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/beego/beego/context"
+	"github.com/beego/beego/logs"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/util"
 	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/xorm"
 )
 
 var logPostOnly bool
@@ -30,24 +33,68 @@ func init() {
 	logPostOnly = conf.GetConfigBool("logPostOnly")
 }
 
+// Record is stored in Casdoor's own database, it has the same json fields as the record of Casvisor plus the result
 type Record struct {
-	casvisorsdk.Record
+	Id int `xorm:"int notnull pk autoincr" json:"id"`
+
+	Owner       string `xorm:"varchar(100) index" json:"owner"`
+	Name        string `xorm:"varchar(100) index" json:"name"`
+	CreatedTime string `xorm:"varchar(100) index" json:"createdTime"`
+
+	Organization string `xorm:"varchar(100)" json:"organization"`
+	ClientIp     string `xorm:"varchar(100) index" json:"clientIp"`
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Method       string `xorm:"varchar(100)" json:"method"`
+	RequestUri   string `xorm:"varchar(1000)" json:"requestUri"`
+	Action       string `xorm:"varchar(1000)" json:"action"`
+	Type         string `xorm:"varchar(100) index" json:"type"`
+	Target       string `xorm:"varchar(300)" json:"target"`
+	Result       string `xorm:"varchar(100)" json:"result"`
+	Reason       string `xorm:"varchar(1000)" json:"reason"`
+
+	Object string `xorm:"mediumtext" json:"object"`
+	Diff   string `xorm:"mediumtext" json:"diff"`
+
+	IsTriggered bool `json:"isTriggered"`
+
+	PrevHash string `xorm:"varchar(100)" json:"prevHash"`
+	Hash     string `xorm:"varchar(100)" json:"hash"`
+}
+
+// RecordFilter narrows the records by exact values and by a range of the created time
+type RecordFilter struct {
+	User      string
+	Action    string
+	Type      string
+	ClientIp  string
+	Result    string
+	StartTime string
+	EndTime   string
 }
 
-func NewRecord(ctx *context.Context) *casvisorsdk.Record {
+// the bodies of these APIs carry the credentials, possibly in custom fields that the denylist doesn't know,
+// so only the other fields of their records are kept
+var credentialRecordPaths = map[string]bool{
+	"/api/login":                     true,
+	"/api/signup":                    true,
+	"/api/recover-account":           true,
+	"/api/complete-account-recovery": true,
+}
+
+func NewRecord(ctx *context.Context) *Record {
 	ip := strings.Replace(util.GetIPFromRequest(ctx.Request), ": ", "", -1)
 	action := strings.Replace(ctx.Request.URL.Path, "/api/", "", -1)
-	requestUri := util.FilterQuery(ctx.Request.RequestURI, []string{"accessToken"})
+	requestUri := RedactRequestUri(ctx.Request.RequestURI)
 	if len(requestUri) > 1000 {
 		requestUri = requestUri[0:1000]
 	}
 
 	object := ""
-	if ctx.Input.RequestBody != nil && len(ctx.Input.RequestBody) != 0 {
-		object = string(ctx.Input.RequestBody)
+	if ctx.Input.RequestBody != nil && len(ctx.Input.RequestBody) != 0 && !credentialRecordPaths[ctx.Request.URL.Path] {
+		object = RedactObject(string(ctx.Input.RequestBody))
 	}
 
-	record := casvisorsdk.Record{
+	record := Record{
 		Name:        util.GenerateId(),
 		CreatedTime: util.GetCurrentTime(),
 		ClientIp:    ip,
@@ -55,13 +102,103 @@ func NewRecord(ctx *context.Context) *casvisorsdk.Record {
 		Method:      ctx.Request.Method,
 		RequestUri:  requestUri,
 		Action:      action,
+		Target:      GetRecordTarget(ctx.Input.Query("id"), object),
 		Object:      object,
 		IsTriggered: false,
 	}
 	return &record
 }
 
-func AddRecord(record *casvisorsdk.Record) bool {
+func (filter *RecordFilter) apply(session *xorm.Session) *xorm.Session {
+	if filter == nil {
+		return session
+	}
+
+	if filter.StartTime != "" {
+		session = session.And("created_time >= ?", filter.StartTime)
+	}
+	if filter.EndTime != "" {
+		session = session.And("created_time <= ?", filter.EndTime)
+	}
+	return session
+}
+
+func (filter *RecordFilter) bean() *Record {
+	if filter == nil {
+		return &Record{}
+	}
+	return &Record{User: filter.User, Action: filter.Action, Type: filter.Type, ClientIp: filter.ClientIp, Result: filter.Result}
+}
+
+func GetRecordCount(owner, field, value string, filter *RecordFilter) (int64, error) {
+	session := filter.apply(GetSession(owner, -1, -1, field, value, "", ""))
+	return session.Count(filter.bean())
+}
+
+func GetRecords(owner string, filter *RecordFilter) ([]*Record, error) {
+	records := []*Record{}
+	session := filter.apply(GetSession(owner, -1, -1, "", "", "", ""))
+	err := session.Find(&records, filter.bean())
+	if err != nil {
+		return records, err
+	}
+
+	return records, nil
+}
+
+func GetPaginationRecords(owner string, offset, limit int, field, value, sortField, sortOrder string, filter *RecordFilter) ([]*Record, error) {
+	records := []*Record{}
+	session := filter.apply(GetSession(owner, offset, limit, field, value, sortField, sortOrder))
+	err := session.Find(&records, filter.bean())
+	if err != nil {
+		return records, err
+	}
+
+	return records, nil
+}
+
+func getRecord(owner string, name string) (*Record, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	record := Record{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&record)
+	if err != nil {
+		return &record, err
+	}
+
+	if existed {
+		return &record, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRecord(id string) (*Record, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRecord(owner, name)
+}
+
+func (record *Record) toCasvisorRecord() *casvisorsdk.Record {
+	return &casvisorsdk.Record{
+		Owner:        record.Owner,
+		Name:         record.Name,
+		CreatedTime:  record.CreatedTime,
+		Organization: record.Organization,
+		ClientIp:     record.ClientIp,
+		User:         record.User,
+		Method:       record.Method,
+		RequestUri:   record.RequestUri,
+		Action:       record.Action,
+		Object:       record.Object,
+		IsTriggered:  record.IsTriggered,
+	}
+}
+
+// AddRecord stores the record in the database and streams it to the event sinks, Casvisor is an extra sink when its
+// application is configured
+func AddRecord(record *Record) bool {
 	if logPostOnly {
 		if record.Method == "GET" {
 			return false
@@ -73,6 +210,9 @@ func AddRecord(record *casvisorsdk.Record) bool {
 	}
 
 	record.Owner = record.Organization
+	if record.Type == "" {
+		record.Type = GetRecordType(record.Action, record.Result)
+	}
 
 	errWebhook := SendWebhooks(record)
 	if errWebhook == nil {
@@ -81,24 +221,32 @@ func AddRecord(record *casvisorsdk.Record) bool {
 		fmt.Println(errWebhook)
 	}
 
-	if casvisorsdk.GetClient() == nil {
-		return false
-	}
-
-	affected, err := casvisorsdk.AddRecord(record)
+	affected, err := insertChainedRecord(record)
 	if err != nil {
 		panic(err)
 	}
 
-	return affected
+	sendEventSinks(record)
+
+	if casvisorsdk.GetClient() != nil {
+		_, err = casvisorsdk.AddRecord(record.toCasvisorRecord())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to add the record: %s to Casvisor, error: %s", record.Name, err.Error()))
+		}
+	}
+
+	return affected != 0
 }
 
-func SendWebhooks(record *casvisorsdk.Record) error {
+// SendWebhooks queues a delivery for every webhook subscribed to the record, a webhook that fails doesn't keep the
+// others from being queued
+func SendWebhooks(record *Record) error {
 	webhooks, err := getWebhooksByOrganization(record.Organization)
 	if err != nil {
 		return err
 	}
 
+	errs := []string{}
 	for _, webhook := range webhooks {
 		if !webhook.IsEnabled {
 			continue
@@ -106,28 +254,123 @@ func SendWebhooks(record *casvisorsdk.Record) error {
 
 		matched := false
 		for _, event := range webhook.Events {
-			if record.Action == event {
+			if record.Action == event || record.Type == event {
 				matched = true
 				break
 			}
 		}
 
 		if matched {
-			var user *User
-			if webhook.IsUserExtended {
-				user, err = getUser(record.Organization, record.User)
-				user, err = GetMaskedUser(user, false, err)
-				if err != nil {
-					return err
-				}
-			}
-
-			err = sendWebhook(webhook, record, user)
+			err = queueWebhook(webhook, record)
 			if err != nil {
-				return err
+				errs = append(errs, fmt.Sprintf("webhook: %s, error: %s", webhook.GetId(), err.Error()))
 			}
 		}
 	}
 
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to send the webhooks: %s", strings.Join(errs, "; "))
+	}
 	return nil
 }
+
+func queueWebhook(webhook *Webhook, record *Record) error {
+	payload := &WebhookPayload{Record: *record}
+	if webhook.IsUserExtended || webhook.Filter != "" {
+		user, err := getUser(record.Organization, record.User)
+		user, err = GetMaskedUser(user, false, err)
+		if err != nil {
+			return err
+		}
+		payload.ExtendedUser = user
+	}
+
+	matched, err := isWebhookFiltered(webhook, payload)
+	if err != nil || !matched {
+		return err
+	}
+
+	// the user is loaded for the filter, but only sent when the webhook asks for it
+	if !webhook.IsUserExtended {
+		payload.ExtendedUser = nil
+	}
+
+	body, err := getWebhookBody(webhook, payload)
+	if err != nil {
+		return err
+	}
+
+	event := record.Type
+	if event == "" {
+		event = record.Action
+	}
+
+	delivery := newWebhookDelivery(webhook, record.Name, event, body)
+	return addWebhookDelivery(delivery)
+}
+
+// addSystemRecord records an event that is triggered by Casdoor itself rather than by an API request,
+// like the expiration of a role assignment, the webhooks subscribed to the action are sent as well
+func addSystemRecord(organization string, user string, action string, obj interface{}) {
+	object := ""
+	if obj != nil {
+		object = util.StructToJson(obj)
+	}
+
+	record := &Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: organization,
+		User:         user,
+		Method:       "POST",
+		RequestUri:   "/api/" + action,
+		Action:       action,
+		Result:       "ok",
+		Object:       object,
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// deleteExpiredRecords deletes the records older than the retention of their organization. The chained records are
+// only deleted up to the latest checkpoint before the expire time, so that the rest of the chain stays anchored
+func deleteExpiredRecords() error {
+	organizations, err := GetOrganizationsByFields("admin", "name", "record_retention_days")
+	if err != nil {
+		return err
+	}
+
+	for _, organization := range organizations {
+		if organization.RecordRetentionDays <= 0 {
+			continue
+		}
+
+		expireTime := time.Now().AddDate(0, 0, -organization.RecordRetentionDays).Format(time.RFC3339)
+		checkpoints := []*RecordCheckpoint{}
+		err = ormer.Engine.Where("owner = ? and record_created_time < ?", organization.Name, expireTime).Desc("record_id").Limit(1).Find(&checkpoints)
+		if err != nil {
+			return err
+		}
+
+		checkpointRecordId := 0
+		if len(checkpoints) > 0 {
+			checkpointRecordId = checkpoints[0].RecordId
+		}
+
+		_, err = ormer.Engine.Where("owner = ? and created_time < ? and (hash is null or hash = ? or id <= ?)", organization.Name, expireTime, "", checkpointRecordId).Delete(&Record{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RunRecordRetentionJob() {
+	for {
+		err := deleteExpiredRecords()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("record retention job failed, error %s", err))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
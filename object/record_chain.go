@@ -0,0 +1,507 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	RecordChainIssueModified  = "Modified"
+	RecordChainIssueGap       = "Gap"
+	RecordChainIssueUnchained = "Unchained"
+	RecordChainIssueSignature = "Signature"
+)
+
+// RecordChainHead is the latest record of the chain of an organization, every record stores the hash of the
+// record before it, so that a modified or deleted record breaks the chain
+type RecordChainHead struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	RecordId    int    `json:"recordId"`
+	Hash        string `xorm:"varchar(100)" json:"hash"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+}
+
+// RecordCheckpoint is the head of a chain signed with the private key of a cert, the records until the checkpoint
+// can't be rewritten without the private key. The created time of the signed record tells whether its absence is
+// explained by the retention of the organization
+type RecordCheckpoint struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	RecordId          int    `xorm:"index" json:"recordId"`
+	RecordCreatedTime string `xorm:"varchar(100)" json:"recordCreatedTime"`
+	Hash              string `xorm:"varchar(100)" json:"hash"`
+	Cert              string `xorm:"varchar(100)" json:"cert"`
+	Signature         string `xorm:"text" json:"signature"`
+}
+
+type RecordChainIssue struct {
+	RecordId int    `json:"recordId"`
+	Type     string `json:"type"`
+	Message  string `json:"message"`
+}
+
+type RecordChainReport struct {
+	Owner           string              `json:"owner"`
+	RecordCount     int                 `json:"recordCount"`
+	CheckpointCount int                 `json:"checkpointCount"`
+	IsValid         bool                `json:"isValid"`
+	Issues          []*RecordChainIssue `json:"issues"`
+}
+
+// RecordExport has everything needed to verify the records offline, the certificates are the public parts of the
+// certs that signed the checkpoints
+type RecordExport struct {
+	Records      []*Record           `json:"records"`
+	Checkpoints  []*RecordCheckpoint `json:"checkpoints"`
+	Certificates map[string]string   `json:"certificates"`
+}
+
+// recordHashContent is hashed as JSON in this field order, the hash of a record is the hex SHA-256 of it
+type recordHashContent struct {
+	Owner        string `json:"owner"`
+	Name         string `json:"name"`
+	CreatedTime  string `json:"createdTime"`
+	Organization string `json:"organization"`
+	ClientIp     string `json:"clientIp"`
+	User         string `json:"user"`
+	Method       string `json:"method"`
+	RequestUri   string `json:"requestUri"`
+	Action       string `json:"action"`
+	Type         string `json:"type"`
+	Target       string `json:"target"`
+	Result       string `json:"result"`
+	Reason       string `json:"reason"`
+	Object       string `json:"object"`
+	Diff         string `json:"diff"`
+	IsTriggered  bool   `json:"isTriggered"`
+	PrevHash     string `json:"prevHash"`
+}
+
+var recordChainMutexes sync.Map
+
+func (record *Record) getHash() string {
+	content := recordHashContent{
+		Owner:        record.Owner,
+		Name:         record.Name,
+		CreatedTime:  record.CreatedTime,
+		Organization: record.Organization,
+		ClientIp:     record.ClientIp,
+		User:         record.User,
+		Method:       record.Method,
+		RequestUri:   record.RequestUri,
+		Action:       record.Action,
+		Type:         record.Type,
+		Target:       record.Target,
+		Result:       record.Result,
+		Reason:       record.Reason,
+		Object:       record.Object,
+		Diff:         record.Diff,
+		IsTriggered:  record.IsTriggered,
+		PrevHash:     record.PrevHash,
+	}
+
+	data, _ := json.Marshal(content)
+	return util.GetSha256Hash(string(data))
+}
+
+// insertChainedRecord links the record to the head of the chain of its organization. The head is only moved if
+// no other replica has moved it in the meantime, otherwise the record is inserted again on the new head
+func insertChainedRecord(record *Record) (int64, error) {
+	mutex, _ := recordChainMutexes.LoadOrStore(record.Owner, &sync.Mutex{})
+	mutex.(*sync.Mutex).Lock()
+	defer mutex.(*sync.Mutex).Unlock()
+
+	for i := 0; i < 5; i++ {
+		affected, ok, err := tryInsertChainedRecord(record)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return affected, nil
+		}
+	}
+	return 0, fmt.Errorf("failed to chain the record: %s, the chain head of organization: %s keeps changing", record.Name, record.Owner)
+}
+
+func tryInsertChainedRecord(record *Record) (int64, bool, error) {
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+
+	head := &RecordChainHead{Owner: record.Owner}
+	existed, err := session.Get(head)
+	if err != nil {
+		session.Rollback()
+		return 0, false, err
+	}
+
+	record.Id = 0
+	record.PrevHash = head.Hash
+	record.Hash = record.getHash()
+	affected, err := session.Insert(record)
+	if err != nil {
+		session.Rollback()
+		return 0, false, err
+	}
+
+	newHead := &RecordChainHead{Owner: record.Owner, RecordId: record.Id, Hash: record.Hash, UpdatedTime: util.GetCurrentTime()}
+	if existed {
+		var updated int64
+		updated, err = session.ID(head.Owner).Where("hash = ?", head.Hash).AllCols().Update(newHead)
+		if err == nil && updated == 0 {
+			session.Rollback()
+			return 0, false, nil
+		}
+	} else {
+		_, err = session.Insert(newHead)
+		if err != nil {
+			// another replica has started the chain in the meantime
+			session.Rollback()
+			return 0, false, nil
+		}
+	}
+	if err != nil {
+		session.Rollback()
+		return 0, false, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return 0, false, err
+	}
+	return affected, true, nil
+}
+
+func getRecordChainHead(owner string) (*RecordChainHead, error) {
+	head := RecordChainHead{Owner: owner}
+	existed, err := ormer.Engine.Get(&head)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &head, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRecordCheckpoints(owner string) ([]*RecordCheckpoint, error) {
+	checkpoints := []*RecordCheckpoint{}
+	err := ormer.Engine.Where("owner = ?", owner).Asc("record_id").Find(&checkpoints)
+	if err != nil {
+		return checkpoints, err
+	}
+
+	return checkpoints, nil
+}
+
+func getLatestRecordCheckpoint(owner string) (*RecordCheckpoint, error) {
+	checkpoints := []*RecordCheckpoint{}
+	err := ormer.Engine.Where("owner = ?", owner).Desc("record_id").Limit(1).Find(&checkpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+	return checkpoints[0], nil
+}
+
+func (checkpoint *RecordCheckpoint) getSigningString() string {
+	fields := []string{checkpoint.Owner, checkpoint.Name, checkpoint.CreatedTime, strconv.Itoa(checkpoint.RecordId), checkpoint.RecordCreatedTime, checkpoint.Hash}
+	return strings.Join(fields, "|")
+}
+
+func getRecordCheckpointCert() (*Cert, error) {
+	certId := conf.GetConfigString("recordCheckpointCert")
+	if certId == "" {
+		return GetDefaultCert()
+	}
+	return GetCert(certId)
+}
+
+func addRecordCheckpoint(head *RecordChainHead, cert *Cert) error {
+	record := &Record{}
+	existed, err := ormer.Engine.Where("id = ?", head.RecordId).Cols("created_time").Get(record)
+	if err != nil {
+		return err
+	} else if !existed {
+		return fmt.Errorf("the head record: %d of the chain of organization: %s doesn't exist", head.RecordId, head.Owner)
+	}
+
+	checkpoint := &RecordCheckpoint{
+		Owner:             head.Owner,
+		Name:              util.GenerateId(),
+		CreatedTime:       util.GetCurrentTime(),
+		RecordId:          head.RecordId,
+		RecordCreatedTime: record.CreatedTime,
+		Hash:              head.Hash,
+		Cert:              cert.GetId(),
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(cert.PrivateKey))
+	if err != nil {
+		return err
+	}
+
+	checkpoint.Signature, err = jwt.SigningMethodRS256.Sign(checkpoint.getSigningString(), key)
+	if err != nil {
+		return err
+	}
+
+	_, err = ormer.Engine.Insert(checkpoint)
+	return err
+}
+
+// addRecordCheckpoints signs the heads of the chains that have moved since their latest checkpoint
+func addRecordCheckpoints() error {
+	heads := []*RecordChainHead{}
+	err := ormer.Engine.Find(&heads)
+	if err != nil {
+		return err
+	}
+
+	var cert *Cert
+	for _, head := range heads {
+		checkpoint, err := getLatestRecordCheckpoint(head.Owner)
+		if err != nil {
+			return err
+		}
+		if checkpoint != nil && checkpoint.RecordId >= head.RecordId {
+			continue
+		}
+
+		if cert == nil {
+			cert, err = getRecordCheckpointCert()
+			if err != nil {
+				return err
+			} else if cert == nil {
+				return fmt.Errorf("the cert for the record checkpoints doesn't exist")
+			}
+		}
+
+		err = addRecordCheckpoint(head, cert)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RunRecordCheckpointJob() {
+	interval := time.Duration(getConfigLimit("recordCheckpointInterval", 60)) * time.Minute
+
+	for {
+		time.Sleep(interval)
+
+		err := addRecordCheckpoints()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("record checkpoint job failed, error %s", err))
+		}
+	}
+}
+
+func verifyRecordCheckpoint(checkpoint *RecordCheckpoint, certs map[string]*Cert) error {
+	cert, ok := certs[checkpoint.Cert]
+	if !ok {
+		var err error
+		cert, err = GetCert(checkpoint.Cert)
+		if err != nil {
+			return err
+		}
+		certs[checkpoint.Cert] = cert
+	}
+	if cert == nil {
+		return fmt.Errorf("the cert: %s of the checkpoint doesn't exist", checkpoint.Cert)
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(cert.Certificate))
+	if err != nil {
+		return err
+	}
+
+	return jwt.SigningMethodRS256.Verify(checkpoint.getSigningString(), checkpoint.Signature, key)
+}
+
+// VerifyRecordChain walks the chain of an organization from its oldest record, which must be linked to the latest
+// checkpoint before it. A missing record signed by a checkpoint is reported, unless it is older than the retention
+// of the organization and has been deleted by it
+func VerifyRecordChain(owner string) (*RecordChainReport, error) {
+	report := &RecordChainReport{Owner: owner, Issues: []*RecordChainIssue{}}
+	addIssue := func(recordId int, issueType string, message string) {
+		report.Issues = append(report.Issues, &RecordChainIssue{RecordId: recordId, Type: issueType, Message: message})
+	}
+
+	head, err := getRecordChainHead(owner)
+	if err != nil {
+		return nil, err
+	}
+	if head == nil {
+		report.IsValid = true
+		return report, nil
+	}
+
+	checkpoints, err := GetRecordCheckpoints(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// the records created before the cutoff may have been deleted by the retention job
+	cutoff := ""
+	organization, err := getOrganization(context.Background(), "admin", owner)
+	if err != nil {
+		return nil, err
+	}
+	if organization != nil && organization.RecordRetentionDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -organization.RecordRetentionDays).Format(time.RFC3339)
+	}
+
+	certs := map[string]*Cert{}
+	validCheckpoints := []*RecordCheckpoint{}
+	checkpointMap := map[int][]*RecordCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		if checkpoint.RecordId > head.RecordId {
+			continue
+		}
+
+		report.CheckpointCount++
+		err = verifyRecordCheckpoint(checkpoint, certs)
+		if err != nil {
+			addIssue(checkpoint.RecordId, RecordChainIssueSignature, fmt.Sprintf("the signature of checkpoint: %s is invalid: %s", checkpoint.Name, err.Error()))
+			continue
+		}
+		validCheckpoints = append(validCheckpoints, checkpoint)
+		checkpointMap[checkpoint.RecordId] = append(checkpointMap[checkpoint.RecordId], checkpoint)
+	}
+
+	var firstRecord *Record
+	prevHash := ""
+	err = ormer.Engine.Where("owner = ? and id <= ?", owner, head.RecordId).Asc("id").Iterate(&Record{}, func(i int, bean interface{}) error {
+		record := bean.(*Record)
+		if record.Hash == "" {
+			if firstRecord != nil {
+				addIssue(record.Id, RecordChainIssueUnchained, fmt.Sprintf("the record: %s isn't linked to the chain", record.Name))
+			}
+			return nil
+		}
+
+		report.RecordCount++
+		if record.getHash() != record.Hash {
+			addIssue(record.Id, RecordChainIssueModified, fmt.Sprintf("the content of the record: %s doesn't match its hash", record.Name))
+		}
+		if firstRecord != nil && record.PrevHash != prevHash {
+			addIssue(record.Id, RecordChainIssueGap, fmt.Sprintf("the record before the record: %s is missing or modified", record.Name))
+		}
+		if firstRecord == nil {
+			firstRecord = record
+		}
+		prevHash = record.Hash
+
+		for _, checkpoint := range checkpointMap[record.Id] {
+			if checkpoint.Hash != record.Hash {
+				addIssue(record.Id, RecordChainIssueModified, fmt.Sprintf("the hash of the record: %s doesn't match checkpoint: %s", record.Name, checkpoint.Name))
+			}
+		}
+		delete(checkpointMap, record.Id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the records before the oldest one can only have been deleted up to a checkpoint by the retention job
+	if firstRecord != nil {
+		anchorHash := ""
+		for _, checkpoint := range validCheckpoints {
+			if checkpoint.RecordId < firstRecord.Id {
+				anchorHash = checkpoint.Hash
+			}
+		}
+		if firstRecord.PrevHash != anchorHash {
+			addIssue(firstRecord.Id, RecordChainIssueGap, fmt.Sprintf("the records before the record: %s are missing or modified", firstRecord.Name))
+		}
+	}
+
+	if prevHash != head.Hash {
+		addIssue(head.RecordId, RecordChainIssueGap, "the latest records of the chain are missing or modified")
+	}
+	recordIds := []int{}
+	for recordId := range checkpointMap {
+		recordIds = append(recordIds, recordId)
+	}
+	sort.Ints(recordIds)
+
+	for _, recordId := range recordIds {
+		for _, checkpoint := range checkpointMap[recordId] {
+			if cutoff == "" || checkpoint.RecordCreatedTime >= cutoff {
+				addIssue(recordId, RecordChainIssueGap, fmt.Sprintf("the record signed by checkpoint: %s is missing", checkpoint.Name))
+				break
+			}
+		}
+	}
+
+	report.IsValid = len(report.Issues) == 0
+	return report, nil
+}
+
+func GetRecordExport(owner string, filter *RecordFilter) (*RecordExport, error) {
+	records, err := GetRecords(owner, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := GetRecordCheckpoints(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates := map[string]string{}
+	for _, checkpoint := range checkpoints {
+		if _, ok := certificates[checkpoint.Cert]; ok {
+			continue
+		}
+
+		cert, err := GetCert(checkpoint.Cert)
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil {
+			certificates[checkpoint.Cert] = cert.Certificate
+		}
+	}
+
+	return &RecordExport{Records: records, Checkpoints: checkpoints, Certificates: certificates}, nil
+}
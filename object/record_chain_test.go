@@ -0,0 +1,151 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+	_ "modernc.org/sqlite"
+)
+
+func TestRecordHash(t *testing.T) {
+	record := &Record{Owner: "built-in", Name: "record1", Organization: "built-in", User: "alice", Action: "update-user", Object: "{}"}
+	hash := record.getHash()
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, record.getHash())
+
+	scenarios := []struct {
+		description string
+		modify      func(record *Record)
+	}{
+		{"Modified object", func(record *Record) { record.Object = `{"isAdmin":true}` }},
+		{"Modified user", func(record *Record) { record.User = "bob" }},
+		{"Modified result", func(record *Record) { record.Result = "error" }},
+		{"Relinked record", func(record *Record) { record.PrevHash = hash }},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			modified := *record
+			scenario.modify(&modified)
+			assert.NotEqual(t, hash, modified.getHash())
+		})
+	}
+}
+
+func TestRecordCheckpointSignature(t *testing.T) {
+	certificate, privateKey := generateRsaKeys(2048, 1, "casdoor", "casdoor")
+	checkpoint := &RecordCheckpoint{Owner: "built-in", Name: "checkpoint1", CreatedTime: "2023-01-01T00:00:00Z", RecordId: 10, RecordCreatedTime: "2023-01-01T00:00:00Z", Hash: "hash"}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
+	assert.Nil(t, err)
+	checkpoint.Signature, err = jwt.SigningMethodRS256.Sign(checkpoint.getSigningString(), key)
+	assert.Nil(t, err)
+
+	certs := map[string]*Cert{"admin/cert1": {Certificate: certificate}}
+	checkpoint.Cert = "admin/cert1"
+	assert.Nil(t, verifyRecordCheckpoint(checkpoint, certs))
+
+	checkpoint.RecordId = 11
+	assert.NotNil(t, verifyRecordCheckpoint(checkpoint, certs))
+}
+
+func TestVerifyRecordChainCheckpoints(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(Record), new(RecordChainHead), new(RecordCheckpoint), new(Organization), new(Cert)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	certificate, privateKey := generateRsaKeys(2048, 1, "casdoor", "casdoor")
+	cert := &Cert{Owner: "admin", Name: "cert1", Certificate: certificate, PrivateKey: privateKey}
+	_, err = engine.Insert(cert)
+	assert.Nil(t, err)
+	organization := &Organization{Owner: "admin", Name: "org1"}
+	_, err = engine.Insert(organization)
+	assert.Nil(t, err)
+
+	// the first record is old enough to be deleted by a retention of 30 days, the second one isn't
+	for _, createdTime := range []string{"2020-01-01T00:00:00Z", util.GetCurrentTime(), util.GetCurrentTime()} {
+		_, err = insertChainedRecord(&Record{Owner: "org1", Name: util.GenerateId(), CreatedTime: createdTime})
+		assert.Nil(t, err)
+
+		head, err := getRecordChainHead("org1")
+		assert.Nil(t, err)
+		assert.Nil(t, addRecordCheckpoint(head, cert))
+	}
+
+	report, err := VerifyRecordChain("org1")
+	assert.Nil(t, err)
+	assert.True(t, report.IsValid)
+
+	// deleting the oldest records moves the start of the chain, but the checkpoints still know about them
+	_, err = engine.Where("id <= ?", 2).Delete(&Record{})
+	assert.Nil(t, err)
+
+	report, err = VerifyRecordChain("org1")
+	assert.Nil(t, err)
+	assert.False(t, report.IsValid)
+	assert.Len(t, report.Issues, 2)
+
+	organization.RecordRetentionDays = 30
+	_, err = engine.ID(core.PK{"admin", "org1"}).Cols("record_retention_days").Update(organization)
+	assert.Nil(t, err)
+
+	report, err = VerifyRecordChain("org1")
+	assert.Nil(t, err)
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, 2, report.Issues[0].RecordId)
+
+	// the retention job keeps the records after the latest checkpoint, so that deleting them is still detected
+	organization2 := &Organization{Owner: "admin", Name: "org2", RecordRetentionDays: 30}
+	_, err = engine.Insert(organization2)
+	assert.Nil(t, err)
+	for i, createdTime := range []string{"2020-01-01T00:00:00Z", "2020-01-02T00:00:00Z", "2020-01-03T00:00:00Z", util.GetCurrentTime()} {
+		_, err = insertChainedRecord(&Record{Owner: "org2", Name: util.GenerateId(), CreatedTime: createdTime})
+		assert.Nil(t, err)
+
+		if i == 1 {
+			head, err := getRecordChainHead("org2")
+			assert.Nil(t, err)
+			assert.Nil(t, addRecordCheckpoint(head, cert))
+		}
+	}
+
+	assert.Nil(t, deleteExpiredRecords())
+	count, err := engine.Where("owner = ?", "org2").Count(&Record{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	report, err = VerifyRecordChain("org2")
+	assert.Nil(t, err)
+	assert.True(t, report.IsValid)
+
+	_, err = engine.Where("owner = ? and created_time < ?", "org2", "2021-01-01T00:00:00Z").Delete(&Record{})
+	assert.Nil(t, err)
+
+	report, err = VerifyRecordChain("org2")
+	assert.Nil(t, err)
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, RecordChainIssueGap, report.Issues[0].Type)
+}
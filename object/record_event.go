@@ -0,0 +1,224 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const redactedValue = "***"
+
+// RedactedFields is the central denylist of the fields whose values never reach the records, the diffs or the
+// webhooks, the fields are matched case-insensitively in JSON bodies, form bodies and query strings
+var RedactedFields = []string{
+	"password", "oldPassword", "newPassword", "passwordSalt", "originalPassword", "masterPassword", "defaultPassword",
+	"clientSecret", "clientSecret2", "secret", "accessSecret", "privateKey", "scimToken", "passwordHashConfig",
+	"accessToken", "refreshToken", "idToken", "code", "captchaToken", "token", "answer", "answers", "recoveryCode",
+	"totpSecret", "recoveryCodes", "mfaRecoveryCodes", "emailCode", "phoneCode",
+}
+
+// RecordFieldChange is a field changed by an update, the fields in RedactedFields only show that they have changed
+type RecordFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// recordTypeVerbs turn actions like "update-user" into event types like "user.updated"
+var recordTypeVerbs = map[string]string{
+	"add":    "created",
+	"update": "updated",
+	"delete": "deleted",
+}
+
+var recordTypes = map[string]string{
+	"set-password":         "user.password_changed",
+	"reset-email-or-phone": "user.contact_changed",
+}
+
+func isRedactedField(field string) bool {
+	for _, redactedField := range RedactedFields {
+		if strings.EqualFold(field, redactedField) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRecordType gets the event type of an action, like "user.updated" or "login.failed"
+func GetRecordType(action string, result string) string {
+	outcome := "succeeded"
+	if result == "error" {
+		outcome = "failed"
+	}
+
+	switch action {
+	case "login", "signup":
+		return action + "." + outcome
+	}
+
+	if recordType, ok := recordTypes[action]; ok {
+		return recordType
+	}
+
+	tokens := strings.SplitN(action, "-", 2)
+	if len(tokens) == 2 {
+		if verb, ok := recordTypeVerbs[tokens[0]]; ok {
+			return strings.ReplaceAll(tokens[1], "-", "_") + "." + verb
+		}
+	}
+	return action
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isRedactedField(key) {
+				if item != "" && item != nil {
+					v[key] = redactedValue
+				}
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// RedactObject removes the values of the redacted fields from a JSON or form body
+func RedactObject(object string) string {
+	if object == "" {
+		return object
+	}
+
+	var value interface{}
+	err := json.Unmarshal([]byte(object), &value)
+	if err == nil {
+		return util.StructToJson(redactValue(value))
+	}
+
+	values, err := url.ParseQuery(object)
+	if err != nil || !strings.Contains(object, "=") {
+		return object
+	}
+
+	for key := range values {
+		if isRedactedField(key) {
+			values.Set(key, redactedValue)
+		}
+	}
+	return values.Encode()
+}
+
+// RedactRequestUri removes the redacted fields from the query of a request URI
+func RedactRequestUri(requestUri string) string {
+	urlData, err := url.Parse(requestUri)
+	if err != nil {
+		return requestUri
+	}
+
+	blackList := []string{}
+	for key := range urlData.Query() {
+		if isRedactedField(key) {
+			blackList = append(blackList, key)
+		}
+	}
+	if len(blackList) == 0 {
+		return requestUri
+	}
+	return util.FilterQuery(requestUri, blackList)
+}
+
+// GetRecordTarget gets the id of the object of a request, from the "id" query or the owner and name of the body
+func GetRecordTarget(id string, object string) string {
+	if id != "" {
+		return id
+	}
+
+	target := struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	}{}
+	err := json.Unmarshal([]byte(object), &target)
+	if err != nil || target.Name == "" {
+		return ""
+	}
+	if target.Owner == "" {
+		return target.Name
+	}
+	return util.GetId(target.Owner, target.Name)
+}
+
+func toFieldMap(obj interface{}) map[string]interface{} {
+	if obj == nil || (reflect.ValueOf(obj).Kind() == reflect.Ptr && reflect.ValueOf(obj).IsNil()) {
+		return nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+
+	res := map[string]interface{}{}
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil
+	}
+	return res
+}
+
+// GetRecordDiff gets the fields changed between the old and the new object, sorted by the field name
+func GetRecordDiff(oldObj interface{}, newObj interface{}) []*RecordFieldChange {
+	oldFields := toFieldMap(oldObj)
+	newFields := toFieldMap(newObj)
+
+	fields := []string{}
+	for field := range oldFields {
+		fields = append(fields, field)
+	}
+	for field := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []*RecordFieldChange{}
+	for _, field := range fields {
+		oldValue, newValue := oldFields[field], newFields[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		if isRedactedField(field) {
+			oldValue, newValue = redactedValue, redactedValue
+		} else {
+			oldValue, newValue = redactValue(oldValue), redactValue(newValue)
+		}
+		changes = append(changes, &RecordFieldChange{Field: field, Old: oldValue, New: newValue})
+	}
+	return changes
+}
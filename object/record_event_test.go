@@ -0,0 +1,98 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beego/beego/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecordType(t *testing.T) {
+	scenarios := []struct {
+		action   string
+		result   string
+		expected string
+	}{
+		{"update-user", "ok", "user.updated"},
+		{"add-application", "ok", "application.created"},
+		{"delete-policy-version", "error", "policy_version.deleted"},
+		{"login", "ok", "login.succeeded"},
+		{"login", "error", "login.failed"},
+		{"set-password", "ok", "user.password_changed"},
+		{"logout", "ok", "logout"},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.action, func(t *testing.T) {
+			assert.Equal(t, scenario.expected, GetRecordType(scenario.action, scenario.result))
+		})
+	}
+}
+
+func TestRedactObject(t *testing.T) {
+	scenarios := []struct {
+		description string
+		input       string
+		expected    string
+	}{
+		{"JSON body", `{"name":"alice","password":"123"}`, `{"name":"alice","password":"***"}`},
+		{"Nested JSON body", `{"providers":[{"clientSecret":"abc","name":"github"}]}`, `{"providers":[{"clientSecret":"***","name":"github"}]}`},
+		{"Form body", "newPassword=123&userName=alice", "newPassword=%2A%2A%2A&userName=alice"},
+		{"Recovery body", `{"answers":["blue","rex"],"token":"abc","username":"alice"}`, `{"answers":"***","token":"***","username":"alice"}`},
+		{"Plain body", "hello", "hello"},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			assert.Equal(t, scenario.expected, RedactObject(scenario.input))
+		})
+	}
+
+	assert.Equal(t, "/api/login?state=1", RedactRequestUri("/api/login?code=abc&state=1"))
+}
+
+func TestNewRecordCredentials(t *testing.T) {
+	scenarios := []struct {
+		path     string
+		body     string
+		expected string
+	}{
+		{"/api/login", `{"username":"alice","password":"123","customPin":"456"}`, ""},
+		{"/api/signup", `{"username":"alice","password":"123"}`, ""},
+		{"/api/update-user", `{"name":"alice","password":"123"}`, `{"name":"alice","password":"***"}`},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.path, func(t *testing.T) {
+			ctx := context.NewContext()
+			ctx.Reset(httptest.NewRecorder(), httptest.NewRequest("POST", scenario.path, nil))
+			ctx.Input.RequestBody = []byte(scenario.body)
+			assert.Equal(t, scenario.expected, NewRecord(ctx).Object)
+		})
+	}
+}
+
+func TestGetRecordDiff(t *testing.T) {
+	oldUser := &User{Owner: "built-in", Name: "alice", DisplayName: "Alice", Password: "123"}
+	newUser := &User{Owner: "built-in", Name: "alice", DisplayName: "Alice Liddell", Password: "456"}
+
+	changes := GetRecordDiff(oldUser, newUser)
+	assert.Equal(t, []*RecordFieldChange{
+		{Field: "displayName", Old: "Alice", New: "Alice Liddell"},
+		{Field: "password", Old: redactedValue, New: redactedValue},
+	}, changes)
+
+	assert.Empty(t, GetRecordDiff(oldUser, oldUser))
+}
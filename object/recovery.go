@@ -0,0 +1,476 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/cred"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	RecoveryMethodRecoveryCode = "RecoveryCode"
+	RecoveryMethodProofs       = "Proofs"
+	RecoveryMethodAdmin        = "Admin"
+)
+
+const (
+	RecoveryStatePending   = "Pending"
+	RecoveryStateApproved  = "Approved"
+	RecoveryStateRejected  = "Rejected"
+	RecoveryStateFailed    = "Failed"
+	RecoveryStateCompleted = "Completed"
+)
+
+// a user has to answer at least this many security questions when they are combined with an email code
+const minSecurityQuestionCount = 2
+
+type SecurityQuestion struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// RecoveryRequest is the audit trail of an account recovery, every attempt is kept whether it succeeded or not
+type RecoveryRequest struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Method       string `xorm:"varchar(100)" json:"method"`
+	Proofs       string `xorm:"varchar(500)" json:"proofs"`
+	Reason       string `xorm:"varchar(1000)" json:"reason"`
+	DisableMfa   bool   `json:"disableMfa"`
+	State        string `xorm:"varchar(100)" json:"state"`
+	Message      string `xorm:"varchar(1000)" json:"message"`
+	RemoteAddr   string `xorm:"varchar(100)" json:"remoteAddr"`
+	Approver     string `xorm:"varchar(100)" json:"approver"`
+	ApprovedTime string `xorm:"varchar(100)" json:"approvedTime"`
+	ExpireTime   string `xorm:"varchar(100)" json:"expireTime"`
+	FinishedTime string `xorm:"varchar(100)" json:"finishedTime"`
+	TokenHash    string `xorm:"varchar(100)" json:"-"`
+}
+
+func GetRecoveryRequestCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&RecoveryRequest{})
+}
+
+func GetRecoveryRequests(owner string) ([]*RecoveryRequest, error) {
+	recoveryRequests := []*RecoveryRequest{}
+	err := ormer.Engine.Desc("created_time").Find(&recoveryRequests, &RecoveryRequest{Owner: owner})
+	if err != nil {
+		return recoveryRequests, err
+	}
+
+	return recoveryRequests, nil
+}
+
+func GetPaginationRecoveryRequests(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*RecoveryRequest, error) {
+	recoveryRequests := []*RecoveryRequest{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&recoveryRequests)
+	if err != nil {
+		return recoveryRequests, err
+	}
+
+	return recoveryRequests, nil
+}
+
+func getRecoveryRequest(owner string, name string) (*RecoveryRequest, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	recoveryRequest := RecoveryRequest{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&recoveryRequest)
+	if err != nil {
+		return &recoveryRequest, err
+	}
+
+	if existed {
+		return &recoveryRequest, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRecoveryRequest(id string) (*RecoveryRequest, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	return getRecoveryRequest(owner, name)
+}
+
+func (recoveryRequest *RecoveryRequest) GetId() string {
+	return fmt.Sprintf("%s/%s", recoveryRequest.Owner, recoveryRequest.Name)
+}
+
+func getRecoveryTokenHash(recoveryRequest *RecoveryRequest, token string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s:%s", recoveryRequest.GetId(), token)))
+	return hex.EncodeToString(hash[:])
+}
+
+func getRecoveryExpireTime() string {
+	timeout := getConfigLimit("recoveryTimeout", 30)
+	return time.Now().Add(time.Duration(timeout) * time.Minute).Format(time.RFC3339)
+}
+
+func normalizeSecurityAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
+
+// getSecurityAnswerHash hashes the answer with argon2id and a random salt, the answers are short and often
+// guessable, so a fast hash would let them be recovered from a leaked database
+func getSecurityAnswerHash(answer string) string {
+	return cred.NewArgon2idCredManager().GetHashedPassword(normalizeSecurityAnswer(answer), "", "")
+}
+
+// SetUserSecurityQuestions replaces the security questions of the user, only the hashes of the answers are stored
+func SetUserSecurityQuestions(user *User, securityQuestions []*SecurityQuestion, lang string) error {
+	for _, securityQuestion := range securityQuestions {
+		if strings.TrimSpace(securityQuestion.Question) == "" || strings.TrimSpace(securityQuestion.Answer) == "" {
+			return fmt.Errorf(i18n.Translate(lang, "general:Missing parameter"))
+		}
+		securityQuestion.Answer = getSecurityAnswerHash(securityQuestion.Answer)
+		if securityQuestion.Answer == "" {
+			return fmt.Errorf("failed to hash the answer to the security question: %s", securityQuestion.Question)
+		}
+	}
+
+	user.SecurityQuestions = securityQuestions
+	_, err := updateUser(user.GetId(), user, []string{"security_questions"})
+	return err
+}
+
+func checkSecurityAnswers(user *User, answers []string) bool {
+	if len(user.SecurityQuestions) < minSecurityQuestionCount || len(answers) != len(user.SecurityQuestions) {
+		return false
+	}
+
+	// all the answers are checked so that the time doesn't tell which one is wrong
+	credManager := cred.NewArgon2idCredManager()
+	matched := true
+	for i, securityQuestion := range user.SecurityQuestions {
+		if !credManager.IsPasswordCorrect(normalizeSecurityAnswer(answers[i]), securityQuestion.Answer, "", "") {
+			matched = false
+		}
+	}
+	return matched
+}
+
+// GetUserSecurityQuestions returns the questions of the user without the answers, the requester has to prove the
+// access to the email or phone of the user with a "forget" verification code first, so the questions can't be used
+// to tell whether an account exists
+func GetUserSecurityQuestions(user *User, dest string, code string, lang string) ([]string, error) {
+	if user == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "recovery:The email or phone doesn't belong to the user"))
+	}
+
+	phone, _ := util.GetE164Number(user.Phone, user.GetCountryCode(""))
+	if dest == "" || (dest != user.Email && dest != phone) {
+		return nil, fmt.Errorf(i18n.Translate(lang, "recovery:The email or phone doesn't belong to the user"))
+	}
+
+	if result := CheckVerificationCode(dest, code, VerifyPurposeForget, lang); result.Code != VerificationSuccess {
+		return nil, fmt.Errorf(result.Msg)
+	}
+
+	questions := []string{}
+	for _, securityQuestion := range user.SecurityQuestions {
+		questions = append(questions, securityQuestion.Question)
+	}
+	return questions, nil
+}
+
+func newRecoveryRequest(user *User, method string, remoteAddr string) *RecoveryRequest {
+	return &RecoveryRequest{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        user.Name,
+		Method:      method,
+		RemoteAddr:  remoteAddr,
+	}
+}
+
+// issueRecoveryToken generates the secret the requester needs to complete the recovery,
+// only its hash is kept in the database
+func issueRecoveryToken(recoveryRequest *RecoveryRequest) string {
+	token := util.GenerateId()
+	recoveryRequest.TokenHash = getRecoveryTokenHash(recoveryRequest, token)
+	return token
+}
+
+func addFailedRecoveryRequest(user *User, recoveryRequest *RecoveryRequest, msg string, lang string) error {
+	recordSigninErrorInfo(user, lang)
+
+	recoveryRequest.State = RecoveryStateFailed
+	recoveryRequest.Message = msg
+	_, err := ormer.Engine.Insert(recoveryRequest)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf(msg)
+}
+
+// RecoverByRecoveryCode proves the account ownership with one of the MFA recovery codes, the code is consumed
+func RecoverByRecoveryCode(user *User, recoveryCode string, remoteAddr string, lang string) (*RecoveryRequest, string, error) {
+	if msg := checkSigninErrorTimes(user, lang); msg != "" {
+		return nil, "", fmt.Errorf(msg)
+	}
+
+	recoveryRequest := newRecoveryRequest(user, RecoveryMethodRecoveryCode, remoteAddr)
+	recoveryRequest.Proofs = "recoveryCode"
+	if err := MfaRecover(user, recoveryCode); err != nil {
+		return nil, "", addFailedRecoveryRequest(user, recoveryRequest, err.Error(), lang)
+	}
+
+	return approveSelfServiceRecovery(user, recoveryRequest)
+}
+
+// RecoverByProofs combines several weaker proofs: a verification code sent to the email or phone of the user
+// and the answers to all of the user's security questions
+func RecoverByProofs(user *User, dest string, code string, answers []string, remoteAddr string, lang string) (*RecoveryRequest, string, error) {
+	if msg := checkSigninErrorTimes(user, lang); msg != "" {
+		return nil, "", fmt.Errorf(msg)
+	}
+
+	recoveryRequest := newRecoveryRequest(user, RecoveryMethodProofs, remoteAddr)
+	recoveryRequest.Proofs = fmt.Sprintf("%s,securityQuestions", GetVerifyType(dest))
+
+	phone, _ := util.GetE164Number(user.Phone, user.GetCountryCode(""))
+	if dest == "" || (dest != user.Email && dest != phone) {
+		return nil, "", addFailedRecoveryRequest(user, recoveryRequest, i18n.Translate(lang, "recovery:The email or phone doesn't belong to the user"), lang)
+	}
+
+	if len(user.SecurityQuestions) < minSecurityQuestionCount {
+		return nil, "", addFailedRecoveryRequest(user, recoveryRequest, fmt.Sprintf(i18n.Translate(lang, "recovery:At least %d security questions are required for this recovery method"), minSecurityQuestionCount), lang)
+	}
+
+	if result := CheckVerificationCode(dest, code, VerifyPurposeForget, lang); result.Code != VerificationSuccess {
+		return nil, "", addFailedRecoveryRequest(user, recoveryRequest, result.Msg, lang)
+	}
+
+	if !checkSecurityAnswers(user, answers) {
+		return nil, "", addFailedRecoveryRequest(user, recoveryRequest, i18n.Translate(lang, "recovery:The answers to the security questions are wrong"), lang)
+	}
+
+	err := DisableVerificationCode(dest, VerifyPurposeForget)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return approveSelfServiceRecovery(user, recoveryRequest)
+}
+
+func approveSelfServiceRecovery(user *User, recoveryRequest *RecoveryRequest) (*RecoveryRequest, string, error) {
+	resetUserSigninErrorTimes(user)
+
+	token := issueRecoveryToken(recoveryRequest)
+	recoveryRequest.State = RecoveryStateApproved
+	recoveryRequest.ApprovedTime = util.GetCurrentTime()
+	recoveryRequest.ExpireTime = getRecoveryExpireTime()
+	_, err := ormer.Engine.Insert(recoveryRequest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return recoveryRequest, token, nil
+}
+
+// AddAdminRecoveryRequest files a recovery request that an administrator of the organization has to approve,
+// no token is returned to the requester, it's emailed to the user when the request is approved
+func AddAdminRecoveryRequest(user *User, reason string, disableMfa bool, remoteAddr string) (*RecoveryRequest, string, error) {
+	recoveryRequest := newRecoveryRequest(user, RecoveryMethodAdmin, remoteAddr)
+	recoveryRequest.Reason = reason
+	recoveryRequest.DisableMfa = disableMfa
+	recoveryRequest.State = RecoveryStatePending
+
+	_, err := ormer.Engine.Insert(recoveryRequest)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return recoveryRequest, "", nil
+}
+
+// sendRecoveryToken emails the token of an approved recovery request to the email of the user, by the first
+// Email provider of the organization
+func sendRecoveryToken(user *User, recoveryRequest *RecoveryRequest, token string, lang string) error {
+	if user.Email == "" {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The user has no email to receive the recovery token"))
+	}
+
+	providers, err := GetProviders(recoveryRequest.Owner)
+	if err != nil {
+		return err
+	}
+
+	var emailProvider *Provider
+	for _, provider := range providers {
+		if provider.Category == "Email" {
+			emailProvider = provider
+			break
+		}
+	}
+	if emailProvider == nil {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The organization has no Email provider to send the recovery token"))
+	}
+
+	content := fmt.Sprintf("Your account recovery request has been approved. Use the recovery request ID: %s and the token: %s to set a new password, the token expires in %d minutes.",
+		recoveryRequest.GetId(), token, getConfigLimit("recoveryTimeout", 30))
+	return SendEmail(context.Background(), emailProvider, "Account recovery", content, user.Email, recoveryRequest.Owner)
+}
+
+// ReviewRecoveryRequest approves or rejects a pending admin recovery request
+func ReviewRecoveryRequest(id string, approver string, approved bool, message string, lang string) (bool, error) {
+	recoveryRequest, err := GetRecoveryRequest(id)
+	if err != nil {
+		return false, err
+	}
+	if recoveryRequest == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request: %s does not exist"), id)
+	}
+	if recoveryRequest.State != RecoveryStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request is not pending"))
+	}
+
+	recoveryRequest.Approver = approver
+	recoveryRequest.ApprovedTime = util.GetCurrentTime()
+	recoveryRequest.Message = message
+
+	token := ""
+	if approved {
+		recoveryRequest.State = RecoveryStateApproved
+		recoveryRequest.ExpireTime = getRecoveryExpireTime()
+		token = issueRecoveryToken(recoveryRequest)
+	} else {
+		recoveryRequest.State = RecoveryStateRejected
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{recoveryRequest.Owner, recoveryRequest.Name}).Where("state = ?", RecoveryStatePending).
+		Cols("approver", "approved_time", "message", "state", "expire_time", "token_hash").Update(recoveryRequest)
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 || !approved {
+		return affected != 0, nil
+	}
+
+	// the token goes to the email of the user rather than to whoever filed the request
+	user, err := getUser(recoveryRequest.Owner, recoveryRequest.User)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(recoveryRequest.Owner, recoveryRequest.User))
+	}
+
+	err = sendRecoveryToken(user, recoveryRequest, token, lang)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompleteRecovery sets the new password of an approved recovery request, then signs the user out everywhere
+func CompleteRecovery(id string, token string, newPassword string, lang string) error {
+	recoveryRequest, err := GetRecoveryRequest(id)
+	if err != nil {
+		return err
+	}
+	if recoveryRequest == nil || recoveryRequest.TokenHash == "" ||
+		subtle.ConstantTimeCompare([]byte(recoveryRequest.TokenHash), []byte(getRecoveryTokenHash(recoveryRequest, token))) != 1 {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request is invalid"))
+	}
+
+	if recoveryRequest.State != RecoveryStateApproved {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request has not been approved"))
+	}
+
+	expireTime, _ := time.Parse(time.RFC3339, recoveryRequest.ExpireTime)
+	if time.Now().After(expireTime) {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request has expired"))
+	}
+
+	user, err := getUser(recoveryRequest.Owner, recoveryRequest.User)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(recoveryRequest.Owner, recoveryRequest.User))
+	}
+
+	if msg := CheckPasswordComplexity(user, newPassword); msg != "" {
+		return fmt.Errorf(msg)
+	}
+
+	// conditional update so that a token can't be used twice
+	recoveryRequest.State = RecoveryStateCompleted
+	recoveryRequest.FinishedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{recoveryRequest.Owner, recoveryRequest.Name}).Where("state = ?", RecoveryStateApproved).
+		Cols("state", "finished_time").Update(recoveryRequest)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf(i18n.Translate(lang, "recovery:The recovery request is invalid"))
+	}
+
+	user.Password = newPassword
+	_, err = SetUserField(user, "password", user.Password)
+	if err != nil {
+		return err
+	}
+
+	if recoveryRequest.DisableMfa {
+		err = DisabledMultiFactorAuth(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return RevokeUserSessionsAndTokens(user)
+}
+
+// RevokeUserSessionsAndTokens signs the user out of all applications and deletes all the tokens issued to them, an
+// expired token could still be refreshed
+func RevokeUserSessionsAndTokens(user *User) error {
+	sessions := []*Session{}
+	err := ormer.Engine.Find(&sessions, &Session{Owner: user.Owner, Name: user.Name})
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		_, err = DeleteSession(session.GetId())
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = ormer.Engine.Delete(&Token{Organization: user.Owner, User: user.Name})
+	return err
+}
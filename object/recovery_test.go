@@ -0,0 +1,76 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+)
+
+func TestCheckSecurityAnswers(t *testing.T) {
+	user := &User{Owner: "org", Name: "alice"}
+	for _, answer := range []string{"Blue", "Rex"} {
+		user.SecurityQuestions = append(user.SecurityQuestions, &SecurityQuestion{Question: "question", Answer: getSecurityAnswerHash(answer)})
+	}
+
+	// the answers are salted, the same answer doesn't give the same hash
+	assert.NotEqual(t, getSecurityAnswerHash("Blue"), user.SecurityQuestions[0].Answer)
+
+	scenarios := []struct {
+		answers  []string
+		expected bool
+	}{
+		{[]string{"Blue", "Rex"}, true},
+		{[]string{"  blue ", "REX"}, true},
+		{[]string{"Blue", "Max"}, false},
+		{[]string{"Blue"}, false},
+	}
+
+	for _, scenario := range scenarios {
+		assert.Equal(t, scenario.expected, checkSecurityAnswers(user, scenario.answers), "answers: %v", scenario.answers)
+	}
+}
+
+func TestRevokeUserSessionsAndTokens(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(Application), new(Organization), new(Provider), new(Session), new(Token)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	_, err = engine.Insert(&Application{Owner: "admin", Name: "app", Organization: "org", ClientId: "client"})
+	assert.Nil(t, err)
+	_, err = AddToken(&Token{Owner: "admin", Name: "token", Application: "app", Organization: "org", User: "alice", AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3600})
+	assert.Nil(t, err)
+
+	assert.Nil(t, RevokeUserSessionsAndTokens(&User{Owner: "org", Name: "alice"}))
+
+	// the refresh token of the recovered account can't mint new access tokens
+	res, err := RefreshToken("refresh_token", "refresh", "", "client", "", "")
+	assert.Nil(t, err)
+	tokenError, ok := res.(*TokenError)
+	assert.True(t, ok)
+	assert.Equal(t, InvalidGrant, tokenError.Error)
+
+	token, err := GetTokenByAccessToken("access")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+}
@@ -98,6 +98,13 @@ func UpdateRole(id string, role *Role) (bool, error) {
 		return false, nil
 	}
 
+	// the policies of the permissions of the role and of its ancestor roles are derived from the role
+	oldPermissionIds, err := getPermissionIdsByRoles(id)
+	if err != nil {
+		return false, err
+	}
+	addPolicyBaselines(PolicyVersionTypePermission, oldPermissionIds...)
+
 	visited := map[string]struct{}{}
 
 	permissions, err := GetPermissionsByRole(id)
@@ -151,8 +158,10 @@ func UpdateRole(id string, role *Role) (bool, error) {
 	}
 
 	for _, permission := range permissions {
-		addGroupingPolicies(permission)
-		addPolicies(permission)
+		if permission.isApproved() {
+			addGroupingPolicies(permission)
+			addPolicies(permission)
+		}
 		visited[permission.GetId()] = struct{}{}
 	}
 
@@ -169,12 +178,21 @@ func UpdateRole(id string, role *Role) (bool, error) {
 		for _, permission := range permissions {
 			permissionId := permission.GetId()
 			if _, ok := visited[permissionId]; !ok {
-				addGroupingPolicies(permission)
+				if permission.isApproved() {
+					addGroupingPolicies(permission)
+				}
 				visited[permissionId] = struct{}{}
 			}
 		}
 	}
 
+	newPermissionIds, err := getPermissionIdsByRoles(newRoleID)
+	if err != nil {
+		return false, err
+	}
+	// a permission in both lists is only stored once because an unchanged snapshot is skipped
+	addPolicyVersions(PolicyVersionTypePermission, "update-role", append(oldPermissionIds, newPermissionIds...)...)
+
 	return affected != 0, nil
 }
 
@@ -228,6 +246,12 @@ func AddRolesInBatch(roles []*Role) bool {
 
 func DeleteRole(role *Role) (bool, error) {
 	roleId := role.GetId()
+	permissionIds, err := getPermissionIdsByRoles(roleId)
+	if err != nil {
+		return false, err
+	}
+	addPolicyBaselines(PolicyVersionTypePermission, permissionIds...)
+
 	permissions, err := GetPermissionsByRole(roleId)
 	if err != nil {
 		return false, err
@@ -246,6 +270,10 @@ func DeleteRole(role *Role) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		addPolicyVersions(PolicyVersionTypePermission, "delete-role", permissionIds...)
+	}
+
 	return affected != 0, nil
 }
 
@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -43,7 +44,7 @@ func ParseSamlResponse(samlResponse string, provider *Provider, host string) (st
 }
 
 func GenerateSamlRequest(id, relayState, host, lang string) (auth string, method string, err error) {
-	provider, err := GetProvider(id)
+	provider, err := GetProvider(context.Background(), id)
 	if err != nil {
 		return "", "", err
 	}
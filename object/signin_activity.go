@@ -0,0 +1,247 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/mssola/user_agent"
+)
+
+// SigninActivity is a successful sign-in of a user, the session of the sign-in can be revoked by the user
+type SigninActivity struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100) index" json:"createdTime"`
+
+	User        string `xorm:"varchar(100) index" json:"user"`
+	Application string `xorm:"varchar(100)" json:"application"`
+	Provider    string `xorm:"varchar(100)" json:"provider"`
+	MfaType     string `xorm:"varchar(100)" json:"mfaType"`
+	ClientIp    string `xorm:"varchar(100)" json:"clientIp"`
+	UserAgent   string `xorm:"varchar(500)" json:"userAgent"`
+	Device      string `xorm:"varchar(100)" json:"device"`
+	Os          string `xorm:"varchar(100)" json:"os"`
+	Browser     string `xorm:"varchar(100)" json:"browser"`
+	DeviceId    string `xorm:"varchar(100) index" json:"deviceId"`
+	SessionId   string `xorm:"varchar(100)" json:"-"`
+	IsRevoked   bool   `json:"isRevoked"`
+
+	IsCurrent bool `xorm:"-" json:"isCurrent"`
+}
+
+// SigninDevice is a browser that the user has signed in with, it's identified by the device cookie of the browser
+type SigninDevice struct {
+	DeviceId       string   `json:"deviceId"`
+	Device         string   `json:"device"`
+	Os             string   `json:"os"`
+	Browser        string   `json:"browser"`
+	ClientIp       string   `json:"clientIp"`
+	LastSigninTime string   `json:"lastSigninTime"`
+	Applications   []string `json:"applications"`
+	SigninCount    int      `json:"signinCount"`
+	IsActive       bool     `json:"isActive"`
+	IsCurrent      bool     `json:"isCurrent"`
+}
+
+// parseUserAgent returns the kind of device, the OS and the browser of the user agent
+func parseUserAgent(userAgent string) (string, string, string) {
+	ua := user_agent.New(userAgent)
+
+	device := "Desktop"
+	if ua.Bot() {
+		device = "Bot"
+	} else if ua.Mobile() {
+		device = "Mobile"
+	}
+
+	browser, version := ua.Browser()
+	if version != "" {
+		browser = fmt.Sprintf("%s %s", browser, version)
+	}
+	return device, ua.OS(), browser
+}
+
+func GetSigninActivityCount(owner string, user string) (int64, error) {
+	return ormer.Engine.Count(&SigninActivity{Owner: owner, User: user})
+}
+
+func GetPaginationSigninActivities(owner string, user string, offset int, limit int) ([]*SigninActivity, error) {
+	activities := []*SigninActivity{}
+	err := ormer.Engine.Desc("created_time").Limit(limit, offset).Find(&activities, &SigninActivity{Owner: owner, User: user})
+	if err != nil {
+		return activities, err
+	}
+
+	return activities, nil
+}
+
+func getSigninActivity(owner string, name string) (*SigninActivity, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	activity := SigninActivity{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&activity)
+	if err != nil {
+		return &activity, err
+	}
+
+	if existed {
+		return &activity, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetSigninActivity(id string) (*SigninActivity, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	return getSigninActivity(owner, name)
+}
+
+// GetSigninDevices returns the devices of the user from the most recently used one, by the sign-ins that are kept
+func GetSigninDevices(owner string, user string) ([]*SigninDevice, error) {
+	activities := []*SigninActivity{}
+	err := ormer.Engine.Where("device_id <> ?", "").Desc("created_time").Find(&activities, &SigninActivity{Owner: owner, User: user})
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []*SigninDevice{}
+	deviceMap := map[string]*SigninDevice{}
+	for _, activity := range activities {
+		device, ok := deviceMap[activity.DeviceId]
+		if !ok {
+			device = &SigninDevice{
+				DeviceId:       activity.DeviceId,
+				Device:         activity.Device,
+				Os:             activity.Os,
+				Browser:        activity.Browser,
+				ClientIp:       activity.ClientIp,
+				LastSigninTime: activity.CreatedTime,
+				Applications:   []string{},
+			}
+			deviceMap[activity.DeviceId] = device
+			devices = append(devices, device)
+		}
+
+		device.SigninCount += 1
+		if !util.InSlice(device.Applications, activity.Application) {
+			device.Applications = append(device.Applications, activity.Application)
+		}
+		if !activity.IsRevoked {
+			device.IsActive = true
+		}
+	}
+	return devices, nil
+}
+
+func AddSigninActivity(activity *SigninActivity) (bool, error) {
+	if activity.Name == "" {
+		activity.Name = util.GenerateId()
+	}
+	if activity.CreatedTime == "" {
+		activity.CreatedTime = util.GetCurrentTime()
+	}
+	activity.Device, activity.Os, activity.Browser = parseUserAgent(activity.UserAgent)
+
+	affected, err := ormer.Engine.Insert(activity)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// revokeSigninSessions signs out the sessions of the sign-ins, the other sign-ins in the same sessions are
+// revoked as well
+func revokeSigninSessions(activities []*SigninActivity) (bool, error) {
+	sessionIds := []string{}
+	for _, activity := range activities {
+		if activity.IsRevoked || activity.SessionId == "" || util.InSlice(sessionIds, activity.SessionId) {
+			continue
+		}
+
+		_, err := DeleteSessionId(util.GetSessionId(activity.Owner, activity.User, activity.Application), activity.SessionId)
+		if err != nil {
+			return false, err
+		}
+		sessionIds = append(sessionIds, activity.SessionId)
+	}
+
+	if len(sessionIds) == 0 {
+		return false, nil
+	}
+
+	// the Casdoor session is shared by the applications that are signed in with it
+	DeleteBeegoSession(sessionIds)
+
+	// the access and refresh tokens issued in the sessions can't be used or refreshed anymore
+	_, err := ormer.Engine.In("session_id", sessionIds).Delete(&Token{Organization: activities[0].Owner, User: activities[0].User})
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.In("session_id", sessionIds).Cols("is_revoked").
+		Update(&SigninActivity{IsRevoked: true}, &SigninActivity{Owner: activities[0].Owner, User: activities[0].User})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func RevokeSigninActivity(activity *SigninActivity) (bool, error) {
+	return revokeSigninSessions([]*SigninActivity{activity})
+}
+
+func RevokeSigninDevice(owner string, user string, deviceId string) (bool, error) {
+	activities := []*SigninActivity{}
+	err := ormer.Engine.Where("is_revoked = ?", false).Find(&activities, &SigninActivity{Owner: owner, User: user, DeviceId: deviceId})
+	if err != nil {
+		return false, err
+	}
+
+	if len(activities) == 0 {
+		return false, nil
+	}
+
+	return revokeSigninSessions(activities)
+}
+
+func (activity *SigninActivity) GetId() string {
+	return fmt.Sprintf("%s/%s", activity.Owner, activity.Name)
+}
+
+// deleteExpiredSigninActivities deletes the sign-ins older than "signinActivityRetention" days in app.conf
+func deleteExpiredSigninActivities() error {
+	expireTime := time.Now().AddDate(0, 0, -int(getConfigLimit("signinActivityRetention", 90))).Format(time.RFC3339)
+	_, err := ormer.Engine.Where("created_time < ?", expireTime).Delete(&SigninActivity{})
+	return err
+}
+
+func RunSigninActivityRetentionJob() {
+	for {
+		err := deleteExpiredSigninActivities()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("sign-in activity retention job failed, error %s", err))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
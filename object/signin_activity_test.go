@@ -0,0 +1,126 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/beego/beego"
+	"github.com/beego/beego/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	scenarios := []struct {
+		userAgent string
+		device    string
+		os        string
+		browser   string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36", "Desktop", "Windows 10", "Chrome 114.0.0.0"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.5 Mobile/15E148 Safari/604.1", "Mobile", "CPU iPhone OS 16_5 like Mac OS X", "Safari 16.5"},
+		{"Googlebot/2.1 (+http://www.google.com/bot.html)", "Bot", "", "Googlebot"},
+	}
+
+	for _, scenario := range scenarios {
+		device, os, browser := parseUserAgent(scenario.userAgent)
+		assert.Equal(t, scenario.device, device, scenario.userAgent)
+		assert.Equal(t, scenario.os, os, scenario.userAgent)
+		assert.Equal(t, scenario.browser, browser, scenario.userAgent)
+	}
+}
+
+func TestGetSigninDevices(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(SigninActivity)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	activities := []*SigninActivity{
+		{Owner: "org", User: "alice", Application: "app1", DeviceId: "device1", CreatedTime: "2023-06-01T10:00:00Z", IsRevoked: true},
+		{Owner: "org", User: "alice", Application: "app2", DeviceId: "device1", CreatedTime: "2023-06-02T10:00:00Z", IsRevoked: true},
+		{Owner: "org", User: "alice", Application: "app1", DeviceId: "device2", CreatedTime: "2023-06-03T10:00:00Z", ClientIp: "10.0.0.1"},
+		{Owner: "org", User: "bob", Application: "app1", DeviceId: "device3", CreatedTime: "2023-06-04T10:00:00Z"},
+	}
+	for _, activity := range activities {
+		_, err = AddSigninActivity(activity)
+		assert.Nil(t, err)
+	}
+
+	count, err := GetSigninActivityCount("org", "alice")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+
+	devices, err := GetSigninDevices("org", "alice")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(devices))
+	assert.Equal(t, "device2", devices[0].DeviceId)
+	assert.Equal(t, "10.0.0.1", devices[0].ClientIp)
+	assert.True(t, devices[0].IsActive)
+	assert.Equal(t, "device1", devices[1].DeviceId)
+	assert.Equal(t, []string{"app2", "app1"}, devices[1].Applications)
+	assert.Equal(t, 2, devices[1].SigninCount)
+	assert.False(t, devices[1].IsActive)
+}
+
+func TestRevokeSigninDevice(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(SigninActivity), new(Session), new(Token)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	oldSessions := beego.GlobalSessions
+	beego.GlobalSessions, err = session.NewManager("memory", &session.ManagerConfig{CookieName: "casdoor_session_id", Gclifetime: 3600})
+	assert.Nil(t, err)
+	defer func() { beego.GlobalSessions = oldSessions }()
+
+	for _, activity := range []*SigninActivity{
+		{Owner: "org", User: "alice", Application: "app1", DeviceId: "device1", SessionId: "session1"},
+		{Owner: "org", User: "alice", Application: "app1", DeviceId: "device2", SessionId: "session2"},
+	} {
+		_, err = AddSigninActivity(activity)
+		assert.Nil(t, err)
+	}
+	for _, token := range []*Token{
+		{Owner: "admin", Name: "token1", Organization: "org", User: "alice", AccessToken: "access1", SessionId: "session1"},
+		{Owner: "admin", Name: "token2", Organization: "org", User: "alice", AccessToken: "access2", SessionId: "session2"},
+		{Owner: "admin", Name: "token3", Organization: "org", User: "alice", AccessToken: "access3"},
+	} {
+		_, err = AddToken(token)
+		assert.Nil(t, err)
+	}
+
+	affected, err := RevokeSigninDevice("org", "alice", "device1")
+	assert.Nil(t, err)
+	assert.True(t, affected)
+
+	// the tokens issued in the revoked session are deleted, so they can't be used or refreshed anymore
+	for accessToken, isExisted := range map[string]bool{"access1": false, "access2": true, "access3": true} {
+		token, err := GetTokenByAccessToken(accessToken)
+		assert.Nil(t, err)
+		assert.Equal(t, isExisted, token != nil, accessToken)
+	}
+}
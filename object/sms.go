@@ -15,9 +15,12 @@
 package object
 
 import (
+	"context"
 	"strings"
 
+	"github.com/casdoor/casdoor/telemetry"
 	sender "github.com/casdoor/go-sms-sender"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func getSmsClient(provider *Provider) (sender.SmsClient, error) {
@@ -36,7 +39,14 @@ func getSmsClient(provider *Provider) (sender.SmsClient, error) {
 	return client, nil
 }
 
-func SendSms(provider *Provider, content string, phoneNumbers ...string) error {
+func SendSms(ctx context.Context, provider *Provider, content string, phoneNumbers ...string) error {
+	_, span := telemetry.StartSpan(ctx, "sms.Send", attribute.String("provider.type", provider.Type))
+	err := sendSms(provider, content, phoneNumbers...)
+	telemetry.EndSpan(span, err)
+	return err
+}
+
+func sendSms(provider *Provider, content string, phoneNumbers ...string) error {
 	client, err := getSmsClient(provider)
 	if err != nil {
 		return err
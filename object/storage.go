@@ -16,6 +16,7 @@ package object
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -24,8 +25,10 @@ import (
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/i18n"
 	"github.com/casdoor/casdoor/storage"
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
 	"github.com/casdoor/oss"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var isCloudIntranet bool
@@ -134,12 +137,19 @@ func uploadFile(provider *Provider, fullFilePath string, fileBuffer *bytes.Buffe
 	return fileUrl, objectKey, nil
 }
 
-func UploadFileSafe(provider *Provider, fullFilePath string, fileBuffer *bytes.Buffer, lang string) (string, string, error) {
+func UploadFileSafe(ctx context.Context, provider *Provider, fullFilePath string, fileBuffer *bytes.Buffer, lang string) (string, string, error) {
 	// check fullFilePath is there security issue
 	if strings.Contains(fullFilePath, "..") {
 		return "", "", fmt.Errorf("the fullFilePath: %s is not allowed", fullFilePath)
 	}
 
+	_, span := telemetry.StartSpan(ctx, "storage.Put", attribute.String("provider.type", provider.Type))
+	fileUrl, objectKey, err := uploadFileWithRetries(provider, fullFilePath, fileBuffer, lang)
+	telemetry.EndSpan(span, err)
+	return fileUrl, objectKey, err
+}
+
+func uploadFileWithRetries(provider *Provider, fullFilePath string, fileBuffer *bytes.Buffer, lang string) (string, string, error) {
 	var fileUrl string
 	var objectKey string
 	var err error
@@ -158,7 +168,7 @@ func UploadFileSafe(provider *Provider, fullFilePath string, fileBuffer *bytes.B
 	return fileUrl, objectKey, nil
 }
 
-func DeleteFile(provider *Provider, objectKey string, lang string) error {
+func DeleteFile(ctx context.Context, provider *Provider, objectKey string, lang string) error {
 	// check fullFilePath is there security issue
 	if strings.Contains(objectKey, "..") {
 		return fmt.Errorf(i18n.Translate(lang, "storage:The objectKey: %s is not allowed"), objectKey)
@@ -169,5 +179,8 @@ func DeleteFile(provider *Provider, objectKey string, lang string) error {
 		return err
 	}
 
-	return storageProvider.Delete(objectKey)
+	_, span := telemetry.StartSpan(ctx, "storage.Delete", attribute.String("provider.type", provider.Type))
+	err = storageProvider.Delete(objectKey)
+	telemetry.EndSpan(span, err)
+	return err
 }
@@ -16,6 +16,7 @@ package object
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/casdoor/casdoor/util"
 	"github.com/xorm-io/core"
@@ -252,10 +253,12 @@ func (syncer *Syncer) getKey() string {
 }
 
 func RunSyncer(syncer *Syncer) error {
+	startTime := time.Now()
 	err := syncer.initAdapter()
-	if err != nil {
-		return err
+	if err == nil {
+		err = syncer.syncUsers()
 	}
 
-	return syncer.syncUsers()
+	observeSyncerRun(syncer, startTime, err)
+	return err
 }
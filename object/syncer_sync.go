@@ -146,7 +146,9 @@ func (syncer *Syncer) syncUsers() error {
 }
 
 func (syncer *Syncer) syncUsersNoError() {
+	startTime := time.Now()
 	err := syncer.syncUsers()
+	observeSyncerRun(syncer, startTime, err)
 	if err != nil {
 		fmt.Printf("syncUsersNoError() error: %s\n", err.Error())
 	}
@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
@@ -60,6 +61,9 @@ type Token struct {
 	CodeChallenge string `xorm:"varchar(100)" json:"codeChallenge"`
 	CodeIsUsed    bool   `json:"codeIsUsed"`
 	CodeExpireIn  int64  `json:"codeExpireIn"`
+
+	// SessionId is the session that the token is issued in, the token is deleted when the session is revoked
+	SessionId string `xorm:"varchar(100) index" json:"-"`
 }
 
 type TokenWrapper struct {
@@ -167,7 +171,7 @@ func UpdateToken(id string, token *Token) (bool, error) {
 		return false, nil
 	}
 
-	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(token)
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Omit("session_id").Update(token)
 	if err != nil {
 		return false, err
 	}
@@ -210,7 +214,7 @@ func ExpireTokenByAccessToken(accessToken string) (bool, *Application, *Token, e
 		return false, nil, nil, err
 	}
 
-	application, err := getApplication(token.Owner, token.Application)
+	application, err := getApplication(context.Background(), token.Owner, token.Application)
 	if err != nil {
 		return false, nil, nil, err
 	}
@@ -270,7 +274,7 @@ func CheckOAuthLogin(clientId string, responseType string, redirectUri string, s
 	return "", application, nil
 }
 
-func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, lang string) (*Code, error) {
+func GetOAuthCode(userId string, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, sessionId string, lang string) (*Code, error) {
 	user, err := GetUser(userId)
 	if err != nil {
 		return nil, err
@@ -330,6 +334,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		CodeChallenge: challenge,
 		CodeIsUsed:    false,
 		CodeExpireIn:  time.Now().Add(time.Minute * 5).Unix(),
+		SessionId:     sessionId,
 	}
 	_, err = AddToken(token)
 	if err != nil {
@@ -401,6 +406,12 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 
 	go updateUsedByCode(token)
 
+	grantTypeLabel := grantType
+	if tag == "wechat_miniprogram" {
+		grantTypeLabel = tag
+	}
+	observeTokenIssued(application, grantTypeLabel)
+
 	tokenWrapper := &TokenWrapper{
 		AccessToken:  token.AccessToken,
 		IdToken:      token.AccessToken,
@@ -414,6 +425,12 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 }
 
 func RefreshToken(grantType string, refreshToken string, scope string, clientId string, clientSecret string, host string) (interface{}, error) {
+	res, err := getRefreshedToken(grantType, refreshToken, scope, clientId, clientSecret, host)
+	observeTokenRefresh(res, err)
+	return res, err
+}
+
+func getRefreshedToken(grantType string, refreshToken string, scope string, clientId string, clientSecret string, host string) (interface{}, error) {
 	// check parameters
 	if grantType != "refresh_token" {
 		return &TokenError{
@@ -498,6 +515,7 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		ExpiresIn:    application.ExpireInHours * hourSeconds,
 		Scope:        scope,
 		TokenType:    "Bearer",
+		SessionId:    token.SessionId,
 	}
 	_, err = AddToken(newToken)
 	if err != nil {
@@ -509,6 +527,8 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		return nil, err
 	}
 
+	observeTokenIssued(application, "refresh_token")
+
 	tokenWrapper := &TokenWrapper{
 		AccessToken:  newToken.AccessToken,
 		IdToken:      newToken.AccessToken,
@@ -729,7 +749,7 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 
 // GetTokenByUser
 // Implicit flow
-func GetTokenByUser(application *Application, user *User, scope string, host string) (*Token, error) {
+func GetTokenByUser(application *Application, user *User, scope string, host string, sessionId string) (*Token, error) {
 	err := ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
 		return nil, err
@@ -754,12 +774,14 @@ func GetTokenByUser(application *Application, user *User, scope string, host str
 		Scope:        scope,
 		TokenType:    "Bearer",
 		CodeIsUsed:   true,
+		SessionId:    sessionId,
 	}
 	_, err = AddToken(token)
 	if err != nil {
 		return nil, err
 	}
 
+	observeTokenIssued(application, "implicit")
 	return token, nil
 }
 
@@ -773,7 +795,7 @@ func GetWechatMiniProgramToken(application *Application, code string, host strin
 			ErrorDescription: "the application does not support wechat mini program",
 		}, nil
 	}
-	provider, err := GetProvider(util.GetId("admin", mpProvider.Name))
+	provider, err := GetProvider(context.Background(), util.GetId("admin", mpProvider.Name))
 	if err != nil {
 		return nil, nil, err
 	}
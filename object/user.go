@@ -23,6 +23,7 @@ import (
 	"github.com/casdoor/casdoor/util"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
 )
 
 const (
@@ -35,12 +36,17 @@ const UserEnforcerId = "built-in/user-enforcer-built-in"
 var userEnforcer *UserGroupEnforcer
 
 func InitUserManager() {
-	enforcer, err := GetInitializedEnforcer(UserEnforcerId)
+	enforcer, err := GetInitializedSyncedEnforcer(UserEnforcerId)
 	if err != nil {
 		panic(err)
 	}
 
-	userEnforcer = NewUserGroupEnforcer(enforcer.Enforcer)
+	err = WatchEnforcer(UserEnforcerId, enforcer)
+	if err != nil {
+		panic(err)
+	}
+
+	userEnforcer = NewUserGroupEnforcer(enforcer)
 }
 
 type User struct {
@@ -176,6 +182,7 @@ type User struct {
 	WebauthnCredentials []webauthn.Credential `xorm:"webauthnCredentials blob" json:"webauthnCredentials"`
 	PreferredMfaType    string                `xorm:"varchar(100)" json:"preferredMfaType"`
 	RecoveryCodes       []string              `xorm:"varchar(1000)" json:"recoveryCodes"`
+	SecurityQuestions   []*SecurityQuestion   `xorm:"mediumtext" json:"securityQuestions"`
 	TotpSecret          string                `xorm:"varchar(100)" json:"totpSecret"`
 	MfaPhoneEnabled     bool                  `json:"mfaPhoneEnabled"`
 	MfaEmailEnabled     bool                  `json:"mfaEmailEnabled"`
@@ -300,6 +307,60 @@ func GetPaginationUsers(owner string, offset, limit int, field, value, sortField
 	return users, nil
 }
 
+// UserFilter narrows the undeleted users of an organization in the database, the empty fields aren't compared and
+// the values are compared case-insensitively
+type UserFilter struct {
+	Name       string
+	Email      string
+	Properties map[string]string
+}
+
+func getUserFilterSession(owner string, filter *UserFilter) *xorm.Session {
+	session := ormer.Engine.Where("owner = ? and is_deleted = ?", owner, false)
+	if filter.Name != "" {
+		session = session.And("lower(name) = ?", strings.ToLower(filter.Name))
+	}
+	if filter.Email != "" {
+		session = session.And("lower(email) = ?", strings.ToLower(filter.Email))
+	}
+	for key, value := range filter.Properties {
+		// the properties are stored as a JSON object, so a property is matched by its JSON pair. The wildcards in
+		// the value can only match more users, the caller checks the properties of the users again
+		pair := strings.Trim(util.StructToJson(map[string]string{key: value}), "{}")
+		session = session.And("lower(properties) like ?", "%"+strings.ToLower(pair)+"%")
+	}
+	return session
+}
+
+func GetFilteredUserCount(owner string, filter *UserFilter) (int64, error) {
+	return getUserFilterSession(owner, filter).Count(&User{})
+}
+
+// GetPaginationFilteredUsers returns the users of the filter from offset, all of them if limit is -1, sorted by
+// sortField or from the newest user
+func GetPaginationFilteredUsers(owner string, filter *UserFilter, offset int, limit int, sortField string, sortOrder string) ([]*User, error) {
+	session := getUserFilterSession(owner, filter)
+	if limit != -1 {
+		session = session.Limit(limit, offset)
+	}
+	if sortField == "" {
+		sortField = "created_time"
+		sortOrder = "descend"
+	}
+	if sortOrder == "ascend" {
+		session = session.Asc(util.SnakeString(sortField))
+	} else {
+		session = session.Desc(util.SnakeString(sortField))
+	}
+
+	users := []*User{}
+	err := session.Find(&users)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func getUser(owner string, name string) (*User, error) {
 	if owner == "" || name == "" {
 		return nil, nil
@@ -465,6 +526,9 @@ func GetMaskedUser(user *User, isAdminOrSelf bool, errs ...error) (*User, error)
 	if user.RecoveryCodes != nil {
 		user.RecoveryCodes = nil
 	}
+	for _, securityQuestion := range user.SecurityQuestions {
+		securityQuestion.Answer = ""
+	}
 
 	return user, nil
 }
@@ -546,7 +610,15 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 		columns = append(columns, "name", "email", "phone", "country_code", "type")
 	}
 
+	// the permissions granted to the groups the user joins or leaves get a new version with the new members
+	var groupPermissionIds []string
 	if util.ContainsString(columns, "groups") {
+		groupPermissionIds, err = getPermissionIdsByGroups(getChangedGroups(oldUser.Groups, user.Groups)...)
+		if err != nil {
+			return false, err
+		}
+		addPolicyBaselines(PolicyVersionTypePermission, groupPermissionIds...)
+
 		_, err := userEnforcer.UpdateGroupsForUser(user.GetId(), user.Groups)
 		if err != nil {
 			return false, err
@@ -558,6 +630,11 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 		return false, err
 	}
 
+	if affected != 0 {
+		enqueueProvisioningForColumns(user, columns)
+		addPolicyVersions(PolicyVersionTypePermission, "update-group-members", groupPermissionIds...)
+	}
+
 	return affected != 0, nil
 }
 
@@ -611,6 +688,10 @@ func UpdateUserForAllFields(id string, user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		enqueueProvisioning(user, ProvisioningActionUpsert)
+	}
+
 	return affected != 0, nil
 }
 
@@ -672,6 +753,10 @@ func AddUser(user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		enqueueProvisioning(user, ProvisioningActionUpsert)
+	}
+
 	return affected != 0, nil
 }
 
@@ -749,6 +834,10 @@ func DeleteUser(user *User) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		enqueueProvisioning(user, ProvisioningActionDelete)
+	}
+
 	return affected != 0, nil
 }
 
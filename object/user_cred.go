@@ -39,11 +39,60 @@ func (user *User) UpdateUserHash() error {
 	return nil
 }
 
+// UpdateUserPassword hashes the new plain password with the organization's password type, a Firebase hash can
+// only be created for an imported user with the exported salt, so the other passwords are hashed with scrypt
 func (user *User) UpdateUserPassword(organization *Organization) {
+	passwordType := organization.PasswordType
+	credManager := cred.GetCredManager(passwordType)
+	if credManager == nil {
+		return
+	}
+
+	hashedPassword := credManager.GetHashedPassword(user.Password, user.PasswordSalt, organization.getCredSalt(passwordType))
+	if hashedPassword == "" {
+		passwordType = "scrypt"
+		hashedPassword = cred.GetCredManager(passwordType).GetHashedPassword(user.Password, user.PasswordSalt, organization.getCredSalt(passwordType))
+	}
+
+	user.Password = hashedPassword
+	user.PasswordType = passwordType
+}
+
+// UpgradeUserPasswordHash rehashes the verified plain password with the organization's password type, the stored
+// hash is kept if the new type can't create a hash for the user, like a Firebase hash without an exported salt
+func (user *User) UpgradeUserPasswordHash(organization *Organization, plainPassword string, passwordType string) error {
+	if !cred.IsPasswordRehashNeeded(passwordType, user.Password, organization.PasswordType) {
+		return nil
+	}
+
 	credManager := cred.GetCredManager(organization.PasswordType)
-	if credManager != nil {
-		hashedPassword := credManager.GetHashedPassword(user.Password, user.PasswordSalt, organization.PasswordSalt)
-		user.Password = hashedPassword
-		user.PasswordType = organization.PasswordType
+	hashedPassword := credManager.GetHashedPassword(plainPassword, user.PasswordSalt, organization.getCredSalt(organization.PasswordType))
+	if hashedPassword == "" {
+		return nil
+	}
+
+	user.Password = hashedPassword
+	user.PasswordType = organization.PasswordType
+	_, err := updateUser(user.GetId(), user, []string{"password", "password_type", "hash"})
+	return err
+}
+
+type PasswordTypeCount struct {
+	PasswordType string `json:"passwordType"`
+	Count        int64  `json:"count"`
+}
+
+func GetUserPasswordTypeCounts(owner string) ([]*PasswordTypeCount, error) {
+	counts := []*PasswordTypeCount{}
+	session := ormer.Engine.Table(&User{}).Select("password_type, count(*) as count").Where("is_deleted = ?", false)
+	if owner != "" {
+		session = session.And("owner = ?", owner)
 	}
+
+	err := session.GroupBy("password_type").Asc("password_type").Find(&counts)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
 }
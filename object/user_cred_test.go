@@ -0,0 +1,36 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/cred"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateUserPasswordFirebase(t *testing.T) {
+	organization := &Organization{Owner: "admin", Name: "org", PasswordType: "firebase-scrypt", PasswordHashConfig: `{"signerKey":"key"}`}
+
+	// a user without an exported Firebase salt gets a scrypt hash instead of an empty password
+	user := &User{Owner: "org", Name: "alice", Password: "123456"}
+	user.UpdateUserPassword(organization)
+	assert.Equal(t, "scrypt", user.PasswordType)
+	assert.True(t, cred.GetCredManager("scrypt").IsPasswordCorrect("123456", user.Password, "", ""))
+
+	maskedOrganization, err := GetMaskedOrganization(organization)
+	assert.Nil(t, err)
+	assert.Equal(t, "***", maskedOrganization.PasswordHashConfig)
+}
@@ -15,13 +15,16 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
 	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
 )
 
 func updateUserColumn(column string, user *User) bool {
@@ -105,7 +108,7 @@ func TestGetMaskedUsers(t *testing.T) {
 func TestGetUserByField(t *testing.T) {
 	InitConfig()
 
-	user, _ := GetUserByField("built-in", "DingTalk", "test")
+	user, _ := GetUserByField(context.Background(), "built-in", "DingTalk", "test")
 	if user != nil {
 		t.Logf("%+v", user)
 	} else {
@@ -133,3 +136,51 @@ func TestGetEmailsForUsers(t *testing.T) {
 	text := strings.Join(emails, "\n")
 	println(text)
 }
+
+func TestGetPaginationFilteredUsers(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(User)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	users := []*User{
+		{Owner: "org", Name: "alice", CreatedTime: "2023-01-01T00:00:00Z", Email: "Alice@example.com", Properties: map[string]string{"scimExternalId": "a1"}},
+		{Owner: "org", Name: "bob", CreatedTime: "2023-01-02T00:00:00Z", Email: "bob@example.com"},
+		{Owner: "org", Name: "carol", CreatedTime: "2023-01-03T00:00:00Z", IsDeleted: true},
+		{Owner: "org2", Name: "alice", CreatedTime: "2023-01-04T00:00:00Z"},
+	}
+	_, err = engine.Insert(users)
+	assert.Nil(t, err)
+
+	scenarios := []struct {
+		filter   *UserFilter
+		offset   int
+		limit    int
+		expected []string
+	}{
+		{&UserFilter{}, 0, -1, []string{"bob", "alice"}},
+		{&UserFilter{}, 1, 1, []string{"alice"}},
+		{&UserFilter{Name: "ALICE"}, 0, -1, []string{"alice"}},
+		{&UserFilter{Email: "alice@EXAMPLE.com"}, 0, -1, []string{"alice"}},
+		{&UserFilter{Properties: map[string]string{"scimExternalId": "A1"}}, 0, -1, []string{"alice"}},
+		{&UserFilter{Name: "alice", Email: "bob@example.com"}, 0, -1, []string{}},
+	}
+	for _, scenario := range scenarios {
+		users, err := GetPaginationFilteredUsers("org", scenario.filter, scenario.offset, scenario.limit, "", "")
+		assert.Nil(t, err)
+		names := []string{}
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		assert.Equal(t, scenario.expected, names, "filter: %v", scenario.filter)
+	}
+
+	count, err := GetFilteredUserCount("org", &UserFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
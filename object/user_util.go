@@ -15,6 +15,7 @@
 package object
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -24,13 +25,13 @@ import (
 	"github.com/xorm-io/core"
 )
 
-func GetUserByField(organizationName string, field string, value string) (*User, error) {
+func GetUserByField(ctx context.Context, organizationName string, field string, value string) (*User, error) {
 	if field == "" || value == "" {
 		return nil, nil
 	}
 
 	user := User{Owner: organizationName}
-	existed, err := ormer.Engine.Where(fmt.Sprintf("%s=?", strings.ToLower(field)), value).Get(&user)
+	existed, err := ormer.Engine.Context(ctx).Where(fmt.Sprintf("%s=?", strings.ToLower(field)), value).Get(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -43,36 +44,36 @@ func GetUserByField(organizationName string, field string, value string) (*User,
 }
 
 func HasUserByField(organizationName string, field string, value string) bool {
-	user, err := GetUserByField(organizationName, field, value)
+	user, err := GetUserByField(context.Background(), organizationName, field, value)
 	if err != nil {
 		panic(err)
 	}
 	return user != nil
 }
 
-func GetUserByFields(organization string, field string) (*User, error) {
+func GetUserByFields(ctx context.Context, organization string, field string) (*User, error) {
 	// check username
-	user, err := GetUserByField(organization, "name", field)
+	user, err := GetUserByField(ctx, organization, "name", field)
 	if err != nil || user != nil {
 		return user, err
 	}
 
 	// check email
 	if strings.Contains(field, "@") {
-		user, err = GetUserByField(organization, "email", field)
+		user, err = GetUserByField(ctx, organization, "email", field)
 		if user != nil || err != nil {
 			return user, err
 		}
 	}
 
 	// check phone
-	user, err = GetUserByField(organization, "phone", field)
+	user, err = GetUserByField(ctx, organization, "phone", field)
 	if user != nil || err != nil {
 		return user, err
 	}
 
 	// check ID card
-	user, err = GetUserByField(organization, "id_card", field)
+	user, err = GetUserByField(ctx, organization, "id_card", field)
 	if user != nil || err != nil {
 		return user, err
 	}
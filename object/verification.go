@@ -15,6 +15,11 @@
 package object
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -37,6 +42,7 @@ const (
 	wrongCodeError
 	noRecordError
 	timeoutError
+	tooManyAttemptsError
 )
 
 const (
@@ -44,6 +50,16 @@ const (
 	VerifyTypeEmail = "email"
 )
 
+// the purposes a verification code can be issued for, a code only verifies for the purpose it was sent with
+const (
+	VerifyPurposeSignup   = "signup"
+	VerifyPurposeReset    = "reset"
+	VerifyPurposeLogin    = "login"
+	VerifyPurposeForget   = "forget"
+	VerifyPurposeMfaSetup = "mfaSetup"
+	VerifyPurposeMfaAuth  = "mfaAuth"
+)
+
 type VerificationRecord struct {
 	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
 	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
@@ -54,19 +70,105 @@ type VerificationRecord struct {
 	User       string `xorm:"varchar(100) notnull"`
 	Provider   string `xorm:"varchar(100) notnull"`
 	Receiver   string `xorm:"varchar(100) notnull"`
-	Code       string `xorm:"varchar(10) notnull"`
-	Time       int64  `xorm:"notnull"`
+	Code       string `xorm:"varchar(10) notnull"` // kept empty, only the hash of the code is stored
+	CodeHash   string `xorm:"varchar(100)"`
+	Purpose    string `xorm:"varchar(100)"`
+	WrongTimes int
+	Time       int64 `xorm:"notnull"`
 	IsUsed     bool
 }
 
-func IsAllowSend(user *User, remoteAddr, recordType string) error {
+func getConfigLimit(key string, defaultValue int64) int64 {
+	limit, err := conf.GetConfigInt64(key)
+	if err != nil {
+		return defaultValue
+	}
+	return limit
+}
+
+// getVerificationCodeSecret returns the server secret that the codes are hashed with, it's "verificationCodeSecret"
+// in app.conf or the private key of the built-in cert, so the codes can't be guessed from a leaked database alone
+func getVerificationCodeSecret() (string, error) {
+	secret := conf.GetConfigString("verificationCodeSecret")
+	if secret != "" {
+		return secret, nil
+	}
+
+	cert, err := GetDefaultCert()
+	if err != nil {
+		return "", err
+	}
+	if cert == nil || cert.PrivateKey == "" {
+		return "", errors.New("the verification code secret is not configured")
+	}
+	return cert.PrivateKey, nil
+}
+
+func getVerificationCodeHash(record *VerificationRecord, code string) (string, error) {
+	secret, err := getVerificationCodeSecret()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s/%s:%s", record.Owner, record.Name, code)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// countDailySends counts the codes and the magic links sent to one destination or from one IP address since the time,
+// the column is "receiver" or "remote_addr" in both tables
+func countDailySends(ctx context.Context, column string, value string, since time.Time) (int64, error) {
+	codeCount, err := ormer.Engine.Context(ctx).Where(fmt.Sprintf("%s = ? and time >= ?", column), value, since.Unix()).Count(&VerificationRecord{})
+	if err != nil {
+		return 0, err
+	}
+
+	magicLinkCount, err := ormer.Engine.Context(ctx).Where(fmt.Sprintf("%s = ? and created_time >= ?", column), value, since.Format(time.RFC3339)).Count(&MagicLink{})
+	if err != nil {
+		return 0, err
+	}
+
+	return codeCount + magicLinkCount, nil
+}
+
+// checkDailySendQuota limits how many codes and magic links can be sent to one destination or from one IP address
+// in 24 hours, a limit of 0 or less disables the corresponding quota
+func checkDailySendQuota(ctx context.Context, remoteAddr string, dest string) error {
+	since := time.Now().Add(-24 * time.Hour)
+
+	destLimit := getConfigLimit("verificationCodeDailyLimitPerDest", 20)
+	if destLimit > 0 && dest != "" {
+		count, err := countDailySends(ctx, "receiver", dest, since)
+		if err != nil {
+			return err
+		}
+		if count >= destLimit {
+			return fmt.Errorf("you can only send %d codes to the same destination in 24 hours", destLimit)
+		}
+	}
+
+	ipLimit := getConfigLimit("verificationCodeDailyLimitPerIp", 100)
+	if ipLimit > 0 && remoteAddr != "" {
+		count, err := countDailySends(ctx, "remote_addr", remoteAddr, since)
+		if err != nil {
+			return err
+		}
+		if count >= ipLimit {
+			return fmt.Errorf("you can only send %d codes from the same IP address in 24 hours", ipLimit)
+		}
+	}
+
+	return nil
+}
+
+func IsAllowSend(ctx context.Context, user *User, remoteAddr, recordType string, dest string) error {
 	var record VerificationRecord
 	record.RemoteAddr = remoteAddr
 	record.Type = recordType
 	if user != nil {
 		record.User = user.GetId()
 	}
-	has, err := ormer.Engine.Desc("created_time").Get(&record)
+	has, err := ormer.Engine.Context(ctx).Desc("created_time").Get(&record)
 	if err != nil {
 		return err
 	}
@@ -76,10 +178,10 @@ func IsAllowSend(user *User, remoteAddr, recordType string) error {
 		return errors.New("you can only send one code in 60s")
 	}
 
-	return nil
+	return checkDailySendQuota(ctx, remoteAddr, dest)
 }
 
-func SendVerificationCodeToEmail(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string) error {
+func SendVerificationCodeToEmail(ctx context.Context, organization *Organization, user *User, provider *Provider, remoteAddr string, dest string, purpose string) error {
 	if provider == nil {
 		return fmt.Errorf("please set an Email provider first")
 	}
@@ -90,43 +192,49 @@ func SendVerificationCodeToEmail(organization *Organization, user *User, provide
 	// "You have requested a verification code at Casdoor. Here is your code: %s, please enter in 5 minutes."
 	content := fmt.Sprintf(provider.Content, code)
 
-	if err := IsAllowSend(user, remoteAddr, provider.Category); err != nil {
+	if err := IsAllowSend(ctx, user, remoteAddr, provider.Category, dest); err != nil {
+		observeVerificationCode(organization, "email", provider, "rate_limited")
 		return err
 	}
 
-	if err := SendEmail(provider, title, content, dest, sender); err != nil {
+	if err := SendEmail(ctx, provider, title, content, dest, sender); err != nil {
+		observeVerificationCode(organization, "email", provider, "failure")
 		return err
 	}
 
-	if err := AddToVerificationRecord(user, provider, remoteAddr, provider.Category, dest, code); err != nil {
+	if err := AddToVerificationRecord(ctx, user, provider, remoteAddr, provider.Category, dest, code, purpose); err != nil {
 		return err
 	}
 
+	observeVerificationCode(organization, "email", provider, "success")
 	return nil
 }
 
-func SendVerificationCodeToPhone(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string) error {
+func SendVerificationCodeToPhone(ctx context.Context, organization *Organization, user *User, provider *Provider, remoteAddr string, dest string, purpose string) error {
 	if provider == nil {
 		return errors.New("please set a SMS provider first")
 	}
 
-	if err := IsAllowSend(user, remoteAddr, provider.Category); err != nil {
+	if err := IsAllowSend(ctx, user, remoteAddr, provider.Category, dest); err != nil {
+		observeVerificationCode(organization, "sms", provider, "rate_limited")
 		return err
 	}
 
 	code := getRandomCode(6)
-	if err := SendSms(provider, code, dest); err != nil {
+	if err := SendSms(ctx, provider, code, dest); err != nil {
+		observeVerificationCode(organization, "sms", provider, "failure")
 		return err
 	}
 
-	if err := AddToVerificationRecord(user, provider, remoteAddr, provider.Category, dest, code); err != nil {
+	if err := AddToVerificationRecord(ctx, user, provider, remoteAddr, provider.Category, dest, code, purpose); err != nil {
 		return err
 	}
 
+	observeVerificationCode(organization, "sms", provider, "success")
 	return nil
 }
 
-func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordType, dest, code string) error {
+func AddToVerificationRecord(ctx context.Context, user *User, provider *Provider, remoteAddr, recordType, dest, code, purpose string) error {
 	var record VerificationRecord
 	record.RemoteAddr = remoteAddr
 	record.Type = recordType
@@ -139,11 +247,17 @@ func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordT
 
 	record.Provider = provider.Name
 	record.Receiver = dest
-	record.Code = code
+	codeHash, err := getVerificationCodeHash(&record, code)
+	if err != nil {
+		return err
+	}
+
+	record.CodeHash = codeHash
+	record.Purpose = purpose
 	record.Time = time.Now().Unix()
 	record.IsUsed = false
 
-	_, err := ormer.Engine.Insert(record)
+	_, err = ormer.Engine.Context(ctx).Insert(record)
 	if err != nil {
 		return err
 	}
@@ -151,10 +265,46 @@ func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordT
 	return nil
 }
 
-func getVerificationRecord(dest string) (*VerificationRecord, error) {
+// addVerificationWrongTimes counts a wrong guess of the code and returns whether the code is invalidated, the
+// count is increased in the database only while the code is unused and under the limit, so concurrent guesses
+// can't exceed "verificationCodeMaxAttempts"
+func addVerificationWrongTimes(record *VerificationRecord) (bool, error) {
+	maxAttempts := getConfigLimit("verificationCodeMaxAttempts", 5)
+
+	session := ormer.Engine.ID(core.PK{record.Owner, record.Name}).Where("is_used = ?", false)
+	if maxAttempts > 0 {
+		session = session.And("wrong_times < ?", maxAttempts)
+	}
+	affected, err := session.Incr("wrong_times").Update(&VerificationRecord{})
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		// the code has been used, or invalidated by the other guesses
+		return true, nil
+	}
+
+	if maxAttempts <= 0 {
+		return false, nil
+	}
+
+	affected, err = ormer.Engine.ID(core.PK{record.Owner, record.Name}).Where("wrong_times >= ?", maxAttempts).
+		Cols("is_used").Update(&VerificationRecord{IsUsed: true})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func getVerificationRecord(dest string, purpose string) (*VerificationRecord, error) {
+	if dest == "" {
+		return nil, nil
+	}
+
 	var record VerificationRecord
 	record.Receiver = dest
-	has, err := ormer.Engine.Desc("time").Where("is_used = false").Get(&record)
+	has, err := ormer.Engine.Desc("time").Where("is_used = ? and purpose = ?", false, purpose).Get(&record)
 	if err != nil {
 		return nil, err
 	}
@@ -164,8 +314,8 @@ func getVerificationRecord(dest string) (*VerificationRecord, error) {
 	return &record, nil
 }
 
-func CheckVerificationCode(dest, code, lang string) *VerifyResult {
-	record, err := getVerificationRecord(dest)
+func CheckVerificationCode(dest, code, purpose, lang string) *VerifyResult {
+	record, err := getVerificationRecord(dest, purpose)
 	if err != nil {
 		panic(err)
 	}
@@ -184,15 +334,29 @@ func CheckVerificationCode(dest, code, lang string) *VerifyResult {
 		return &VerifyResult{timeoutError, fmt.Sprintf(i18n.Translate(lang, "verification:You should verify your code in %d min!"), timeout)}
 	}
 
-	if record.Code != code {
+	codeHash, err := getVerificationCodeHash(record, code)
+	if err != nil {
+		panic(err)
+	}
+
+	if record.CodeHash == "" || subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(codeHash)) != 1 {
+		// invalidate the code after too many wrong guesses so that it can't be brute-forced within its timeout
+		isInvalidated, err := addVerificationWrongTimes(record)
+		if err != nil {
+			panic(err)
+		}
+
+		if isInvalidated {
+			return &VerifyResult{tooManyAttemptsError, i18n.Translate(lang, "verification:Too many wrong attempts, please request a new code")}
+		}
 		return &VerifyResult{wrongCodeError, i18n.Translate(lang, "verification:Wrong verification code!")}
 	}
 
 	return &VerifyResult{VerificationSuccess, ""}
 }
 
-func DisableVerificationCode(dest string) (err error) {
-	record, err := getVerificationRecord(dest)
+func DisableVerificationCode(dest string, purpose string) (err error) {
+	record, err := getVerificationRecord(dest, purpose)
 	if record == nil || err != nil {
 		return
 	}
@@ -208,13 +372,16 @@ func CheckSigninCode(user *User, dest, code, lang string) string {
 		return msg
 	}
 
-	result := CheckVerificationCode(dest, code, lang)
+	result := CheckVerificationCode(dest, code, VerifyPurposeLogin, lang)
 	switch result.Code {
 	case VerificationSuccess:
 		resetUserSigninErrorTimes(user)
 		return ""
 	case wrongCodeError:
 		return recordSigninErrorInfo(user, lang)
+	case tooManyAttemptsError:
+		recordSigninErrorInfo(user, lang)
+		return result.Msg
 	default:
 		return result.Msg
 	}
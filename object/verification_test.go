@@ -0,0 +1,83 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+	_ "modernc.org/sqlite"
+)
+
+func TestCheckVerificationCode(t *testing.T) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.SetMaxOpenConns(1)
+	assert.Nil(t, engine.Sync2(new(VerificationRecord), new(MagicLink)))
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	defer func() { ormer = oldOrmer }()
+
+	for key, value := range map[string]string{"verificationCodeSecret": "secret", "verificationCodeTimeout": "10", "verificationCodeMaxAttempts": "5"} {
+		os.Setenv(key, value)
+		defer os.Unsetenv(key)
+	}
+
+	provider := &Provider{Owner: "admin", Name: "provider", Category: "Email"}
+	dest := "alice@example.com"
+	assert.Nil(t, AddToVerificationRecord(context.Background(), nil, provider, "127.0.0.1", provider.Category, dest, "123456", VerifyPurposeLogin))
+
+	record, err := getVerificationRecord(dest, VerifyPurposeLogin)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "123456", record.CodeHash)
+
+	os.Setenv("verificationCodeSecret", "other")
+	assert.Equal(t, wrongCodeError, CheckVerificationCode(dest, "123456", VerifyPurposeLogin, "en").Code)
+	os.Setenv("verificationCodeSecret", "secret")
+
+	var wg sync.WaitGroup
+	results := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- CheckVerificationCode(dest, "000000", VerifyPurposeLogin, "en").Code
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	wrongCount := 0
+	for code := range results {
+		if code == wrongCodeError {
+			wrongCount++
+		}
+	}
+	// one wrong guess was made with the other secret above
+	assert.Equal(t, 3, wrongCount)
+
+	record = &VerificationRecord{Owner: record.Owner, Name: record.Name}
+	_, err = engine.Get(record)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, record.WrongTimes)
+	assert.True(t, record.IsUsed)
+	assert.Equal(t, noRecordError, CheckVerificationCode(dest, "123456", VerifyPurposeLogin, "en").Code)
+}
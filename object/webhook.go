@@ -40,6 +40,14 @@ type Webhook struct {
 	Events         []string  `xorm:"varchar(1000)" json:"events"`
 	IsUserExtended bool      `json:"isUserExtended"`
 	IsEnabled      bool      `json:"isEnabled"`
+
+	Preset   string `xorm:"varchar(100)" json:"preset"`
+	Template string `xorm:"mediumtext" json:"template"`
+	Filter   string `xorm:"varchar(1000)" json:"filter"`
+
+	Secret       string `xorm:"varchar(100)" json:"secret"`
+	Timeout      int    `json:"timeout"`
+	FailureCount int    `json:"failureCount"`
 }
 
 func GetWebhookCount(owner, organization, field, value string) (int64, error) {
@@ -102,11 +110,19 @@ func GetWebhook(id string) (*Webhook, error) {
 }
 
 func UpdateWebhook(id string, webhook *Webhook) (bool, error) {
+	err := checkWebhook(webhook)
+	if err != nil {
+		return false, err
+	}
+
 	owner, name := util.GetOwnerAndNameFromId(id)
 	if w, err := getWebhook(owner, name); err != nil {
 		return false, err
 	} else if w == nil {
 		return false, nil
+	} else if webhook.IsEnabled && !w.IsEnabled {
+		// a webhook disabled after repeated failures starts counting again when it's enabled
+		webhook.FailureCount = 0
 	}
 
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(webhook)
@@ -118,6 +134,11 @@ func UpdateWebhook(id string, webhook *Webhook) (bool, error) {
 }
 
 func AddWebhook(webhook *Webhook) (bool, error) {
+	err := checkWebhook(webhook)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(webhook)
 	if err != nil {
 		return false, err
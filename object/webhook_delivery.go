@@ -0,0 +1,297 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	WebhookDeliveryStatePending   = "Pending"
+	WebhookDeliveryStateSucceeded = "Succeeded"
+	WebhookDeliveryStateFailed    = "Failed"
+)
+
+const (
+	webhookDeliveryInterval    = 10 * time.Second
+	webhookDeliveryBatchSize   = 100
+	webhookDeliveryBaseBackoff = 30
+	// a claimed delivery is retried by another worker if the one sending it dies
+	webhookDeliveryClaimTimeout = 300
+)
+
+// WebhookDelivery is a queued request of a webhook, it's kept afterward as the delivery history of the webhook
+type WebhookDelivery struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Webhook      string `xorm:"varchar(100) index" json:"webhook"`
+	Organization string `xorm:"varchar(100)" json:"organization"`
+	Record       string `xorm:"varchar(100)" json:"record"`
+	Event        string `xorm:"varchar(100)" json:"event"`
+	Url          string `xorm:"varchar(100)" json:"url"`
+	Body         string `xorm:"mediumtext" json:"body"`
+
+	State      string `xorm:"varchar(100) index" json:"state"`
+	Attempts   int    `json:"attempts"`
+	NextTime   int64  `xorm:"index" json:"nextTime"`
+	StatusCode int    `json:"statusCode"`
+	Latency    int64  `json:"latency"`
+	Response   string `xorm:"varchar(1000)" json:"response"`
+	Message    string `xorm:"varchar(1000)" json:"message"`
+}
+
+func GetWebhookDeliveryCount(owner, webhook, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	if webhook != "" {
+		session = session.And("webhook = ?", webhook)
+	}
+	return session.Count(&WebhookDelivery{})
+}
+
+func GetWebhookDeliveries(owner string, webhook string) ([]*WebhookDelivery, error) {
+	deliveries := []*WebhookDelivery{}
+	err := ormer.Engine.Desc("created_time").Find(&deliveries, &WebhookDelivery{Owner: owner, Webhook: webhook})
+	if err != nil {
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func GetPaginationWebhookDeliveries(owner, webhook string, offset, limit int, field, value, sortField, sortOrder string) ([]*WebhookDelivery, error) {
+	deliveries := []*WebhookDelivery{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	if webhook != "" {
+		session = session.And("webhook = ?", webhook)
+	}
+	err := session.Find(&deliveries)
+	if err != nil {
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func getWebhookDelivery(owner string, name string) (*WebhookDelivery, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	delivery := WebhookDelivery{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&delivery)
+	if err != nil {
+		return &delivery, err
+	}
+
+	if existed {
+		return &delivery, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetWebhookDelivery(id string) (*WebhookDelivery, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getWebhookDelivery(owner, name)
+}
+
+func (delivery *WebhookDelivery) GetId() string {
+	return fmt.Sprintf("%s/%s", delivery.Owner, delivery.Name)
+}
+
+func newWebhookDelivery(webhook *Webhook, record string, event string, body string) *WebhookDelivery {
+	return &WebhookDelivery{
+		Owner:        webhook.Owner,
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		UpdatedTime:  util.GetCurrentTime(),
+		Webhook:      webhook.Name,
+		Organization: webhook.Organization,
+		Record:       record,
+		Event:        event,
+		Url:          webhook.Url,
+		Body:         body,
+		State:        WebhookDeliveryStatePending,
+		NextTime:     time.Now().Unix(),
+	}
+}
+
+// addWebhookDelivery queues the delivery and makes its first attempt right away in the background, the failed
+// attempts are retried by the delivery job
+func addWebhookDelivery(delivery *WebhookDelivery) error {
+	_, err := ormer.Engine.Insert(delivery)
+	if err != nil {
+		return err
+	}
+
+	util.SafeGoroutine(func() {
+		err := runWebhookDelivery(delivery)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("webhook delivery: %s failed, error %s", delivery.GetId(), err))
+		}
+	})
+	return nil
+}
+
+// RedeliverWebhook queues a copy of a delivery, the original one is kept in the history
+func RedeliverWebhook(id string) (*WebhookDelivery, error) {
+	delivery, err := GetWebhookDelivery(id)
+	if err != nil {
+		return nil, err
+	} else if delivery == nil {
+		return nil, fmt.Errorf("the webhook delivery: %s doesn't exist", id)
+	}
+
+	webhook, err := getWebhook(delivery.Owner, delivery.Webhook)
+	if err != nil {
+		return nil, err
+	} else if webhook == nil {
+		return nil, fmt.Errorf("the webhook: %s doesn't exist", util.GetId(delivery.Owner, delivery.Webhook))
+	}
+
+	newDelivery := newWebhookDelivery(webhook, delivery.Record, delivery.Event, delivery.Body)
+	_, err = ormer.Engine.Insert(newDelivery)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runWebhookDelivery(newDelivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return getWebhookDelivery(newDelivery.Owner, newDelivery.Name)
+}
+
+func getWebhookMaxAttempts() int {
+	return int(getConfigLimit("webhookMaxAttempts", 8))
+}
+
+// updateWebhookFailureCount counts the consecutive failed deliveries of a webhook, the webhook is disabled when
+// the count reaches the limit
+func updateWebhookFailureCount(webhook *Webhook, isFailed bool) error {
+	if !isFailed {
+		if webhook.FailureCount == 0 {
+			return nil
+		}
+		_, err := ormer.Engine.ID(core.PK{webhook.Owner, webhook.Name}).Cols("failure_count").Update(&Webhook{FailureCount: 0})
+		return err
+	}
+
+	_, err := ormer.Engine.ID(core.PK{webhook.Owner, webhook.Name}).Incr("failure_count").Update(&Webhook{})
+	if err != nil {
+		return err
+	}
+
+	if int64(webhook.FailureCount+1) >= getConfigLimit("webhookMaxFailures", 5) && webhook.IsEnabled {
+		_, err = ormer.Engine.ID(core.PK{webhook.Owner, webhook.Name}).Cols("is_enabled").Update(&Webhook{IsEnabled: false})
+		if err != nil {
+			return err
+		}
+		logs.Warning(fmt.Sprintf("the webhook: %s is disabled after %d failed deliveries", webhook.GetId(), webhook.FailureCount+1))
+	}
+	return nil
+}
+
+func runWebhookDelivery(delivery *WebhookDelivery) error {
+	// claim the delivery so that the other replicas skip it
+	now := time.Now().Unix()
+	affected, err := ormer.Engine.ID(core.PK{delivery.Owner, delivery.Name}).Where("state = ? and next_time = ?", WebhookDeliveryStatePending, delivery.NextTime).
+		Cols("next_time").Update(&WebhookDelivery{NextTime: now + webhookDeliveryClaimTimeout})
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	webhook, err := getWebhook(delivery.Owner, delivery.Webhook)
+	if err != nil {
+		return err
+	}
+
+	delivery.Attempts += 1
+	delivery.UpdatedTime = util.GetCurrentTime()
+	if webhook == nil || !webhook.IsEnabled {
+		delivery.State = WebhookDeliveryStateFailed
+		delivery.Message = "the webhook is deleted or disabled"
+	} else {
+		startTime := time.Now()
+		delivery.StatusCode, delivery.Response, err = sendWebhook(webhook, delivery)
+		delivery.Latency = time.Since(startTime).Milliseconds()
+		observeWebhookDelivery(webhook.Organization, err)
+
+		if err == nil {
+			delivery.State = WebhookDeliveryStateSucceeded
+			delivery.Message = ""
+		} else {
+			delivery.Message = err.Error()
+			if delivery.Attempts >= getWebhookMaxAttempts() {
+				delivery.State = WebhookDeliveryStateFailed
+			} else {
+				delivery.NextTime = time.Now().Unix() + webhookDeliveryBaseBackoff<<(delivery.Attempts-1)
+			}
+		}
+
+		if delivery.State != WebhookDeliveryStatePending {
+			err = updateWebhookFailureCount(webhook, delivery.State == WebhookDeliveryStateFailed)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	session := ormer.Engine.ID(core.PK{delivery.Owner, delivery.Name}).Where("next_time = ?", now+webhookDeliveryClaimTimeout)
+	if delivery.State == WebhookDeliveryStatePending {
+		_, err = session.Cols("attempts", "next_time", "status_code", "latency", "response", "message", "updated_time").Update(delivery)
+	} else {
+		_, err = session.Cols("attempts", "state", "status_code", "latency", "response", "message", "updated_time").Update(delivery)
+	}
+	return err
+}
+
+func runWebhookDeliveries() error {
+	deliveries := []*WebhookDelivery{}
+	err := ormer.Engine.Where("state = ? and next_time <= ?", WebhookDeliveryStatePending, time.Now().Unix()).
+		Asc("created_time").Limit(webhookDeliveryBatchSize).Find(&deliveries)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		err = runWebhookDelivery(delivery)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunWebhookDeliveryJob sends the queued webhook deliveries, failed deliveries are retried with an exponential backoff
+func RunWebhookDeliveryJob() {
+	for {
+		err := runWebhookDeliveries()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("webhook delivery failed, error %s", err))
+		}
+
+		time.Sleep(webhookDeliveryInterval)
+	}
+}
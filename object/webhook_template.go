@@ -0,0 +1,180 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Knetic/govaluate"
+)
+
+// WebhookPayload is the default body of a webhook, and the data of its template
+type WebhookPayload struct {
+	Record
+	ExtendedUser *User `xorm:"-" json:"extendedUser"`
+}
+
+// WebhookPresets are the templates of the chat services supported by the notification providers, the message is
+// rendered by the "message" function
+var WebhookPresets = map[string]string{
+	"Slack":           `{"text": {{json (message .)}}}`,
+	"Microsoft Teams": `{"@type": "MessageCard", "@context": "https://schema.org/extensions", "summary": {{json .Type}}, "title": {{json .Type}}, "text": {{json (message .)}}}`,
+	"Discord":         `{"content": {{json (message .)}}}`,
+	"Google Chat":     `{"text": {{json (message .)}}}`,
+	"Lark":            `{"msg_type": "text", "content": {"text": {{json (message .)}}}}`,
+	"DingTalk":        `{"msgtype": "text", "text": {"content": {{json (message .)}}}}`,
+	"Rocket Chat":     `{"text": {{json (message .)}}}`,
+	"Matrix":          `{"msgtype": "m.text", "body": {{json (message .)}}}`,
+}
+
+func getWebhookMessage(payload *WebhookPayload) string {
+	message := fmt.Sprintf("[%s] %s", payload.Organization, payload.Type)
+	if payload.User != "" {
+		message += fmt.Sprintf(" by %s", payload.User)
+	}
+	if payload.Target != "" {
+		message += fmt.Sprintf(" on %s", payload.Target)
+	}
+	if payload.Result == "error" {
+		message += " failed"
+		if payload.Reason != "" {
+			message += fmt.Sprintf(": %s", payload.Reason)
+		}
+	}
+	return message
+}
+
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"message": getWebhookMessage,
+	"join":    strings.Join,
+}
+
+func (webhook *Webhook) getTemplate() string {
+	if webhook.Template != "" {
+		return webhook.Template
+	}
+	return WebhookPresets[webhook.Preset]
+}
+
+func parseWebhookTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(webhookTemplateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// getWebhookBody renders the template of the webhook, or the payload as JSON if the webhook has no template
+func getWebhookBody(webhook *Webhook, payload *WebhookPayload) (string, error) {
+	text := webhook.getTemplate()
+	if text == "" {
+		data, err := json.Marshal(payload)
+		return string(data), err
+	}
+
+	t, err := parseWebhookTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	err = t.Execute(&buffer, payload)
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// getWebhookFilterParameters are the variables of the filter expressions, like "result == 'error'" or
+// "'built-in/admins' IN groups"
+func getWebhookFilterParameters(payload *WebhookPayload) map[string]interface{} {
+	parameters := map[string]interface{}{
+		"organization": payload.Organization,
+		"user":         payload.User,
+		"clientIp":     payload.ClientIp,
+		"method":       payload.Method,
+		"action":       payload.Action,
+		"type":         payload.Type,
+		"target":       payload.Target,
+		"result":       payload.Result,
+		"reason":       payload.Reason,
+		"userType":     "",
+		"tag":          "",
+		"isAdmin":      false,
+		"groups":       []interface{}{},
+	}
+
+	if user := payload.ExtendedUser; user != nil {
+		parameters["userType"] = user.Type
+		parameters["tag"] = user.Tag
+		parameters["isAdmin"] = user.IsAdmin
+		parameters["groups"] = toInterfaces(user.Groups)
+	}
+	return parameters
+}
+
+// evaluateEventFilter checks a filter expression of a webhook or an event sink, an empty filter lets every event through
+func evaluateEventFilter(filter string, payload *WebhookPayload) (bool, error) {
+	if filter == "" {
+		return true, nil
+	}
+
+	expression, err := govaluate.NewEvaluableExpression(filter)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := expression.Evaluate(getWebhookFilterParameters(payload))
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := res.(bool)
+	if !ok {
+		return false, fmt.Errorf("the filter: %s should be a boolean expression", filter)
+	}
+	return matched, nil
+}
+
+func isWebhookFiltered(webhook *Webhook, payload *WebhookPayload) (bool, error) {
+	return evaluateEventFilter(webhook.Filter, payload)
+}
+
+func checkWebhook(webhook *Webhook) error {
+	if webhook.Preset != "" {
+		if _, ok := WebhookPresets[webhook.Preset]; !ok {
+			return fmt.Errorf("unknown webhook preset: %s", webhook.Preset)
+		}
+	}
+
+	if webhook.Template != "" {
+		_, err := parseWebhookTemplate(webhook.Template)
+		if err != nil {
+			return err
+		}
+	}
+
+	if webhook.Filter != "" {
+		_, err := isWebhookFiltered(webhook, &WebhookPayload{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
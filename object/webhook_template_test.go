@@ -0,0 +1,68 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsWebhookFiltered(t *testing.T) {
+	payload := &WebhookPayload{
+		Record:       Record{Organization: "built-in", User: "alice", Type: "login.failed", Result: "error"},
+		ExtendedUser: &User{Groups: []string{"built-in/admins"}},
+	}
+
+	scenarios := []struct {
+		filter   string
+		expected bool
+	}{
+		{"", true},
+		{"result == 'error'", true},
+		{"type == 'user.updated'", false},
+		{"'built-in/admins' IN groups", true},
+		{"'built-in/devs' IN groups || user == 'bob'", false},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.filter, func(t *testing.T) {
+			matched, err := isWebhookFiltered(&Webhook{Filter: scenario.filter}, payload)
+			assert.Nil(t, err)
+			assert.Equal(t, scenario.expected, matched)
+		})
+	}
+
+	assert.NotNil(t, checkWebhook(&Webhook{Filter: "result =="}))
+	assert.NotNil(t, checkWebhook(&Webhook{Template: "{{.Action"}))
+	assert.NotNil(t, checkWebhook(&Webhook{Preset: "Unknown"}))
+}
+
+func TestGetWebhookBody(t *testing.T) {
+	payload := &WebhookPayload{Record: Record{Organization: "built-in", User: "alice", Type: "user.updated", Target: "built-in/bob", Result: "ok"}}
+
+	body, err := getWebhookBody(&Webhook{Template: `{{.User}} {{.Type}}`}, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice user.updated", body)
+
+	for preset := range WebhookPresets {
+		t.Run(preset, func(t *testing.T) {
+			body, err := getWebhookBody(&Webhook{Preset: preset}, payload)
+			assert.Nil(t, err)
+			assert.True(t, json.Valid([]byte(body)), body)
+			assert.Contains(t, body, "[built-in] user.updated by alice on built-in/bob")
+		})
+	}
+}
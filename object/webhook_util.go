@@ -15,30 +15,36 @@
 package object
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/casdoor/casdoor/telemetry"
 	"github.com/casdoor/casdoor/util"
-	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
 )
 
-func sendWebhook(webhook *Webhook, record *casvisorsdk.Record, extendedUser *User) error {
-	client := &http.Client{}
+// getWebhookSignature signs the timestamp together with the body, so that a receiver can reject the replays of an old delivery
+func getWebhookSignature(secret string, timestamp string, body string) string {
+	return "sha256=" + util.GetHmacSha256(secret, timestamp+"."+body)
+}
 
-	type RecordEx struct {
-		casvisorsdk.Record
-		ExtendedUser *User `xorm:"-" json:"extendedUser"`
-	}
-	recordEx := &RecordEx{
-		Record:       *record,
-		ExtendedUser: extendedUser,
+func getWebhookTimeout(webhook *Webhook) time.Duration {
+	if webhook.Timeout > 0 {
+		return time.Duration(webhook.Timeout) * time.Second
 	}
+	return time.Duration(getConfigLimit("webhookTimeout", 10)) * time.Second
+}
 
-	body := strings.NewReader(util.StructToJson(recordEx))
+// sendWebhook sends a delivery once, it returns the status code and the beginning of the response body
+func sendWebhook(webhook *Webhook, delivery *WebhookDelivery) (int, string, error) {
+	client := &http.Client{Timeout: getWebhookTimeout(webhook), Transport: telemetry.NewTransport(nil, nil)}
 
-	req, err := http.NewRequest(webhook.Method, webhook.Url, body)
+	req, err := http.NewRequest(webhook.Method, webhook.Url, strings.NewReader(delivery.Body))
 	if err != nil {
-		return err
+		return 0, "", err
 	}
 
 	req.Header.Set("Content-Type", webhook.ContentType)
@@ -47,6 +53,27 @@ func sendWebhook(webhook *Webhook, record *casvisorsdk.Record, extendedUser *Use
 		req.Header.Set(header.Name, header.Value)
 	}
 
-	_, err = client.Do(req)
-	return err
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("X-Casdoor-Delivery", delivery.Name)
+	req.Header.Set("X-Casdoor-Event", delivery.Event)
+	req.Header.Set("X-Casdoor-Timestamp", timestamp)
+	if webhook.Secret != "" {
+		req.Header.Set("X-Casdoor-Signature", getWebhookSignature(webhook.Secret, timestamp, delivery.Body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1000))
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(data), fmt.Errorf("the webhook responded with status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(data), nil
 }
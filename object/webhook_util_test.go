@@ -0,0 +1,50 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendWebhook(t *testing.T) {
+	var signature, timestamp string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Casdoor-Signature")
+		timestamp = r.Header.Get("X-Casdoor-Timestamp")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{Url: server.URL, Method: "POST", ContentType: "application/json", Secret: "secret", Timeout: 1}
+	delivery := &WebhookDelivery{Name: "delivery1", Event: "user.updated", Body: `{"action":"update-user"}`}
+
+	statusCode, response, err := sendWebhook(webhook, delivery)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "done", response)
+	assert.Equal(t, getWebhookSignature("secret", timestamp, delivery.Body), signature)
+
+	webhook.Url = server.URL + "/fail"
+	statusCode, _, err = sendWebhook(webhook, delivery)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, statusCode)
+}
@@ -0,0 +1,331 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provision
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	userSchema    = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema   = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	patchOpSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	pageSize      = 100
+)
+
+// Client is a SCIM 2.0 client that pushes users and group memberships to a downstream application
+type Client struct {
+	Endpoint   string
+	Token      string
+	HttpClient *http.Client
+}
+
+type Error struct {
+	StatusCode int
+	Detail     string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("SCIM request failed with status %d: %s", e.StatusCode, e.Detail)
+}
+
+type listResponse struct {
+	TotalResults int                      `json:"totalResults"`
+	Resources    []map[string]interface{} `json:"Resources"`
+}
+
+func NewClient(endpoint string, token string) *Client {
+	return &Client{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		Token:      token,
+		HttpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *Client) do(method string, path string, body interface{}, res interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		bytesBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bytesBody)
+	}
+
+	req, err := http.NewRequest(method, c.Endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/scim+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/scim+json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var scimError struct {
+			Detail string `json:"detail"`
+		}
+		_ = json.Unmarshal(respBody, &scimError)
+		if scimError.Detail == "" {
+			scimError.Detail = string(respBody)
+		}
+		return &Error{StatusCode: resp.StatusCode, Detail: scimError.Detail}
+	}
+
+	if res != nil && len(respBody) != 0 {
+		return json.Unmarshal(respBody, res)
+	}
+	return nil
+}
+
+func (c *Client) find(resourceType string, filter string) (map[string]interface{}, error) {
+	var res listResponse
+	err := c.do(http.MethodGet, fmt.Sprintf("/%s?filter=%s", resourceType, url.QueryEscape(filter)), nil, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Resources) == 0 {
+		return nil, nil
+	}
+	return res.Resources[0], nil
+}
+
+func (c *Client) list(resourceType string, filter string) ([]map[string]interface{}, error) {
+	resources := []map[string]interface{}{}
+	for startIndex := 1; ; startIndex += pageSize {
+		path := fmt.Sprintf("/%s?startIndex=%d&count=%d", resourceType, startIndex, pageSize)
+		if filter != "" {
+			path += "&filter=" + url.QueryEscape(filter)
+		}
+
+		var res listResponse
+		err := c.do(http.MethodGet, path, nil, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, res.Resources...)
+		if len(res.Resources) == 0 || len(resources) >= res.TotalResults {
+			return resources, nil
+		}
+	}
+}
+
+// FindUser looks the user up by the external id first and by the userName for users created before the provisioning
+func (c *Client) FindUser(externalId string, userName string) (map[string]interface{}, error) {
+	user, err := c.find("Users", fmt.Sprintf("externalId eq %s", strconv.Quote(externalId)))
+	if err != nil || user != nil || userName == "" {
+		return user, err
+	}
+	return c.find("Users", fmt.Sprintf("userName eq %s", strconv.Quote(userName)))
+}
+
+func (c *Client) ListUsers() ([]map[string]interface{}, error) {
+	return c.list("Users", "")
+}
+
+func (c *Client) CreateUser(user map[string]interface{}) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := c.do(http.MethodPost, "/Users", user, &res)
+	return res, err
+}
+
+func (c *Client) ReplaceUser(id string, user map[string]interface{}) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := c.do(http.MethodPut, "/Users/"+url.PathEscape(id), user, &res)
+	return res, err
+}
+
+func (c *Client) DeleteUser(id string) error {
+	err := c.do(http.MethodDelete, "/Users/"+url.PathEscape(id), nil, nil)
+	if e, ok := err.(*Error); ok && e.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) FindGroup(displayName string) (map[string]interface{}, error) {
+	return c.find("Groups", fmt.Sprintf("displayName eq %s", strconv.Quote(displayName)))
+}
+
+func (c *Client) CreateGroup(displayName string) (map[string]interface{}, error) {
+	group := map[string]interface{}{
+		"schemas":     []string{groupSchema},
+		"displayName": displayName,
+	}
+
+	var res map[string]interface{}
+	err := c.do(http.MethodPost, "/Groups", group, &res)
+	return res, err
+}
+
+func (c *Client) GetGroupsOfMember(memberId string) ([]map[string]interface{}, error) {
+	return c.list("Groups", fmt.Sprintf("members[value eq %s]", strconv.Quote(memberId)))
+}
+
+func (c *Client) PatchGroupMember(groupId string, op string, memberId string) error {
+	operation := map[string]interface{}{"op": op}
+	if op == "remove" {
+		operation["path"] = fmt.Sprintf("members[value eq %s]", strconv.Quote(memberId))
+	} else {
+		operation["path"] = "members"
+		operation["value"] = []interface{}{map[string]interface{}{"value": memberId}}
+	}
+
+	patch := map[string]interface{}{
+		"schemas":    []string{patchOpSchema},
+		"Operations": []interface{}{operation},
+	}
+	return c.do(http.MethodPatch, "/Groups/"+url.PathEscape(groupId), patch, nil)
+}
+
+// UpsertUser creates the user or replaces it when it already exists downstream, and returns its downstream id and
+// whether it was created by this call
+func (c *Client) UpsertUser(externalId string, user map[string]interface{}) (string, bool, error) {
+	userName, _ := user["userName"].(string)
+	existing, err := c.FindUser(externalId, userName)
+	if err != nil {
+		return "", false, err
+	}
+
+	var res map[string]interface{}
+	if existing == nil {
+		res, err = c.CreateUser(user)
+	} else {
+		res, err = c.ReplaceUser(getId(existing), user)
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	id := getId(res)
+	if id == "" && existing != nil {
+		id = getId(existing)
+	}
+	return id, existing == nil, nil
+}
+
+// SyncGroupMemberships makes the downstream user a member of the groups with the given display names, the groups
+// are created downstream when they don't exist. Only the memberships in managedGroupIds, the ones added by earlier
+// syncs, are removed, so the memberships managed downstream are kept. It returns the managed memberships after the
+// sync, the memberships that may still exist are kept in it when the sync fails
+func (c *Client) SyncGroupMemberships(memberId string, groupNames []string, managedGroupIds []string) ([]string, error) {
+	currentGroups, err := c.GetGroupsOfMember(memberId)
+	if err != nil {
+		return managedGroupIds, err
+	}
+
+	wasManaged := map[string]bool{}
+	for _, groupId := range managedGroupIds {
+		wasManaged[groupId] = true
+	}
+
+	// the memberships removed downstream in the meantime aren't managed anymore
+	managed := map[string]bool{}
+	for _, group := range currentGroups {
+		if groupId := getId(group); wasManaged[groupId] {
+			managed[groupId] = true
+		}
+	}
+
+	wanted := map[string]bool{}
+	for _, groupName := range groupNames {
+		wanted[groupName] = true
+	}
+
+	for _, group := range currentGroups {
+		groupId := getId(group)
+		displayName, _ := group["displayName"].(string)
+		if wanted[displayName] {
+			delete(wanted, displayName)
+			continue
+		}
+		if !managed[groupId] {
+			continue
+		}
+
+		err = c.PatchGroupMember(groupId, "remove", memberId)
+		if err != nil {
+			return getManagedGroupIds(managed), err
+		}
+		delete(managed, groupId)
+	}
+
+	for groupName := range wanted {
+		group, err := c.FindGroup(groupName)
+		if err != nil {
+			return getManagedGroupIds(managed), err
+		}
+		if group == nil {
+			group, err = c.CreateGroup(groupName)
+			if err != nil {
+				return getManagedGroupIds(managed), err
+			}
+		}
+
+		err = c.PatchGroupMember(getId(group), "add", memberId)
+		if err != nil {
+			return getManagedGroupIds(managed), err
+		}
+		managed[getId(group)] = true
+	}
+	return getManagedGroupIds(managed), nil
+}
+
+func getManagedGroupIds(managed map[string]bool) []string {
+	res := []string{}
+	for groupId := range managed {
+		res = append(res, groupId)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func getId(resource map[string]interface{}) string {
+	if resource == nil {
+		return ""
+	}
+	id, _ := resource["id"].(string)
+	return id
+}
+
+func GetExternalId(resource map[string]interface{}) string {
+	externalId, _ := resource["externalId"].(string)
+	return externalId
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provision
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scimStub is a minimal in-memory SCIM service provider supporting the requests sent by the client
+type scimStub struct {
+	mutex  sync.Mutex
+	nextId int
+	users  map[string]map[string]interface{}
+	groups map[string]map[string]interface{}
+}
+
+var stubFilterRegex = regexp.MustCompile(`^(\w+)(?:\[value eq "(.*)"\]| eq "(.*)")$`)
+
+func newScimStub() *scimStub {
+	return &scimStub{users: map[string]map[string]interface{}{}, groups: map[string]map[string]interface{}{}}
+}
+
+func (s *scimStub) match(resource map[string]interface{}, filter string) bool {
+	if filter == "" {
+		return true
+	}
+
+	tokens := stubFilterRegex.FindStringSubmatch(filter)
+	if tokens == nil {
+		return false
+	}
+	if tokens[1] == "members" {
+		for _, member := range resource["members"].([]interface{}) {
+			if member.(map[string]interface{})["value"] == tokens[2] {
+				return true
+			}
+		}
+		return false
+	}
+	return resource[tokens[1]] == tokens[3]
+}
+
+func (s *scimStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"detail": "unauthorized"}`))
+		return
+	}
+
+	tokens := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	store := s.users
+	if tokens[0] == "Groups" {
+		store = s.groups
+	}
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	write := func(status int, v interface{}) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(tokens) == 1:
+		resources := []map[string]interface{}{}
+		for _, resource := range store {
+			if s.match(resource, r.URL.Query().Get("filter")) {
+				resources = append(resources, resource)
+			}
+		}
+		write(http.StatusOK, map[string]interface{}{"totalResults": len(resources), "Resources": resources})
+	case r.Method == http.MethodPost && len(tokens) == 1:
+		s.nextId++
+		body["id"] = fmt.Sprintf("%d", s.nextId)
+		if tokens[0] == "Groups" {
+			body["members"] = []interface{}{}
+		}
+		store[body["id"].(string)] = body
+		write(http.StatusCreated, body)
+	case r.Method == http.MethodPut && store[tokens[1]] != nil:
+		body["id"] = tokens[1]
+		store[tokens[1]] = body
+		write(http.StatusOK, body)
+	case r.Method == http.MethodDelete && store[tokens[1]] != nil:
+		delete(store, tokens[1])
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPatch && store[tokens[1]] != nil:
+		group := store[tokens[1]]
+		operation := body["Operations"].([]interface{})[0].(map[string]interface{})
+		members := group["members"].([]interface{})
+		if operation["op"] == "add" {
+			members = append(members, operation["value"].([]interface{})...)
+		} else {
+			res := []interface{}{}
+			for _, member := range members {
+				if !s.match(map[string]interface{}{"members": []interface{}{member}}, operation["path"].(string)) {
+					res = append(res, member)
+				}
+			}
+			members = res
+		}
+		group["members"] = members
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		write(http.StatusNotFound, map[string]interface{}{"detail": "not found"})
+	}
+}
+
+func TestClient(t *testing.T) {
+	stub := newScimStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "secret")
+
+	fields := map[string]interface{}{
+		"name":        "alice",
+		"displayName": "Alice",
+		"email":       "alice@example.com",
+		"active":      true,
+		"properties":  map[string]interface{}{"department": "R&D"},
+	}
+	mappings := append(DefaultMappings, &Mapping{ScimAttribute: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", UserField: "properties.department"})
+	user := BuildUser("uuid-1", fields, mappings)
+	assert.Equal(t, "R&D", user["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"].(map[string]interface{})["department"])
+	assert.Nil(t, user["phoneNumbers"])
+
+	id, isCreated, err := client.UpsertUser("uuid-1", user)
+	assert.Nil(t, err)
+	assert.True(t, isCreated)
+	assert.Equal(t, 1, len(stub.users))
+
+	// the second upsert replaces the user found by its external id
+	fields["displayName"] = "Alice Smith"
+	sameId, isCreated, err := client.UpsertUser("uuid-1", BuildUser("uuid-1", fields, nil))
+	assert.Nil(t, err)
+	assert.False(t, isCreated)
+	assert.Equal(t, id, sameId)
+	assert.Equal(t, "Alice Smith", stub.users[id]["displayName"])
+
+	// a membership added downstream is not managed by Casdoor and is kept
+	downstreamGroup, err := client.CreateGroup("downstream")
+	assert.Nil(t, err)
+	assert.Nil(t, client.PatchGroupMember(getId(downstreamGroup), "add", id))
+
+	managedGroupIds, err := client.SyncGroupMemberships(id, []string{"admins", "staff"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(managedGroupIds))
+	groups, err := client.GetGroupsOfMember(id)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(groups))
+
+	managedGroupIds, err = client.SyncGroupMemberships(id, []string{"staff"}, managedGroupIds)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(managedGroupIds))
+	groups, err = client.GetGroupsOfMember(id)
+	assert.Nil(t, err)
+	groupNames := []string{}
+	for _, group := range groups {
+		groupNames = append(groupNames, group["displayName"].(string))
+	}
+	assert.ElementsMatch(t, []string{"downstream", "staff"}, groupNames)
+
+	users, err := client.ListUsers()
+	assert.Nil(t, err)
+	assert.Equal(t, "uuid-1", GetExternalId(users[0]))
+
+	err = client.DeleteUser(id)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(stub.users))
+	assert.Nil(t, client.DeleteUser(id))
+
+	_, err = NewClient(server.URL, "wrong").ListUsers()
+	assert.Equal(t, http.StatusUnauthorized, err.(*Error).StatusCode)
+}
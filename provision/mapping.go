@@ -0,0 +1,115 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provision
+
+import (
+	"strings"
+)
+
+// Mapping maps a field of the Casdoor user (its JSON name, "properties.xxx" for a property)
+// to a SCIM attribute path like "name.givenName" or "urn:...:enterprise:2.0:User:department"
+type Mapping struct {
+	ScimAttribute string `json:"scimAttribute"`
+	UserField     string `json:"userField"`
+}
+
+var DefaultMappings = []*Mapping{
+	{ScimAttribute: "userName", UserField: "name"},
+	{ScimAttribute: "displayName", UserField: "displayName"},
+	{ScimAttribute: "name.givenName", UserField: "firstName"},
+	{ScimAttribute: "name.familyName", UserField: "lastName"},
+	{ScimAttribute: "emails", UserField: "email"},
+	{ScimAttribute: "phoneNumbers", UserField: "phone"},
+	{ScimAttribute: "title", UserField: "title"},
+	{ScimAttribute: "preferredLanguage", UserField: "language"},
+	{ScimAttribute: "active", UserField: "active"},
+}
+
+var multiValuedTypes = map[string]string{
+	"emails":       "work",
+	"phoneNumbers": "work",
+	"photos":       "photo",
+}
+
+func getField(fields map[string]interface{}, path string) interface{} {
+	tokens := strings.SplitN(path, ".", 2)
+	value, ok := fields[tokens[0]]
+	if !ok || len(tokens) == 1 {
+		return value
+	}
+
+	if m, ok := value.(map[string]interface{}); ok {
+		return getField(m, tokens[1])
+	}
+	return nil
+}
+
+func setAttribute(resource map[string]interface{}, path string, value interface{}) {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		i := strings.LastIndex(path, ":")
+		schema := path[:i]
+		if schema != userSchema {
+			extension, ok := resource[schema].(map[string]interface{})
+			if !ok {
+				extension = map[string]interface{}{}
+				resource[schema] = extension
+				resource["schemas"] = append(resource["schemas"].([]string), schema)
+			}
+			setAttribute(extension, path[i+1:], value)
+			return
+		}
+		path = path[i+1:]
+	}
+
+	tokens := strings.SplitN(path, ".", 2)
+	if len(tokens) == 2 {
+		child, ok := resource[tokens[0]].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			resource[tokens[0]] = child
+		}
+		setAttribute(child, tokens[1], value)
+		return
+	}
+
+	if valueType, ok := multiValuedTypes[path]; ok {
+		value = []interface{}{map[string]interface{}{"value": value, "type": valueType, "primary": true}}
+	}
+	resource[path] = value
+}
+
+// BuildUser builds the SCIM user from the fields of a Casdoor user, empty fields are left out
+func BuildUser(externalId string, fields map[string]interface{}, mappings []*Mapping) map[string]interface{} {
+	if len(mappings) == 0 {
+		mappings = DefaultMappings
+	}
+
+	resource := map[string]interface{}{
+		"schemas":    []string{userSchema},
+		"externalId": externalId,
+	}
+	for _, mapping := range mappings {
+		if mapping.ScimAttribute == "" || mapping.UserField == "" {
+			continue
+		}
+
+		value := getField(fields, mapping.UserField)
+		if value == nil || value == "" {
+			continue
+		}
+		setAttribute(resource, mapping.ScimAttribute, value)
+	}
+	return resource
+}
@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/telemetry"
 	"golang.org/x/net/proxy"
 )
 
@@ -33,7 +34,7 @@ var (
 
 func InitHttpClient() {
 	// not use proxy
-	DefaultHttpClient = http.DefaultClient
+	DefaultHttpClient = &http.Client{Transport: telemetry.NewTransport(http.DefaultTransport, nil)}
 
 	// use proxy
 	ProxyHttpClient = getProxyHttpClient()
@@ -59,11 +60,11 @@ func isAddressOpen(address string) bool {
 func getProxyHttpClient() *http.Client {
 	socks5Proxy := conf.GetConfigString("socks5Proxy")
 	if socks5Proxy == "" {
-		return &http.Client{}
+		return &http.Client{Transport: telemetry.NewTransport(http.DefaultTransport, nil)}
 	}
 
 	if !isAddressOpen(socks5Proxy) {
-		return &http.Client{}
+		return &http.Client{Transport: telemetry.NewTransport(http.DefaultTransport, nil)}
 	}
 
 	// https://stackoverflow.com/questions/33585587/creating-a-go-socks5-client
@@ -74,7 +75,7 @@ func getProxyHttpClient() *http.Client {
 
 	tr := &http.Transport{Dial: dialer.Dial, TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
 	return &http.Client{
-		Transport: tr,
+		Transport: telemetry.NewTransport(tr, nil),
 	}
 }
 
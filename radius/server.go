@@ -39,15 +39,40 @@ func StartRadiusServer() {
 	}
 }
 
+// observedResponseWriter keeps the code of the response for the request counter
+type observedResponseWriter struct {
+	radius.ResponseWriter
+	code radius.Code
+}
+
+func (w *observedResponseWriter) Write(packet *radius.Packet) error {
+	w.code = packet.Code
+	return w.ResponseWriter.Write(packet)
+}
+
 func handlerRadius(w radius.ResponseWriter, r *radius.Request) {
+	ow := &observedResponseWriter{ResponseWriter: w}
 	switch r.Code {
 	case radius.CodeAccessRequest:
-		handleAccessRequest(w, r)
+		handleAccessRequest(ow, r)
 	case radius.CodeAccountingRequest:
-		handleAccountingRequest(w, r)
+		handleAccountingRequest(ow, r)
 	default:
 		log.Printf("radius message, code = %d", r.Code)
+		object.RadiusRequestCounter.WithLabelValues("other", "unsupported").Inc()
+		return
+	}
+
+	result := "none"
+	switch ow.code {
+	case radius.CodeAccessAccept:
+		result = "accept"
+	case radius.CodeAccessReject:
+		result = "reject"
+	case radius.CodeAccountingResponse:
+		result = "success"
 	}
+	object.RadiusRequestCounter.WithLabelValues(r.Code.String(), result).Inc()
 }
 
 func handleAccessRequest(w radius.ResponseWriter, r *radius.Request) {
@@ -147,6 +147,11 @@ func getUrlPath(urlPath string) string {
 		return "/api/webauthn"
 	}
 
+	// the SCIM server authenticates the requests with the bearer token of the organization
+	if strings.HasPrefix(urlPath, "/scim/") {
+		return "/scim"
+	}
+
 	return urlPath
 }
 
@@ -55,11 +55,8 @@ func getUserByClientIdSecret(ctx *context.Context) string {
 	return util.GetId(application.Organization, application.Name)
 }
 
+// RecordMessage prepares the record before the request is handled, so that the user is the one before a logout
 func RecordMessage(ctx *context.Context) {
-	if ctx.Request.URL.Path == "/api/login" || ctx.Request.URL.Path == "/api/signup" {
-		return
-	}
-
 	record := object.NewRecord(ctx)
 
 	userId := getUser(ctx)
@@ -67,5 +64,32 @@ func RecordMessage(ctx *context.Context) {
 		record.Organization, record.User = util.GetOwnerAndNameFromId(userId)
 	}
 
+	ctx.Input.SetData("record", record)
+}
+
+// AfterRecordMessage adds the prepared record with the response status of the API as its result
+func AfterRecordMessage(ctx *context.Context) {
+	record, ok := ctx.Input.GetData("record").(*object.Record)
+	if !ok {
+		return
+	}
+
+	if result, ok := ctx.Input.GetData("responseStatus").(string); ok {
+		record.Result = result
+	}
+	if msg, ok := ctx.Input.GetData("responseMsg").(string); ok && record.Result == "error" {
+		record.Reason = msg
+	}
+
+	// a successful login or signup is recorded by the controller with the signed-in user
+	if (ctx.Request.URL.Path == "/api/login" || ctx.Request.URL.Path == "/api/signup") && record.Result == "ok" {
+		return
+	}
+
+	// the changed fields are set by the controller of an update
+	if diff, ok := ctx.Input.GetData("recordDiff").(string); ok && record.Result == "ok" {
+		record.Diff = diff
+	}
+
 	util.SafeGoroutine(func() { object.AddRecord(record) })
 }
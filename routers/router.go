@@ -26,6 +26,7 @@ package routers
 import (
 	"github.com/beego/beego"
 	"github.com/casdoor/casdoor/controllers"
+	"github.com/casdoor/casdoor/scim"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -50,8 +51,11 @@ func initAPI() {
 
 	beego.Router("/api/signup", &controllers.ApiController{}, "POST:Signup")
 	beego.Router("/api/login", &controllers.ApiController{}, "POST:Login")
+	beego.Router("/api/send-magic-link", &controllers.ApiController{}, "POST:SendMagicLink")
+	beego.Router("/api/login/magic-link", &controllers.ApiController{}, "GET:MagicLinkLogin")
 	beego.Router("/api/get-app-login", &controllers.ApiController{}, "GET:GetApplicationLogin")
 	beego.Router("/api/get-dashboard", &controllers.ApiController{}, "GET:GetDashboard")
+	beego.Router("/api/get-dashboard-stats", &controllers.ApiController{}, "GET:GetDashboardStats")
 	beego.Router("/api/logout", &controllers.ApiController{}, "GET,POST:Logout")
 	beego.Router("/api/get-account", &controllers.ApiController{}, "GET:GetAccount")
 	beego.Router("/api/userinfo", &controllers.ApiController{}, "GET:GetUserinfo")
@@ -77,6 +81,7 @@ func initAPI() {
 	beego.Router("/api/get-users", &controllers.ApiController{}, "GET:GetUsers")
 	beego.Router("/api/get-sorted-users", &controllers.ApiController{}, "GET:GetSortedUsers")
 	beego.Router("/api/get-user-count", &controllers.ApiController{}, "GET:GetUserCount")
+	beego.Router("/api/get-user-password-type-counts", &controllers.ApiController{}, "GET:GetUserPasswordTypeCounts")
 	beego.Router("/api/get-user", &controllers.ApiController{}, "GET:GetUser")
 	beego.Router("/api/update-user", &controllers.ApiController{}, "POST:UpdateUser")
 	beego.Router("/api/add-user-keys", &controllers.ApiController{}, "POST:AddUserkeys")
@@ -109,6 +114,8 @@ func initAPI() {
 
 	beego.Router("/api/enforce", &controllers.ApiController{}, "POST:Enforce")
 	beego.Router("/api/batch-enforce", &controllers.ApiController{}, "POST:BatchEnforce")
+	beego.Router("/api/explain-enforce", &controllers.ApiController{}, "POST:ExplainEnforce")
+	beego.Router("/api/simulate-policy-change", &controllers.ApiController{}, "POST:SimulatePolicyChange")
 	beego.Router("/api/get-all-objects", &controllers.ApiController{}, "GET:GetAllObjects")
 	beego.Router("/api/get-all-actions", &controllers.ApiController{}, "GET:GetAllActions")
 	beego.Router("/api/get-all-roles", &controllers.ApiController{}, "GET:GetAllRoles")
@@ -135,11 +142,52 @@ func initAPI() {
 	beego.Router("/api/add-enforcer", &controllers.ApiController{}, "POST:AddEnforcer")
 	beego.Router("/api/delete-enforcer", &controllers.ApiController{}, "POST:DeleteEnforcer")
 
+	beego.Router("/api/get-policy-versions", &controllers.ApiController{}, "GET:GetPolicyVersions")
+	beego.Router("/api/get-policy-version", &controllers.ApiController{}, "GET:GetPolicyVersion")
+	beego.Router("/api/diff-policy-versions", &controllers.ApiController{}, "GET:DiffPolicyVersions")
+	beego.Router("/api/rollback-enforcer", &controllers.ApiController{}, "POST:RollbackEnforcer")
+
 	beego.Router("/api/set-password", &controllers.ApiController{}, "POST:SetPassword")
 	beego.Router("/api/check-user-password", &controllers.ApiController{}, "POST:CheckUserPassword")
 	beego.Router("/api/get-email-and-phone", &controllers.ApiController{}, "GET:GetEmailAndPhone")
 	beego.Router("/api/send-verification-code", &controllers.ApiController{}, "POST:SendVerificationCode")
 	beego.Router("/api/verify-code", &controllers.ApiController{}, "POST:VerifyCode")
+	beego.Router("/api/recover-account", &controllers.ApiController{}, "POST:RecoverAccount")
+	beego.Router("/api/complete-account-recovery", &controllers.ApiController{}, "POST:CompleteAccountRecovery")
+	beego.Router("/api/get-security-questions", &controllers.ApiController{}, "GET:GetSecurityQuestions")
+	beego.Router("/api/set-security-questions", &controllers.ApiController{}, "POST:SetSecurityQuestions")
+	beego.Router("/api/get-recovery-requests", &controllers.ApiController{}, "GET:GetRecoveryRequests")
+	beego.Router("/api/get-recovery-request", &controllers.ApiController{}, "GET:GetRecoveryRequest")
+	beego.Router("/api/review-recovery-request", &controllers.ApiController{}, "POST:ReviewRecoveryRequest")
+
+	beego.Router("/api/get-assignments", &controllers.ApiController{}, "GET:GetAssignments")
+	beego.Router("/api/get-assignment", &controllers.ApiController{}, "GET:GetAssignment")
+	beego.Router("/api/add-assignment", &controllers.ApiController{}, "POST:AddAssignment")
+	beego.Router("/api/request-assignment", &controllers.ApiController{}, "POST:RequestAssignment")
+	beego.Router("/api/review-assignment", &controllers.ApiController{}, "POST:ReviewAssignment")
+	beego.Router("/api/revoke-assignment", &controllers.ApiController{}, "POST:RevokeAssignment")
+
+	beego.Router("/api/get-approval-requests", &controllers.ApiController{}, "GET:GetApprovalRequests")
+	beego.Router("/api/get-pending-approval-requests", &controllers.ApiController{}, "GET:GetPendingApprovalRequests")
+	beego.Router("/api/get-approval-request", &controllers.ApiController{}, "GET:GetApprovalRequest")
+	beego.Router("/api/submit-approval-request", &controllers.ApiController{}, "POST:SubmitApprovalRequest")
+	beego.Router("/api/review-approval-request", &controllers.ApiController{}, "POST:ReviewApprovalRequest")
+	beego.Router("/api/cancel-approval-request", &controllers.ApiController{}, "POST:CancelApprovalRequest")
+
+	beego.Router("/api/get-access-reviews", &controllers.ApiController{}, "GET:GetAccessReviews")
+	beego.Router("/api/get-access-review", &controllers.ApiController{}, "GET:GetAccessReview")
+	beego.Router("/api/update-access-review", &controllers.ApiController{}, "POST:UpdateAccessReview")
+	beego.Router("/api/add-access-review", &controllers.ApiController{}, "POST:AddAccessReview")
+	beego.Router("/api/delete-access-review", &controllers.ApiController{}, "POST:DeleteAccessReview")
+	beego.Router("/api/start-access-review", &controllers.ApiController{}, "POST:StartAccessReview")
+	beego.Router("/api/close-access-review", &controllers.ApiController{}, "POST:CloseAccessReview")
+	beego.Router("/api/export-access-review", &controllers.ApiController{}, "GET:ExportAccessReview")
+	beego.Router("/api/get-access-review-items", &controllers.ApiController{}, "GET:GetAccessReviewItems")
+	beego.Router("/api/get-reviewer-access-review-items", &controllers.ApiController{}, "GET:GetReviewerAccessReviewItems")
+	beego.Router("/api/decide-access-review-item", &controllers.ApiController{}, "POST:DecideAccessReviewItem")
+
+	beego.Router("/api/get-provisioning-tasks", &controllers.ApiController{}, "GET:GetProvisioningTasks")
+	beego.Router("/api/reconcile-provisioning", &controllers.ApiController{}, "POST:ReconcileProvisioning")
 	beego.Router("/api/verify-captcha", &controllers.ApiController{}, "POST:VerifyCaptcha")
 	beego.Router("/api/reset-email-or-phone", &controllers.ApiController{}, "POST:ResetEmailOrPhone")
 	beego.Router("/api/get-captcha", &controllers.ApiController{}, "GET:GetCaptcha")
@@ -189,12 +237,31 @@ func initAPI() {
 	beego.Router("/api/add-session", &controllers.ApiController{}, "POST:AddSession")
 	beego.Router("/api/delete-session", &controllers.ApiController{}, "POST:DeleteSession")
 	beego.Router("/api/is-session-duplicated", &controllers.ApiController{}, "GET:IsSessionDuplicated")
+	beego.Router("/api/get-signin-activities", &controllers.ApiController{}, "GET:GetSigninActivities")
+	beego.Router("/api/get-signin-devices", &controllers.ApiController{}, "GET:GetSigninDevices")
+	beego.Router("/api/revoke-signin-activity", &controllers.ApiController{}, "POST:RevokeSigninActivity")
+	beego.Router("/api/revoke-signin-device", &controllers.ApiController{}, "POST:RevokeSigninDevice")
+
+	beego.Router("/api/get-records", &controllers.ApiController{}, "GET:GetRecords")
+	beego.Router("/api/get-record", &controllers.ApiController{}, "GET:GetRecord")
+	beego.Router("/api/get-record-checkpoints", &controllers.ApiController{}, "GET:GetRecordCheckpoints")
+	beego.Router("/api/verify-record-chain", &controllers.ApiController{}, "GET:VerifyRecordChain")
+	beego.Router("/api/export-records", &controllers.ApiController{}, "GET:ExportRecords")
 
 	beego.Router("/api/get-webhooks", &controllers.ApiController{}, "GET:GetWebhooks")
 	beego.Router("/api/get-webhook", &controllers.ApiController{}, "GET:GetWebhook")
 	beego.Router("/api/update-webhook", &controllers.ApiController{}, "POST:UpdateWebhook")
 	beego.Router("/api/add-webhook", &controllers.ApiController{}, "POST:AddWebhook")
 	beego.Router("/api/delete-webhook", &controllers.ApiController{}, "POST:DeleteWebhook")
+	beego.Router("/api/get-webhook-deliveries", &controllers.ApiController{}, "GET:GetWebhookDeliveries")
+	beego.Router("/api/get-webhook-delivery", &controllers.ApiController{}, "GET:GetWebhookDelivery")
+	beego.Router("/api/redeliver-webhook", &controllers.ApiController{}, "POST:RedeliverWebhook")
+
+	beego.Router("/api/get-event-sinks", &controllers.ApiController{}, "GET:GetEventSinks")
+	beego.Router("/api/get-event-sink", &controllers.ApiController{}, "GET:GetEventSink")
+	beego.Router("/api/update-event-sink", &controllers.ApiController{}, "POST:UpdateEventSink")
+	beego.Router("/api/add-event-sink", &controllers.ApiController{}, "POST:AddEventSink")
+	beego.Router("/api/delete-event-sink", &controllers.ApiController{}, "POST:DeleteEventSink")
 
 	beego.Router("/api/get-syncers", &controllers.ApiController{}, "GET:GetSyncers")
 	beego.Router("/api/get-syncer", &controllers.ApiController{}, "GET:GetSyncer")
@@ -265,6 +332,7 @@ func initAPI() {
 	beego.Router("/api/get-prometheus-info", &controllers.ApiController{}, "GET:GetPrometheusInfo")
 
 	beego.Handler("/api/metrics", promhttp.Handler())
+	beego.Handler(scim.BasePath, scim.Server, true)
 
 	beego.Router("/.well-known/openid-configuration", &controllers.RootController{}, "GET:GetOidcDiscovery")
 	beego.Router("/.well-known/jwks", &controllers.RootController{}, "*:GetJwks")
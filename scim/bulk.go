@@ -0,0 +1,159 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type BulkOperation struct {
+	Method  string          `json:"method"`
+	BulkId  string          `json:"bulkId,omitempty"`
+	Version string          `json:"version,omitempty"`
+	Path    string          `json:"path"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type BulkRequest struct {
+	Schemas      []string         `json:"schemas"`
+	FailOnErrors int              `json:"failOnErrors"`
+	Operations   []*BulkOperation `json:"Operations"`
+}
+
+type BulkOperationResponse struct {
+	Method   string      `json:"method"`
+	BulkId   string      `json:"bulkId,omitempty"`
+	Version  string      `json:"version,omitempty"`
+	Location string      `json:"location,omitempty"`
+	Status   string      `json:"status"`
+	Response interface{} `json:"response,omitempty"`
+}
+
+type BulkResponse struct {
+	Schemas    []string                 `json:"schemas"`
+	Operations []*BulkOperationResponse `json:"Operations"`
+}
+
+// serveBulk runs the operations in order, "bulkId:<id>" in a later path or data refers to a resource created before
+func (s *session) serveBulk(w http.ResponseWriter, r *http.Request) {
+	var bulkRequest BulkRequest
+	if err := readJson(r, &bulkRequest); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if len(bulkRequest.Operations) > maxBulkOperations {
+		writeError(w, newError(http.StatusRequestEntityTooLarge, "", "a bulk request can contain at most %d operations", maxBulkOperations))
+		return
+	}
+
+	res := &BulkResponse{Schemas: []string{BulkResponseSchema}, Operations: []*BulkOperationResponse{}}
+	bulkIds := map[string]string{}
+	errorCount := 0
+	for _, operation := range bulkRequest.Operations {
+		if bulkRequest.FailOnErrors > 0 && errorCount >= bulkRequest.FailOnErrors {
+			break
+		}
+
+		operationResponse := s.runBulkOperation(operation, bulkIds)
+		if status, _ := strconv.Atoi(operationResponse.Status); status >= http.StatusBadRequest {
+			errorCount++
+		}
+		res.Operations = append(res.Operations, operationResponse)
+	}
+
+	writeJson(w, http.StatusOK, "", res)
+}
+
+func replaceBulkIds(s string, bulkIds map[string]string) string {
+	for bulkId, id := range bulkIds {
+		s = strings.ReplaceAll(s, "bulkId:"+bulkId, id)
+	}
+	return s
+}
+
+func (s *session) runBulkOperation(operation *BulkOperation, bulkIds map[string]string) *BulkOperationResponse {
+	method := strings.ToUpper(operation.Method)
+	res := &BulkOperationResponse{Method: method, BulkId: operation.BulkId}
+
+	fail := func(err error) *BulkOperationResponse {
+		e := toError(err)
+		res.Status = strconv.Itoa(e.Status)
+		res.Response = e.toResponse()
+		return res
+	}
+
+	path := strings.Trim(replaceBulkIds(operation.Path, bulkIds), "/")
+	tokens := strings.SplitN(path, "/", 2)
+	resourceType := tokens[0]
+	id := ""
+	if len(tokens) == 2 {
+		id = tokens[1]
+	}
+	if resourceType != "Users" && resourceType != "Groups" {
+		return fail(newError(http.StatusBadRequest, "invalidPath", "the path: %s is not supported in a bulk request", operation.Path))
+	}
+
+	data := []byte(replaceBulkIds(string(operation.Data), bulkIds))
+	if strings.Contains(string(data), "bulkId:") {
+		return fail(newError(http.StatusConflict, "invalidValue", "the data refers to a bulkId that is not created yet"))
+	}
+
+	var resource map[string]interface{}
+	var err error
+	status := http.StatusOK
+	switch {
+	case method == http.MethodPost && id == "":
+		var body map[string]interface{}
+		if err = json.Unmarshal(data, &body); err == nil {
+			resource, err = s.create(resourceType, body)
+			status = http.StatusCreated
+		}
+	case method == http.MethodPut && id != "":
+		var body map[string]interface{}
+		if err = json.Unmarshal(data, &body); err == nil {
+			resource, err = s.replace(resourceType, id, body, operation.Version)
+		}
+	case method == http.MethodPatch && id != "":
+		var patchRequest PatchRequest
+		if err = json.Unmarshal(data, &patchRequest); err == nil {
+			resource, err = s.patch(resourceType, id, &patchRequest, operation.Version)
+		}
+	case method == http.MethodDelete && id != "":
+		err = s.delete(resourceType, id, operation.Version)
+		status = http.StatusNoContent
+	default:
+		err = newError(http.StatusMethodNotAllowed, "", "the method: %s is not allowed for the path: %s", method, operation.Path)
+	}
+	if err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			err = newError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error())
+		}
+		return fail(err)
+	}
+
+	res.Status = strconv.Itoa(status)
+	if resource != nil {
+		res.Version = setVersion(resource)
+		res.Location = resource["meta"].(map[string]interface{})["location"].(string)
+		if operation.BulkId != "" {
+			bulkIds[operation.BulkId] = resource["id"].(string)
+		}
+	}
+	return res
+}
@@ -0,0 +1,423 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2)
+type Filter interface {
+	Match(resource map[string]interface{}) bool
+}
+
+type logicalFilter struct {
+	op    string
+	left  Filter
+	right Filter
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+type attributeFilter struct {
+	path  string
+	op    string
+	value interface{}
+}
+
+// valuePathFilter matches a multi-valued attribute such as emails[type eq "work"]
+type valuePathFilter struct {
+	attr   string
+	filter Filter
+}
+
+func (f *logicalFilter) Match(resource map[string]interface{}) bool {
+	if f.op == "and" {
+		return f.left.Match(resource) && f.right.Match(resource)
+	}
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+func (f *notFilter) Match(resource map[string]interface{}) bool {
+	return !f.filter.Match(resource)
+}
+
+func (f *valuePathFilter) Match(resource map[string]interface{}) bool {
+	for _, item := range toList(getAttribute(resource, f.attr)) {
+		if m, ok := item.(map[string]interface{}); ok && f.filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *attributeFilter) Match(resource map[string]interface{}) bool {
+	values := toList(getAttribute(resource, f.path))
+	if f.op == "pr" {
+		for _, value := range values {
+			if value != nil && value != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, value := range values {
+		// a complex multi-valued attribute is compared by its "value" sub-attribute
+		if m, ok := value.(map[string]interface{}); ok {
+			value = getAttribute(m, "value")
+		}
+		if compareValue(value, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareValue(actual interface{}, op string, expected interface{}) bool {
+	switch expectedValue := expected.(type) {
+	case nil:
+		return (op == "eq" && actual == nil) || (op == "ne" && actual != nil)
+	case bool:
+		actualValue, ok := actual.(bool)
+		if !ok {
+			return op == "ne"
+		}
+		return (op == "eq" && actualValue == expectedValue) || (op == "ne" && actualValue != expectedValue)
+	case float64:
+		actualValue, ok := toFloat(actual)
+		if !ok {
+			return op == "ne"
+		}
+		return compareOrdered(op, actualValue < expectedValue, actualValue == expectedValue)
+	case string:
+		actualString, ok := actual.(string)
+		if !ok {
+			return op == "ne"
+		}
+
+		// timestamps are compared chronologically, other strings case-insensitively
+		actualTime, err1 := time.Parse(time.RFC3339, actualString)
+		expectedTime, err2 := time.Parse(time.RFC3339, expectedValue)
+		if err1 == nil && err2 == nil && op != "co" && op != "sw" && op != "ew" {
+			return compareOrdered(op, actualTime.Before(expectedTime), actualTime.Equal(expectedTime))
+		}
+
+		a := strings.ToLower(actualString)
+		e := strings.ToLower(expectedValue)
+		switch op {
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		default:
+			return compareOrdered(op, a < e, a == e)
+		}
+	}
+	return false
+}
+
+func compareOrdered(op string, less bool, equal bool) bool {
+	switch op {
+	case "eq":
+		return equal
+	case "ne":
+		return !equal
+	case "gt":
+		return !less && !equal
+	case "ge":
+		return !less
+	case "lt":
+		return less
+	case "le":
+		return less || equal
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func toList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	case []map[string]interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = v[i]
+		}
+		return res
+	}
+	return []interface{}{value}
+}
+
+// getAttribute reads an attribute path like "name.givenName" or "urn:...:User:department" case-insensitively,
+// sub-attributes of multi-valued attributes are collected into a list
+func getAttribute(resource map[string]interface{}, path string) interface{} {
+	if value, ok := getKey(resource, path); ok {
+		return value
+	}
+
+	// the path can be prefixed with a schema URN
+	if i := strings.LastIndex(path, ":"); i != -1 {
+		if extension, ok := getKey(resource, path[:i]); ok {
+			if m, ok := extension.(map[string]interface{}); ok {
+				return getAttribute(m, path[i+1:])
+			}
+		}
+		return getAttribute(resource, path[i+1:])
+	}
+
+	tokens := strings.SplitN(path, ".", 2)
+	if len(tokens) != 2 {
+		return nil
+	}
+
+	value, ok := getKey(resource, tokens[0])
+	if !ok {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return getAttribute(v, tokens[1])
+	case []interface{}:
+		res := []interface{}{}
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if subValue := getAttribute(m, tokens[1]); subValue != nil {
+					res = append(res, subValue)
+				}
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func getKey(resource map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := resource[key]; ok {
+		return value, true
+	}
+	for k, value := range resource {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+// ParseFilter parses a SCIM filter such as `userName eq "alice" and (emails[type eq "work"] pr or not (active eq false))`
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	res, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token: %s", p.tokens[p.pos])
+	}
+	return res, nil
+}
+
+func tokenizeFilter(filter string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '[' || r == ']':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter: %s", filter)
+			}
+			tokens = append(tokens, string(runes[i:j+1]))
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()[]\"", runes[j]); j++ {
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (Filter, error) {
+	if strings.EqualFold(p.peek(), "not") {
+		p.next()
+		filter, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: filter}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *filterParser) parseAtom() (Filter, error) {
+	token := p.next()
+	if token == "" {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+
+	if token == "(" {
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ) in filter")
+		}
+		return filter, nil
+	}
+
+	attr := token
+	if p.peek() == "[" {
+		p.next()
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != "]" {
+			return nil, fmt.Errorf("missing ] in filter")
+		}
+		return &valuePathFilter{attr: attr, filter: filter}, nil
+	}
+
+	op := strings.ToLower(p.next())
+	switch op {
+	case "pr":
+		return &attributeFilter{path: attr, op: op}, nil
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+		value, err := parseFilterValue(p.next())
+		if err != nil {
+			return nil, err
+		}
+		return &attributeFilter{path: attr, op: op, value: value}, nil
+	}
+	return nil, fmt.Errorf("unknown operator: %s", op)
+}
+
+func parseFilterValue(token string) (interface{}, error) {
+	if strings.HasPrefix(token, "\"") {
+		value, err := strconv.Unquote(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string in filter: %s", token)
+		}
+		return value, nil
+	}
+
+	switch strings.ToLower(token) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value in filter: %s", token)
+	}
+	return value, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/stretchr/testify/assert"
+)
+
+const testUser = `{
+	"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User", "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"],
+	"id": "2819c223",
+	"userName": "alice",
+	"name": {"givenName": "Alice", "familyName": "Smith"},
+	"active": true,
+	"emails": [{"value": "alice@example.com", "type": "work", "primary": true}, {"value": "alice@home.org", "type": "home"}],
+	"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": {"department": "R&D"},
+	"meta": {"lastModified": "2023-05-01T10:00:00Z"}
+}`
+
+func getTestUser(t *testing.T) map[string]interface{} {
+	var resource map[string]interface{}
+	err := json.Unmarshal([]byte(testUser), &resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resource
+}
+
+func TestFilter(t *testing.T) {
+	resource := getTestUser(t)
+
+	scenarios := []struct {
+		filter   string
+		expected bool
+	}{
+		{`userName eq "alice"`, true},
+		{`UserName eq "ALICE"`, true},
+		{`userName ne "alice"`, false},
+		{`name.familyName sw "Sm"`, true},
+		{`emails co "home.org"`, true},
+		{`emails[type eq "work" and value ew "example.com"]`, true},
+		{`emails[type eq "other"]`, false},
+		{`emails.type eq "home"`, true},
+		{`active eq false`, false},
+		{`not (active eq false)`, true},
+		{`title pr`, false},
+		{`title pr or userName pr`, true},
+		{`urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department eq "R&D"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "alice"`, true},
+		{`meta.lastModified gt "2023-01-01T00:00:00Z"`, true},
+		{`meta.lastModified lt "2023-01-01T00:00:00Z"`, false},
+		{`userName eq "bob" or (active eq true and name.givenName eq "Alice")`, true},
+	}
+	for _, scenery := range scenarios {
+		t.Run(scenery.filter, func(t *testing.T) {
+			filter, err := ParseFilter(scenery.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, scenery.expected, filter.Match(resource))
+		})
+	}
+
+	for _, filter := range []string{`userName eq`, `userName xx "a"`, `(userName eq "a"`, `emails[type eq "a"`, `userName eq "a`} {
+		_, err := ParseFilter(filter)
+		assert.NotNil(t, err, filter)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	resource := getTestUser(t)
+
+	operations := []*PatchOperation{
+		{Op: "replace", Path: "active", Value: false},
+		{Op: "Replace", Path: `emails[type eq "work"].value`, Value: "alice@corp.example.com"},
+		{Op: "remove", Path: `emails[type eq "home"]`},
+		{Op: "add", Path: "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:costCenter", Value: "4130"},
+		{Op: "add", Value: map[string]interface{}{"title": "Engineer", "name.givenName": "Ally"}},
+		{Op: "add", Path: "phoneNumbers", Value: []interface{}{map[string]interface{}{"value": "555-0100"}}},
+	}
+	err := ApplyPatch(resource, operations)
+	assert.Nil(t, err)
+
+	assert.Equal(t, false, resource["active"])
+	assert.Equal(t, "alice@corp.example.com", getPrimaryValue(resource, "emails"))
+	assert.Equal(t, 1, len(toList(resource["emails"])))
+	assert.Equal(t, "4130", getString(resource, EnterpriseUserSchema+":costCenter"))
+	assert.Equal(t, "R&D", getString(resource, EnterpriseUserSchema+":department"))
+	assert.Equal(t, "Engineer", resource["title"])
+	assert.Equal(t, "Ally", getString(resource, "name.givenName"))
+	assert.Equal(t, "555-0100", getPrimaryValue(resource, "phoneNumbers"))
+
+	err = ApplyPatch(resource, []*PatchOperation{{Op: "replace", Path: `emails[type eq "missing"].value`, Value: "x"}})
+	assert.NotNil(t, err)
+}
+
+func TestAddUserFilter(t *testing.T) {
+	scenarios := []struct {
+		filter   string
+		expected object.UserFilter
+		isExact  bool
+	}{
+		{`userName eq "alice"`, object.UserFilter{Name: "alice"}, true},
+		{`userName eq "alice" and emails.value eq "alice@example.com"`, object.UserFilter{Name: "alice", Email: "alice@example.com"}, true},
+		{`externalId eq "a1"`, object.UserFilter{Properties: map[string]string{propertyExternalId: "a1"}}, false},
+		{`userName eq "alice" and active eq true`, object.UserFilter{Name: "alice"}, false},
+		{`userName eq "alice" or userName eq "bob"`, object.UserFilter{}, false},
+		{`userName sw "a"`, object.UserFilter{}, false},
+	}
+	for _, scenario := range scenarios {
+		filter, err := ParseFilter(scenario.filter)
+		assert.Nil(t, err)
+
+		userFilter := &object.UserFilter{Properties: map[string]string{}}
+		assert.Equal(t, scenario.isExact, addUserFilter(userFilter, filter), scenario.filter)
+		if scenario.expected.Properties == nil {
+			scenario.expected.Properties = map[string]string{}
+		}
+		assert.Equal(t, scenario.expected, *userFilter, scenario.filter)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+func (s *session) groupToScim(group *object.Group, users []*object.User) map[string]interface{} {
+	members := []interface{}{}
+	for _, user := range users {
+		members = append(members, map[string]interface{}{
+			"value":   user.Id,
+			"display": user.Name,
+			"type":    "User",
+			"$ref":    fmt.Sprintf("%s/Users/%s", s.baseUrl, user.Id),
+		})
+	}
+
+	lastModified := group.UpdatedTime
+	if lastModified == "" {
+		lastModified = group.CreatedTime
+	}
+
+	return map[string]interface{}{
+		"schemas":     []interface{}{GroupSchema},
+		"id":          group.Name,
+		"displayName": group.DisplayName,
+		"members":     members,
+		"meta": map[string]interface{}{
+			"resourceType": "Group",
+			"created":      group.CreatedTime,
+			"lastModified": lastModified,
+			"location":     fmt.Sprintf("%s/Groups/%s", s.baseUrl, group.Name),
+		},
+	}
+}
+
+func getMemberIds(resource map[string]interface{}) []string {
+	ids := []string{}
+	for _, item := range toList(getAttribute(resource, "members")) {
+		if m, ok := item.(map[string]interface{}); ok {
+			if id := getString(m, "value"); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func (s *session) getObjectGroup(id string) (*object.Group, error) {
+	group, err := object.GetGroup(util.GetId(s.organization.Name, id))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, newError(http.StatusNotFound, "", "the group: %s is not found", id)
+	}
+	return group, nil
+}
+
+func (s *session) getGroups() ([]map[string]interface{}, error) {
+	groups, err := object.GetGroups(s.organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []map[string]interface{}{}
+	for _, group := range groups {
+		users, err := object.GetGroupUsers(group.GetId())
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, s.groupToScim(group, users))
+	}
+	return resources, nil
+}
+
+func (s *session) getGroup(id string) (map[string]interface{}, error) {
+	group, err := s.getObjectGroup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := object.GetGroupUsers(group.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return s.groupToScim(group, users), nil
+}
+
+// setGroupMembers adds the group to the users in memberIds and removes it from the other current members
+func (s *session) setGroupMembers(group *object.Group, memberIds []string) error {
+	currentUsers, err := object.GetGroupUsers(group.GetId())
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range memberIds {
+		wanted[id] = true
+	}
+
+	groupId := group.GetId()
+	for _, user := range currentUsers {
+		if wanted[user.Id] {
+			delete(wanted, user.Id)
+			continue
+		}
+
+		user.Groups = util.DeleteVal(user.Groups, groupId)
+		_, err = object.UpdateUser(user.GetId(), user, []string{"groups"}, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	for id := range wanted {
+		user, err := s.getObjectUser(id)
+		if err != nil {
+			return newError(http.StatusBadRequest, "invalidValue", "the member: %s is not a user of the organization", id)
+		}
+
+		user.Groups = append(user.Groups, groupId)
+		_, err = object.UpdateUser(user.GetId(), user, []string{"groups"}, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *session) createGroup(body map[string]interface{}) (map[string]interface{}, error) {
+	displayName := strings.TrimSpace(getString(body, "displayName"))
+	if displayName == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "the attribute: displayName is required")
+	}
+	if strings.Contains(displayName, "/") {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "the displayName: %s can't contain \"/\"", displayName)
+	}
+
+	existed, err := object.GetGroup(util.GetId(s.organization.Name, displayName))
+	if err != nil {
+		return nil, err
+	}
+	if existed != nil {
+		return nil, newError(http.StatusConflict, "uniqueness", "the group: %s already exists", displayName)
+	}
+
+	group := &object.Group{
+		Owner:       s.organization.Name,
+		Name:        displayName,
+		CreatedTime: util.GetCurrentTime(),
+		UpdatedTime: util.GetCurrentTime(),
+		DisplayName: displayName,
+		Type:        "Virtual",
+		IsTopGroup:  true,
+		IsEnabled:   true,
+	}
+	_, err = object.AddGroup(group)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "%s", err.Error())
+	}
+
+	err = s.setGroupMembers(group, getMemberIds(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getGroup(group.Name)
+}
+
+func (s *session) updateGroup(id string, body map[string]interface{}) (map[string]interface{}, error) {
+	group, err := s.getObjectGroup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the name is the SCIM id, so a new displayName doesn't rename the group
+	displayName := strings.TrimSpace(getString(body, "displayName"))
+	if displayName == "" {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "the attribute: displayName is required")
+	}
+
+	group.DisplayName = displayName
+	group.UpdatedTime = util.GetCurrentTime()
+	_, err = object.UpdateGroup(group.GetId(), group)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setGroupMembers(group, getMemberIds(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getGroup(id)
+}
+
+func (s *session) deleteGroup(id string) error {
+	group, err := s.getObjectGroup(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.setGroupMembers(group, []string{})
+	if err != nil {
+		return err
+	}
+
+	_, err = object.DeleteGroup(group)
+	if err != nil {
+		return newError(http.StatusBadRequest, "mutability", "%s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type PatchRequest struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+type patchPath struct {
+	urn    string
+	attr   string
+	filter Filter
+	sub    string
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	res := &patchPath{}
+
+	// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department"
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		bracket := strings.Index(path, "[")
+		head := path
+		if bracket != -1 {
+			head = path[:bracket]
+		}
+		i := strings.LastIndex(head, ":")
+		res.urn = path[:i]
+		path = path[i+1:]
+		if strings.EqualFold(res.urn, UserSchema) || strings.EqualFold(res.urn, GroupSchema) {
+			res.urn = ""
+		}
+	}
+
+	if i := strings.Index(path, "["); i != -1 {
+		j := strings.LastIndex(path, "]")
+		if j < i {
+			return nil, fmt.Errorf("invalid path: %s", path)
+		}
+
+		filter, err := ParseFilter(path[i+1 : j])
+		if err != nil {
+			return nil, err
+		}
+
+		res.attr = path[:i]
+		res.filter = filter
+		res.sub = strings.TrimPrefix(path[j+1:], ".")
+		return res, nil
+	}
+
+	tokens := strings.SplitN(path, ".", 2)
+	res.attr = tokens[0]
+	if len(tokens) == 2 {
+		res.sub = tokens[1]
+	}
+	return res, nil
+}
+
+func findKey(m map[string]interface{}, key string) string {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return key
+}
+
+// ApplyPatch applies the operations of a PATCH request to the SCIM representation of a resource
+func ApplyPatch(resource map[string]interface{}, operations []*PatchOperation) error {
+	for _, operation := range operations {
+		err := applyPatchOperation(resource, strings.ToLower(operation.Op), operation.Path, operation.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPatchOperation(resource map[string]interface{}, op string, path string, value interface{}) error {
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("unknown patch operation: %s", op)
+	}
+
+	if path == "" {
+		if op == "remove" {
+			return fmt.Errorf("the path is required for the remove operation")
+		}
+
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("the value must be an object when the path is empty")
+		}
+		for key, v := range values {
+			if extension, ok := v.(map[string]interface{}); ok && strings.HasPrefix(strings.ToLower(key), "urn:") {
+				for subKey, subValue := range extension {
+					err := applyPatchOperation(resource, op, key+":"+subKey, subValue)
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			err := applyPatchOperation(resource, op, key, v)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	p, err := parsePatchPath(path)
+	if err != nil {
+		return err
+	}
+
+	container := resource
+	if p.urn != "" {
+		key := findKey(resource, p.urn)
+		extension, ok := resource[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			extension = map[string]interface{}{}
+			resource[key] = extension
+		}
+		container = extension
+	}
+
+	key := findKey(container, p.attr)
+	if p.filter != nil {
+		return patchMultiValued(container, key, p, op, value)
+	}
+
+	if p.sub != "" {
+		complexValue, ok := container[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			complexValue = map[string]interface{}{}
+			container[key] = complexValue
+		}
+
+		subKey := findKey(complexValue, p.sub)
+		if op == "remove" {
+			delete(complexValue, subKey)
+		} else {
+			complexValue[subKey] = value
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		delete(container, key)
+	case "replace":
+		container[key] = value
+	case "add":
+		existing, ok := container[key]
+		if !ok || existing == nil {
+			container[key] = value
+			return nil
+		}
+
+		switch existingValue := existing.(type) {
+		case []interface{}:
+			container[key] = appendUnique(existingValue, toList(value))
+		case map[string]interface{}:
+			if m, ok := value.(map[string]interface{}); ok {
+				for k, v := range m {
+					existingValue[findKey(existingValue, k)] = v
+				}
+			} else {
+				container[key] = value
+			}
+		default:
+			container[key] = value
+		}
+	}
+	return nil
+}
+
+func patchMultiValued(container map[string]interface{}, key string, p *patchPath, op string, value interface{}) error {
+	items := toList(container[key])
+	res := []interface{}{}
+	matched := false
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok || !p.filter.Match(m) {
+			res = append(res, item)
+			continue
+		}
+
+		matched = true
+		if p.sub != "" {
+			if op == "remove" {
+				delete(m, findKey(m, p.sub))
+			} else {
+				m[findKey(m, p.sub)] = value
+			}
+			res = append(res, m)
+			continue
+		}
+
+		switch op {
+		case "remove":
+		case "replace":
+			res = append(res, value)
+		case "add":
+			if v, ok := value.(map[string]interface{}); ok {
+				for k, subValue := range v {
+					m[findKey(m, k)] = subValue
+				}
+			}
+			res = append(res, m)
+		}
+	}
+
+	if !matched && op != "remove" {
+		return fmt.Errorf("no value matches the path filter of the attribute: %s", p.attr)
+	}
+
+	container[key] = res
+	return nil
+}
+
+// appendUnique appends the values to a multi-valued attribute, skipping the ones whose "value" is already present
+func appendUnique(items []interface{}, values []interface{}) []interface{} {
+	for _, value := range values {
+		duplicated := false
+		if m, ok := value.(map[string]interface{}); ok {
+			for _, item := range items {
+				if existing, ok := item.(map[string]interface{}); ok && existing["value"] != nil && existing["value"] == m["value"] {
+					duplicated = true
+					break
+				}
+			}
+		}
+		if !duplicated {
+			items = append(items, value)
+		}
+	}
+	return items
+}
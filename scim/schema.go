@@ -0,0 +1,171 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+const (
+	UserSchema                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	EnterpriseUserSchema        = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	GroupSchema                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ListResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ErrorSchema                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+	PatchOpSchema               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	BulkRequestSchema           = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	BulkResponseSchema          = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	ServiceProviderConfigSchema = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ResourceTypeSchema          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+)
+
+const (
+	maxBulkOperations = 1000
+	maxBulkPayload    = 1 << 20
+	maxPageSize       = 1000
+)
+
+func newAttribute(name string, attributeType string, multiValued bool, required bool, mutability string, subAttributes ...map[string]interface{}) map[string]interface{} {
+	attribute := map[string]interface{}{
+		"name":        name,
+		"type":        attributeType,
+		"multiValued": multiValued,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    "default",
+		"uniqueness":  "none",
+	}
+	if name == "password" {
+		attribute["returned"] = "never"
+	}
+	if len(subAttributes) != 0 {
+		attribute["subAttributes"] = subAttributes
+	}
+	return attribute
+}
+
+func newMultiValuedAttribute(name string, mutability string) map[string]interface{} {
+	return newAttribute(name, "complex", true, false, mutability,
+		newAttribute("value", "string", false, false, mutability),
+		newAttribute("display", "string", false, false, mutability),
+		newAttribute("type", "string", false, false, mutability),
+		newAttribute("primary", "boolean", false, false, mutability),
+	)
+}
+
+func getServiceProviderConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":          []string{ServiceProviderConfigSchema},
+		"documentationUri": "https://casdoor.org/docs/",
+		"patch":            map[string]interface{}{"supported": true},
+		"bulk":             map[string]interface{}{"supported": true, "maxOperations": maxBulkOperations, "maxPayloadSize": maxBulkPayload},
+		"filter":           map[string]interface{}{"supported": true, "maxResults": maxPageSize},
+		"changePassword":   map[string]interface{}{"supported": true},
+		"sort":             map[string]interface{}{"supported": false},
+		"etag":             map[string]interface{}{"supported": true},
+		"authenticationSchemes": []interface{}{
+			map[string]interface{}{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with the SCIM token of the organization",
+				"primary":     true,
+			},
+		},
+		"meta": map[string]interface{}{"resourceType": "ServiceProviderConfig"},
+	}
+}
+
+func getResourceTypes(baseUrl string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"schemas":          []string{ResourceTypeSchema},
+			"id":               "User",
+			"name":             "User",
+			"endpoint":         "/Users",
+			"schema":           UserSchema,
+			"schemaExtensions": []interface{}{map[string]interface{}{"schema": EnterpriseUserSchema, "required": false}},
+			"meta":             map[string]interface{}{"resourceType": "ResourceType", "location": baseUrl + "/ResourceTypes/User"},
+		},
+		map[string]interface{}{
+			"schemas":  []string{ResourceTypeSchema},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   GroupSchema,
+			"meta":     map[string]interface{}{"resourceType": "ResourceType", "location": baseUrl + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+func getSchemas(baseUrl string) []interface{} {
+	userAttributes := []interface{}{
+		newAttribute("userName", "string", false, true, "readWrite"),
+		newAttribute("name", "complex", false, false, "readWrite",
+			newAttribute("formatted", "string", false, false, "readWrite"),
+			newAttribute("familyName", "string", false, false, "readWrite"),
+			newAttribute("givenName", "string", false, false, "readWrite"),
+		),
+		newAttribute("displayName", "string", false, false, "readWrite"),
+		newAttribute("profileUrl", "reference", false, false, "readWrite"),
+		newAttribute("title", "string", false, false, "readWrite"),
+		newAttribute("userType", "string", false, false, "readWrite"),
+		newAttribute("preferredLanguage", "string", false, false, "readWrite"),
+		newAttribute("locale", "string", false, false, "readWrite"),
+		newAttribute("active", "boolean", false, false, "readWrite"),
+		newAttribute("password", "string", false, false, "writeOnly"),
+		newMultiValuedAttribute("emails", "readWrite"),
+		newMultiValuedAttribute("phoneNumbers", "readWrite"),
+		newMultiValuedAttribute("photos", "readWrite"),
+		newAttribute("addresses", "complex", true, false, "readWrite",
+			newAttribute("formatted", "string", false, false, "readWrite"),
+			newAttribute("locality", "string", false, false, "readWrite"),
+			newAttribute("region", "string", false, false, "readWrite"),
+			newAttribute("country", "string", false, false, "readWrite"),
+			newAttribute("type", "string", false, false, "readWrite"),
+		),
+		newMultiValuedAttribute("groups", "readOnly"),
+	}
+
+	enterpriseAttributes := []interface{}{
+		newAttribute("employeeNumber", "string", false, false, "readWrite"),
+		newAttribute("costCenter", "string", false, false, "readWrite"),
+		newAttribute("organization", "string", false, false, "readWrite"),
+		newAttribute("division", "string", false, false, "readWrite"),
+		newAttribute("department", "string", false, false, "readWrite"),
+		newAttribute("manager", "complex", false, false, "readWrite",
+			newAttribute("value", "string", false, false, "readWrite"),
+			newAttribute("displayName", "string", false, false, "readOnly"),
+		),
+	}
+
+	groupAttributes := []interface{}{
+		newAttribute("displayName", "string", false, true, "readWrite"),
+		newMultiValuedAttribute("members", "readWrite"),
+	}
+
+	newSchema := func(id string, name string, attributes []interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"schemas":    []string{SchemaSchema},
+			"id":         id,
+			"name":       name,
+			"attributes": attributes,
+			"meta":       map[string]interface{}{"resourceType": "Schema", "location": baseUrl + "/Schemas/" + id},
+		}
+	}
+
+	return []interface{}{
+		newSchema(UserSchema, "User", userAttributes),
+		newSchema(EnterpriseUserSchema, "EnterpriseUser", enterpriseAttributes),
+		newSchema(GroupSchema, "Group", groupAttributes),
+	}
+}
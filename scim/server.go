@@ -0,0 +1,464 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+const BasePath = "/scim/v2"
+
+// Server serves the SCIM 2.0 protocol (RFC 7643, RFC 7644) for the organization that owns the bearer token
+var Server http.Handler = http.HandlerFunc(serveHTTP)
+
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+func newError(status int, scimType string, format string, a ...interface{}) *Error {
+	return &Error{Status: status, ScimType: scimType, Detail: fmt.Sprintf(format, a...)}
+}
+
+func (e *Error) toResponse() map[string]interface{} {
+	res := map[string]interface{}{
+		"schemas": []string{ErrorSchema},
+		"status":  strconv.Itoa(e.Status),
+		"detail":  e.Detail,
+	}
+	if e.ScimType != "" {
+		res["scimType"] = e.ScimType
+	}
+	return res
+}
+
+func toError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return newError(http.StatusInternalServerError, "", "%s", err.Error())
+}
+
+// session is one authenticated SCIM request, scoped to a single organization
+type session struct {
+	organization *object.Organization
+	baseUrl      string
+}
+
+func getBaseUrl(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, BasePath)
+}
+
+func getBearerToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len("Bearer ") && strings.EqualFold(authorization[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(authorization[len("Bearer "):])
+	}
+	return ""
+}
+
+func writeJson(w http.ResponseWriter, status int, version string, body interface{}) {
+	w.Header().Set("Content-Type", "application/scim+json")
+	if version != "" {
+		w.Header().Set("ETag", version)
+	}
+	w.WriteHeader(status)
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e := toError(err)
+	writeJson(w, e.Status, "", e.toResponse())
+}
+
+// getVersion is the weak ETag of a resource, computed from its representation without the meta attribute
+func getVersion(resource map[string]interface{}) string {
+	content := map[string]interface{}{}
+	for k, v := range resource {
+		if k != "meta" {
+			content[k] = v
+		}
+	}
+
+	bytes, _ := json.Marshal(content)
+	hash := sha256.Sum256(bytes)
+	return fmt.Sprintf("W/\"%s\"", hex.EncodeToString(hash[:8]))
+}
+
+func setVersion(resource map[string]interface{}) string {
+	version := getVersion(resource)
+	if meta, ok := resource["meta"].(map[string]interface{}); ok {
+		meta["version"] = version
+	}
+	return version
+}
+
+func checkPrecondition(resource map[string]interface{}, ifMatch string) error {
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+
+	version := getVersion(resource)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == version {
+			return nil
+		}
+	}
+	return newError(http.StatusPreconditionFailed, "", "the resource has been modified, current version is %s", version)
+}
+
+func readJson(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBulkPayload+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxBulkPayload {
+		return newError(http.StatusRequestEntityTooLarge, "", "the request body is larger than %d bytes", maxBulkPayload)
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return newError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error())
+	}
+	return nil
+}
+
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type SearchRequest struct {
+	Schemas    []string `json:"schemas"`
+	Filter     string   `json:"filter"`
+	StartIndex int      `json:"startIndex"`
+	Count      int      `json:"count"`
+	SortBy     string   `json:"sortBy"`
+	SortOrder  string   `json:"sortOrder"`
+}
+
+func getSearchRequest(r *http.Request) *SearchRequest {
+	query := r.URL.Query()
+	startIndex, _ := strconv.Atoi(query.Get("startIndex"))
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil {
+		count = -1
+	}
+
+	return &SearchRequest{
+		Filter:     query.Get("filter"),
+		StartIndex: startIndex,
+		Count:      count,
+		SortBy:     query.Get("sortBy"),
+		SortOrder:  query.Get("sortOrder"),
+	}
+}
+
+// getPage returns the 1-based start index and the count of the resources to return, at most maxPageSize
+func getPage(searchRequest *SearchRequest) (int, int) {
+	startIndex := searchRequest.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := searchRequest.Count
+	if count < 0 || count > maxPageSize {
+		count = maxPageSize
+	}
+	return startIndex, count
+}
+
+// newListResponse filters, sorts and paginates the resources, startIndex is 1-based
+func newListResponse(resources []map[string]interface{}, searchRequest *SearchRequest) (*ListResponse, error) {
+	if searchRequest.Filter != "" {
+		filter, err := ParseFilter(searchRequest.Filter)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidFilter", "%s", err.Error())
+		}
+
+		matched := []map[string]interface{}{}
+		for _, resource := range resources {
+			if filter.Match(resource) {
+				matched = append(matched, resource)
+			}
+		}
+		resources = matched
+	}
+
+	if searchRequest.SortBy != "" {
+		descending := strings.EqualFold(searchRequest.SortOrder, "descending")
+		sort.SliceStable(resources, func(i, j int) bool {
+			a := fmt.Sprintf("%v", getAttribute(resources[i], searchRequest.SortBy))
+			b := fmt.Sprintf("%v", getAttribute(resources[j], searchRequest.SortBy))
+			if descending {
+				return a > b
+			}
+			return a < b
+		})
+	}
+
+	startIndex, count := getPage(searchRequest)
+	res := &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	for i := startIndex - 1; i < len(resources) && len(res.Resources) < count; i++ {
+		res.Resources = append(res.Resources, resources[i])
+	}
+	res.ItemsPerPage = len(res.Resources)
+	return res, nil
+}
+
+func serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, BasePath) {
+		writeError(w, newError(http.StatusNotFound, "", "the path: %s is not found", r.URL.Path))
+		return
+	}
+
+	organization, err := object.GetOrganizationByScimToken(getBearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if organization == nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, newError(http.StatusUnauthorized, "", "the bearer token is invalid"))
+		return
+	}
+
+	s := &session{organization: organization, baseUrl: getBaseUrl(r)}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, BasePath), "/")
+	tokens := strings.SplitN(path, "/", 2)
+	resourceType := tokens[0]
+	id := ""
+	if len(tokens) == 2 {
+		id = tokens[1]
+	}
+
+	switch {
+	case resourceType == "ServiceProviderConfig" && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, "", getServiceProviderConfig())
+	case resourceType == "ResourceTypes" && r.Method == http.MethodGet:
+		serveStatic(w, getResourceTypes(s.baseUrl), id)
+	case resourceType == "Schemas" && r.Method == http.MethodGet:
+		serveStatic(w, getSchemas(s.baseUrl), id)
+	case resourceType == "Bulk" && r.Method == http.MethodPost:
+		s.serveBulk(w, r)
+	case resourceType == "Users" || resourceType == "Groups":
+		s.serveResource(w, r, resourceType, id)
+	default:
+		writeError(w, newError(http.StatusNotFound, "", "the endpoint: %s %s is not found", r.Method, r.URL.Path))
+	}
+}
+
+func serveStatic(w http.ResponseWriter, resources []interface{}, id string) {
+	if id == "" {
+		res := &ListResponse{
+			Schemas:      []string{ListResponseSchema},
+			TotalResults: len(resources),
+			StartIndex:   1,
+			ItemsPerPage: len(resources),
+			Resources:    resources,
+		}
+		writeJson(w, http.StatusOK, "", res)
+		return
+	}
+
+	for _, resource := range resources {
+		if resource.(map[string]interface{})["id"] == id {
+			writeJson(w, http.StatusOK, "", resource)
+			return
+		}
+	}
+	writeError(w, newError(http.StatusNotFound, "", "the resource: %s is not found", id))
+}
+
+func (s *session) serveResource(w http.ResponseWriter, r *http.Request, resourceType string, id string) {
+	ifMatch := r.Header.Get("If-Match")
+
+	if id == ".search" && r.Method == http.MethodPost {
+		var searchRequest SearchRequest
+		if err := readJson(r, &searchRequest); err != nil {
+			writeError(w, err)
+			return
+		}
+		if searchRequest.Count == 0 {
+			searchRequest.Count = -1
+		}
+		s.serveList(w, resourceType, &searchRequest)
+		return
+	}
+
+	var resource map[string]interface{}
+	var err error
+	status := http.StatusOK
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.serveList(w, resourceType, getSearchRequest(r))
+		return
+	case id == "" && r.Method == http.MethodPost:
+		var body map[string]interface{}
+		if err = readJson(r, &body); err == nil {
+			resource, err = s.create(resourceType, body)
+			status = http.StatusCreated
+		}
+	case id != "" && r.Method == http.MethodGet:
+		resource, err = s.get(resourceType, id)
+		if err == nil && r.Header.Get("If-None-Match") != "" && r.Header.Get("If-None-Match") == getVersion(resource) {
+			writeJson(w, http.StatusNotModified, getVersion(resource), nil)
+			return
+		}
+	case id != "" && r.Method == http.MethodPut:
+		var body map[string]interface{}
+		if err = readJson(r, &body); err == nil {
+			resource, err = s.replace(resourceType, id, body, ifMatch)
+		}
+	case id != "" && r.Method == http.MethodPatch:
+		var patchRequest PatchRequest
+		if err = readJson(r, &patchRequest); err == nil {
+			resource, err = s.patch(resourceType, id, &patchRequest, ifMatch)
+		}
+	case id != "" && r.Method == http.MethodDelete:
+		err = s.delete(resourceType, id, ifMatch)
+		if err == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	default:
+		err = newError(http.StatusMethodNotAllowed, "", "the method: %s is not allowed", r.Method)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if status == http.StatusCreated {
+		w.Header().Set("Location", resource["meta"].(map[string]interface{})["location"].(string))
+	}
+	writeJson(w, status, setVersion(resource), resource)
+}
+
+func (s *session) serveList(w http.ResponseWriter, resourceType string, searchRequest *SearchRequest) {
+	var res *ListResponse
+	var err error
+	if resourceType == "Users" {
+		res, err = s.listUsers(searchRequest)
+	} else {
+		var resources []map[string]interface{}
+		resources, err = s.getGroups()
+		if err == nil {
+			res, err = newListResponse(resources, searchRequest)
+		}
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	for _, resource := range res.Resources {
+		setVersion(resource.(map[string]interface{}))
+	}
+	writeJson(w, http.StatusOK, "", res)
+}
+
+func (s *session) get(resourceType string, id string) (map[string]interface{}, error) {
+	if resourceType == "Users" {
+		return s.getUser(id)
+	}
+	return s.getGroup(id)
+}
+
+func (s *session) create(resourceType string, body map[string]interface{}) (map[string]interface{}, error) {
+	if resourceType == "Users" {
+		return s.createUser(body)
+	}
+	return s.createGroup(body)
+}
+
+func (s *session) replace(resourceType string, id string, body map[string]interface{}, ifMatch string) (map[string]interface{}, error) {
+	resource, err := s.get(resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkPrecondition(resource, ifMatch); err != nil {
+		return nil, err
+	}
+
+	if resourceType == "Users" {
+		return s.updateUser(id, body)
+	}
+	return s.updateGroup(id, body)
+}
+
+func (s *session) patch(resourceType string, id string, patchRequest *PatchRequest, ifMatch string) (map[string]interface{}, error) {
+	resource, err := s.get(resourceType, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkPrecondition(resource, ifMatch); err != nil {
+		return nil, err
+	}
+
+	err = ApplyPatch(resource, patchRequest.Operations)
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, "invalidPath", "%s", err.Error())
+	}
+
+	if resourceType == "Users" {
+		return s.updateUser(id, resource)
+	}
+	return s.updateGroup(id, resource)
+}
+
+func (s *session) delete(resourceType string, id string, ifMatch string) error {
+	resource, err := s.get(resourceType, id)
+	if err != nil {
+		return err
+	}
+	if err = checkPrecondition(resource, ifMatch); err != nil {
+		return err
+	}
+
+	if resourceType == "Users" {
+		return s.deleteUser(id)
+	}
+	return s.deleteGroup(id)
+}
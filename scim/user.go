@@ -0,0 +1,446 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// the enterprise attributes and the external id that have no User column are kept in the user properties
+const (
+	propertyExternalId     = "scimExternalId"
+	propertyEmployeeNumber = "employeeNumber"
+	propertyCostCenter     = "costCenter"
+	propertyDivision       = "division"
+	propertyDepartment     = "department"
+	propertyManager        = "manager"
+)
+
+var enterpriseProperties = map[string]string{
+	"employeeNumber": propertyEmployeeNumber,
+	"costCenter":     propertyCostCenter,
+	"division":       propertyDivision,
+	"department":     propertyDepartment,
+}
+
+// the columns written by SCIM, the password is set separately so that it is hashed
+var userColumns = []string{
+	"display_name", "first_name", "last_name", "homepage", "title", "language", "region", "is_forbidden",
+	"avatar", "location", "address", "affiliation", "properties", "updated_time",
+}
+
+func getString(resource map[string]interface{}, path string) string {
+	switch v := getAttribute(resource, path).(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// getPrimaryValue returns the value of the primary item of a multi-valued attribute, or of the first item
+func getPrimaryValue(resource map[string]interface{}, attr string) string {
+	items := toList(getAttribute(resource, attr))
+	res := ""
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		value := getString(m, "value")
+		if primary, _ := getAttribute(m, "primary").(bool); primary {
+			return value
+		}
+		if i == 0 {
+			res = value
+		}
+	}
+	return res
+}
+
+func newMultiValue(value string, valueType string) []interface{} {
+	if value == "" {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{"value": value, "type": valueType, "primary": true}}
+}
+
+func (s *session) userToScim(user *object.User) map[string]interface{} {
+	groups := []interface{}{}
+	for _, groupId := range user.Groups {
+		_, groupName := util.GetOwnerAndNameFromIdNoCheck(groupId)
+		groups = append(groups, map[string]interface{}{
+			"value":   groupName,
+			"display": groupName,
+			"$ref":    fmt.Sprintf("%s/Groups/%s", s.baseUrl, groupName),
+		})
+	}
+
+	addresses := []interface{}{}
+	if len(user.Address) != 0 || user.Location != "" {
+		addresses = append(addresses, map[string]interface{}{
+			"type":      "work",
+			"formatted": strings.Join(user.Address, "\n"),
+			"locality":  user.Location,
+			"primary":   true,
+		})
+	}
+
+	enterprise := map[string]interface{}{
+		"organization": user.Affiliation,
+	}
+	for attr, property := range enterpriseProperties {
+		enterprise[attr] = user.Properties[property]
+	}
+	if user.Properties[propertyManager] != "" {
+		enterprise["manager"] = map[string]interface{}{"value": user.Properties[propertyManager]}
+	}
+
+	lastModified := user.UpdatedTime
+	if lastModified == "" {
+		lastModified = user.CreatedTime
+	}
+
+	resource := map[string]interface{}{
+		"schemas":  []interface{}{UserSchema, EnterpriseUserSchema},
+		"id":       user.Id,
+		"userName": user.Name,
+		"name": map[string]interface{}{
+			"formatted":  user.DisplayName,
+			"givenName":  user.FirstName,
+			"familyName": user.LastName,
+		},
+		"displayName":        user.DisplayName,
+		"profileUrl":         user.Homepage,
+		"title":              user.Title,
+		"userType":           user.Type,
+		"preferredLanguage":  user.Language,
+		"locale":             user.Region,
+		"active":             !user.IsForbidden,
+		"emails":             newMultiValue(user.Email, "work"),
+		"phoneNumbers":       newMultiValue(user.Phone, "work"),
+		"photos":             newMultiValue(user.Avatar, "photo"),
+		"addresses":          addresses,
+		"groups":             groups,
+		EnterpriseUserSchema: enterprise,
+		"meta": map[string]interface{}{
+			"resourceType": "User",
+			"created":      user.CreatedTime,
+			"lastModified": lastModified,
+			"location":     fmt.Sprintf("%s/Users/%s", s.baseUrl, user.Id),
+		},
+	}
+	if externalId := user.Properties[propertyExternalId]; externalId != "" {
+		resource["externalId"] = externalId
+	}
+	return resource
+}
+
+// scimToUser writes the SCIM representation onto the user, attributes that are absent are cleared as PUT replaces the whole resource
+func scimToUser(resource map[string]interface{}, user *object.User) error {
+	user.Name = getString(resource, "userName")
+	if user.Name == "" {
+		return newError(http.StatusBadRequest, "invalidValue", "the attribute: userName is required")
+	}
+	if strings.Contains(user.Name, "/") {
+		return newError(http.StatusBadRequest, "invalidValue", "the userName: %s can't contain \"/\"", user.Name)
+	}
+
+	user.DisplayName = getString(resource, "displayName")
+	if user.DisplayName == "" {
+		user.DisplayName = getString(resource, "name.formatted")
+	}
+	user.FirstName = getString(resource, "name.givenName")
+	user.LastName = getString(resource, "name.familyName")
+	if user.DisplayName == "" {
+		user.DisplayName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	user.Homepage = getString(resource, "profileUrl")
+	user.Title = getString(resource, "title")
+	if userType := getString(resource, "userType"); userType != "" {
+		user.Type = userType
+	}
+	user.Language = getString(resource, "preferredLanguage")
+	user.Region = getString(resource, "locale")
+
+	user.IsForbidden = false
+	if active, ok := getAttribute(resource, "active").(bool); ok {
+		user.IsForbidden = !active
+	}
+
+	user.Email = getPrimaryValue(resource, "emails")
+	user.Phone = getPrimaryValue(resource, "phoneNumbers")
+	if avatar := getPrimaryValue(resource, "photos"); avatar != "" {
+		user.Avatar = avatar
+	}
+
+	user.Address = []string{}
+	user.Location = ""
+	for _, item := range toList(getAttribute(resource, "addresses")) {
+		if m, ok := item.(map[string]interface{}); ok {
+			if formatted := getString(m, "formatted"); formatted != "" {
+				user.Address = strings.Split(formatted, "\n")
+			}
+			user.Location = getString(m, "locality")
+			break
+		}
+	}
+
+	if user.Properties == nil {
+		user.Properties = map[string]string{}
+	}
+	setProperty := func(key string, value string) {
+		if value == "" {
+			delete(user.Properties, key)
+		} else {
+			user.Properties[key] = value
+		}
+	}
+	setProperty(propertyExternalId, getString(resource, "externalId"))
+	user.Affiliation = getString(resource, EnterpriseUserSchema+":organization")
+	for attr, property := range enterpriseProperties {
+		setProperty(property, getString(resource, EnterpriseUserSchema+":"+attr))
+	}
+	setProperty(propertyManager, getString(resource, EnterpriseUserSchema+":manager.value"))
+
+	if password, ok := getAttribute(resource, "password").(string); ok {
+		user.Password = password
+	} else {
+		user.Password = ""
+	}
+	return nil
+}
+
+func (s *session) getObjectUser(id string) (*object.User, error) {
+	user, err := object.GetUserByUserId(s.organization.Name, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsDeleted {
+		return nil, newError(http.StatusNotFound, "", "the user: %s is not found", id)
+	}
+	return user, nil
+}
+
+// addUserFilter adds the conditions of the filter that the database can compare to userFilter, they are the "eq"
+// comparisons of userName, emails and externalId joined by "and". It returns false if the database doesn't compare
+// the filter exactly like Match(), then the users of userFilter are filtered again in memory
+func addUserFilter(userFilter *object.UserFilter, filter Filter) bool {
+	switch f := filter.(type) {
+	case *logicalFilter:
+		if f.op != "and" {
+			return false
+		}
+		isLeftExact := addUserFilter(userFilter, f.left)
+		isRightExact := addUserFilter(userFilter, f.right)
+		return isLeftExact && isRightExact
+	case *attributeFilter:
+		value, ok := f.value.(string)
+		if !ok || f.op != "eq" {
+			return false
+		}
+		// the timestamps are compared chronologically by Match()
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimPrefix(f.path, UserSchema+":")) {
+		case "username":
+			if userFilter.Name == "" {
+				userFilter.Name = value
+				return true
+			}
+		case "emails", "emails.value":
+			if userFilter.Email == "" {
+				userFilter.Email = value
+				return true
+			}
+		case "externalid":
+			userFilter.Properties[propertyExternalId] = value
+		}
+	}
+	return false
+}
+
+// listUsers filters, sorts and paginates the users in the database when it can compare the whole filter, the other
+// filters are only narrowed by the database and applied by newListResponse()
+func (s *session) listUsers(searchRequest *SearchRequest) (*ListResponse, error) {
+	userFilter := &object.UserFilter{Properties: map[string]string{}}
+	isExact := true
+	if searchRequest.Filter != "" {
+		filter, err := ParseFilter(searchRequest.Filter)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, "invalidFilter", "%s", err.Error())
+		}
+		isExact = addUserFilter(userFilter, filter)
+	}
+
+	isUserNameSort := strings.EqualFold(strings.TrimPrefix(searchRequest.SortBy, UserSchema+":"), "userName")
+	if !isExact || (searchRequest.SortBy != "" && !isUserNameSort) {
+		users, err := object.GetPaginationFilteredUsers(s.organization.Name, userFilter, 0, -1, "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		resources := []map[string]interface{}{}
+		for _, user := range users {
+			resources = append(resources, s.userToScim(user))
+		}
+		return newListResponse(resources, searchRequest)
+	}
+
+	count, err := object.GetFilteredUserCount(s.organization.Name, userFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	startIndex, limit := getPage(searchRequest)
+	res := &ListResponse{
+		Schemas:      []string{ListResponseSchema},
+		TotalResults: int(count),
+		StartIndex:   startIndex,
+		Resources:    []interface{}{},
+	}
+	if limit == 0 || int64(startIndex) > count {
+		return res, nil
+	}
+
+	sortField, sortOrder := "", ""
+	if isUserNameSort {
+		sortField, sortOrder = "name", "ascend"
+		if strings.EqualFold(searchRequest.SortOrder, "descending") {
+			sortOrder = "descend"
+		}
+	}
+	users, err := object.GetPaginationFilteredUsers(s.organization.Name, userFilter, startIndex-1, limit, sortField, sortOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		res.Resources = append(res.Resources, s.userToScim(user))
+	}
+	res.ItemsPerPage = len(res.Resources)
+	return res, nil
+}
+
+func (s *session) getUser(id string) (map[string]interface{}, error) {
+	user, err := s.getObjectUser(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userToScim(user), nil
+}
+
+func (s *session) createUser(body map[string]interface{}) (map[string]interface{}, error) {
+	user := &object.User{
+		Owner:             s.organization.Name,
+		CreatedTime:       util.GetCurrentTime(),
+		Id:                util.GenerateId(),
+		Type:              "normal-user",
+		SignupApplication: s.organization.DefaultApplication,
+		Properties:        map[string]string{},
+	}
+	err := scimToUser(body, user)
+	if err != nil {
+		return nil, err
+	}
+
+	existed, err := object.GetUser(user.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if existed != nil {
+		return nil, newError(http.StatusConflict, "uniqueness", "the userName: %s already exists", user.Name)
+	}
+
+	affected, err := object.AddUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, newError(http.StatusBadRequest, "invalidValue", "failed to add the user: %s", user.Name)
+	}
+
+	return s.getUser(user.Id)
+}
+
+func (s *session) updateUser(id string, body map[string]interface{}) (map[string]interface{}, error) {
+	user, err := s.getObjectUser(id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldId := user.GetId()
+	err = scimToUser(body, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.GetId() != oldId {
+		existed, err := object.GetUser(user.GetId())
+		if err != nil {
+			return nil, err
+		}
+		if existed != nil {
+			return nil, newError(http.StatusConflict, "uniqueness", "the userName: %s already exists", user.Name)
+		}
+	}
+
+	password := user.Password
+	user.Password = "***"
+	user.UpdatedTime = util.GetCurrentTime()
+	_, err = object.UpdateUser(oldId, user, userColumns, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if password != "" {
+		user.Password = password
+		_, err = object.SetUserField(user, "password", password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.getUser(id)
+}
+
+func (s *session) deleteUser(id string) error {
+	user, err := s.getObjectUser(id)
+	if err != nil {
+		return err
+	}
+
+	if s.organization.EnableSoftDeletion {
+		user.IsDeleted = true
+		_, err = object.UpdateUser(user.GetId(), user, []string{"is_deleted"}, false)
+	} else {
+		_, err = object.DeleteUser(user)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport creates a client span for every outbound request and propagates the trace context in its headers.
+// The span is the child of the span in the context of the request, or of the span in the parent context for the
+// clients that are given to libraries which don't set the context of their requests
+type Transport struct {
+	Base   http.RoundTripper
+	Parent context.Context
+}
+
+func NewTransport(base http.RoundTripper, parent context.Context) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base, Parent: parent}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !trace.SpanContextFromContext(ctx).IsValid() && t.Parent != nil {
+		ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(t.Parent))
+	}
+
+	ctx, span := Tracer().Start(ctx, fmt.Sprintf("HTTP %s %s", req.Method, req.URL.Host), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPMethodKey.String(req.Method), semconv.HTTPURLKey.String(req.URL.Scheme+"://"+req.URL.Host+req.URL.Path)))
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// WithParent returns a copy of the client whose requests are traced as the children of the span in ctx
+func WithParent(client *http.Client, ctx context.Context) *http.Client {
+	res := *client
+	base := client.Transport
+	if transport, ok := base.(*Transport); ok {
+		base = transport.Base
+	}
+	res.Transport = NewTransport(base, ctx)
+	return &res
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	_ "modernc.org/sqlite"
+)
+
+func initTestTracer() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestTransport(t *testing.T) {
+	recorder := initTestTracer()
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, span := StartSpan(context.Background(), "parent")
+	client := WithParent(&http.Client{}, ctx)
+
+	// the request has no context, the span of the client is the parent
+	resp, err := client.Get(server.URL)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, span.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, traceparent, spans[0].SpanContext().SpanID().String())
+	assert.Equal(t, "Error", spans[0].Status().Code.String())
+	assert.Equal(t, GetTraceId(ctx), span.SpanContext().TraceID().String())
+}
+
+func TestXormHook(t *testing.T) {
+	recorder := initTestTracer()
+
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+	defer engine.Close()
+	engine.AddHook(&XormHook{DbSystem: "sqlite"})
+
+	// the queries without a parent span aren't traced
+	_, err = engine.Exec("CREATE TABLE item (name TEXT)")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(recorder.Ended()))
+
+	ctx, span := StartSpan(context.Background(), "parent")
+	_, err = engine.Context(ctx).Exec("INSERT INTO item (name) VALUES (?)", "item1")
+	assert.Nil(t, err)
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "DB INSERT", spans[0].Name())
+	assert.Equal(t, span.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
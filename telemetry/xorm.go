@@ -0,0 +1,53 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/xorm-io/xorm/contexts"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// XormHook creates a span for every query of the sessions that have a traced context, like
+// ormer.Engine.Context(ctx), the queries without a parent span aren't traced
+type XormHook struct {
+	DbSystem string
+}
+
+func (h *XormHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if c.Ctx == nil || !trace.SpanContextFromContext(c.Ctx).IsValid() {
+		return c.Ctx, nil
+	}
+
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(c.SQL), " ", 2)[0])
+	ctx, _ := Tracer().Start(c.Ctx, "DB "+operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemKey.String(h.DbSystem), semconv.DBStatementKey.String(c.SQL), semconv.DBOperationKey.String(operation)))
+	return ctx, nil
+}
+
+func (h *XormHook) AfterProcess(c *contexts.ContextHook) error {
+	if c.Ctx == nil {
+		return nil
+	}
+
+	span := trace.SpanFromContext(c.Ctx)
+	if span.IsRecording() {
+		EndSpan(span, c.Err)
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/beego/beego/context"
 	"github.com/beego/beego/logs"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func GetIPInfo(clientIP string) string {
@@ -61,12 +62,23 @@ func GetIPFromRequest(req *http.Request) string {
 	return GetIPInfo(clientIP)
 }
 
+// getLogPrefix is the IP address of the request, and the trace ID if the request is traced
+func getLogPrefix(ctx *context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx.Request.Context())
+	if spanContext.IsValid() && spanContext.IsSampled() {
+		return fmt.Sprintf("(%s) [trace_id=%s] ", GetIPFromRequest(ctx.Request), spanContext.TraceID().String())
+	}
+	return fmt.Sprintf("(%s) ", GetIPFromRequest(ctx.Request))
+}
+
+func LogDebug(ctx *context.Context, f string, v ...interface{}) {
+	logs.Debug(getLogPrefix(ctx)+f, v...)
+}
+
 func LogInfo(ctx *context.Context, f string, v ...interface{}) {
-	ipString := fmt.Sprintf("(%s) ", GetIPFromRequest(ctx.Request))
-	logs.Info(ipString+f, v...)
+	logs.Info(getLogPrefix(ctx)+f, v...)
 }
 
 func LogWarning(ctx *context.Context, f string, v ...interface{}) {
-	ipString := fmt.Sprintf("(%s) ", GetIPFromRequest(ctx.Request))
-	logs.Warning(ipString+f, v...)
+	logs.Warning(getLogPrefix(ctx)+f, v...)
 }
@@ -212,6 +212,12 @@ class ApplicationEditPage extends React.Component {
     });
   }
 
+  updateProvisioningField(key, value) {
+    const provisioning = {...this.state.application.provisioning};
+    provisioning[key] = value;
+    this.updateApplicationField("provisioning", provisioning);
+  }
+
   handleUpload(info) {
     if (info.file.type !== "text/html") {
       Setting.showMessage("error", i18next.t("application:Please select a HTML file"));
@@ -457,6 +463,16 @@ class ApplicationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable magic link signin"), i18next.t("application:Enable magic link signin - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.enableMagicLink} onChange={checked => {
+              this.updateApplicationField("enableMagicLink", checked);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("application:Enable WebAuthn signin"), i18next.t("application:Enable WebAuthn signin - Tooltip"))} :
@@ -477,6 +493,52 @@ class ApplicationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable SCIM provisioning"), i18next.t("application:Enable SCIM provisioning - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.provisioning?.enabled} onChange={checked => {
+              this.updateProvisioningField("enabled", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.provisioning?.enabled ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:SCIM endpoint"), i18next.t("application:SCIM endpoint - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input prefix={<LinkOutlined />} value={this.state.application.provisioning.endpoint} onChange={e => {
+                    this.updateProvisioningField("endpoint", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:SCIM token"), i18next.t("application:SCIM token - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input.Password value={this.state.application.provisioning.token} onChange={e => {
+                    this.updateProvisioningField("token", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+                  {Setting.getLabel(i18next.t("application:Sync groups"), i18next.t("application:Sync groups - Tooltip"))} :
+                </Col>
+                <Col span={1} >
+                  <Switch checked={this.state.application.provisioning.syncGroups} onChange={checked => {
+                    this.updateProvisioningField("syncGroups", checked);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("application:Org choice mode"), i18next.t("application:Org choice mode - Tooltip"))} :
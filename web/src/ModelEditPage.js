@@ -142,6 +142,16 @@ class ModelEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("model:ABAC attributes"), i18next.t("model:ABAC attributes - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.model.abacAttributes ?? []} onChange={(value => {this.updateModelField("abacAttributes", value);})}
+              options={["type", "displayName", "affiliation", "title", "tag", "region", "location", "language", "isAdmin", "score", "groups", "roles", "properties", "ip", "time"].map((attribute) => Setting.getOption(attribute, attribute))}
+            />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("model:Model text"), i18next.t("model:Model text - Tooltip"))} :
@@ -490,6 +490,20 @@ class PermissionEditPage extends React.Component {
       });
   }
 
+  submitApprovalRequest() {
+    PermissionBackend.submitApprovalRequest("Permission", `${this.state.permission.owner}/${this.state.permission.name}`, this.state.permission.description)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("permission:Successfully requested approval"));
+        } else {
+          Setting.showMessage("error", `${i18next.t("general:Failed to save")}: ${res.msg}`);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `${i18next.t("general:Failed to connect to server")}: ${error}`);
+      });
+  }
+
   deletePermission() {
     PermissionBackend.deletePermission(this.state.permission)
       .then((res) => {
@@ -514,6 +528,7 @@ class PermissionEditPage extends React.Component {
           <Button size="large" onClick={() => this.submitPermissionEdit(false)}>{i18next.t("general:Save")}</Button>
           <Button style={{marginLeft: "20px"}} type="primary" size="large" onClick={() => this.submitPermissionEdit(true)}>{i18next.t("general:Save & Exit")}</Button>
           {this.state.mode === "add" ? <Button style={{marginLeft: "20px"}} size="large" onClick={() => this.deletePermission()}>{i18next.t("general:Cancel")}</Button> : null}
+          {this.state.permission?.state === "Pending" && this.state.permission?.submitter === this.props.account.name ? <Button style={{marginLeft: "20px"}} size="large" onClick={() => this.submitApprovalRequest()}>{i18next.t("permission:Request approval")}</Button> : null}
         </div>
       </div>
     );
@@ -920,6 +920,26 @@ class ProviderEditPage extends React.Component {
                   }} />
                 </Col>
               </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Magic link title"), i18next.t("provider:Magic link title - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.magicLinkTitle} onChange={e => {
+                    this.updateProviderField("magicLinkTitle", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Magic link content"), i18next.t("provider:Magic link content - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <TextArea autoSize={{minRows: 3, maxRows: 100}} value={this.state.provider.magicLinkContent} placeholder="Click the link to sign in to %application%: %link%, it expires in %timeout% minutes." onChange={e => {
+                    this.updateProviderField("magicLinkContent", e.target.value);
+                  }} />
+                </Col>
+              </Row>
               <Row style={{marginTop: "20px"}} >
                 <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
                   {Setting.getLabel(i18next.t("provider:Test Email"), i18next.t("provider:Test Email - Tooltip"))} :
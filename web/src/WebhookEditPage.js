@@ -13,7 +13,7 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, Row, Select, Switch} from "antd";
+import {Button, Card, Col, Input, InputNumber, Row, Select, Switch} from "antd";
 import {LinkOutlined} from "@ant-design/icons";
 import * as WebhookBackend from "./backend/WebhookBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
@@ -170,6 +170,16 @@ class WebhookEditPage extends React.Component {
     return res;
   }
 
+  getEventTypes() {
+    const res = ["login.succeeded", "login.failed", "signup.succeeded", "signup.failed", "user.password_changed", "user.contact_changed"];
+    ["organization", "group", "user", "application", "provider", "cert", "role", "permission", "model", "adapter", "enforcer", "webhook"].forEach(obj => {
+      ["created", "updated", "deleted"].forEach(verb => {
+        res.push(`${obj}.${verb}`);
+      });
+    });
+    return res;
+  }
+
   renderWebhook() {
     const preview = Setting.deepCopy(previewTemplate);
     if (this.state.webhook.isUserExtended) {
@@ -274,7 +284,7 @@ class WebhookEditPage extends React.Component {
               }} >
               {
                 (
-                  ["signup", "login", "logout"].concat(this.getApiPaths()).map((option, index) => {
+                  ["signup", "login", "logout", "request-assignment", "activate-assignment", "expire-assignment", "revoke-assignment"].concat(this.getEventTypes()).concat(this.getApiPaths()).map((option, index) => {
                     return (
                       <Option key={option} value={option}>{option}</Option>
                     );
@@ -284,6 +294,37 @@ class WebhookEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Filter"), i18next.t("webhook:Filter - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.webhook.filter} placeholder="result == 'error' && 'built-in/admins' IN groups" onChange={e => {
+              this.updateWebhookField("filter", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Preset"), i18next.t("webhook:Preset - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} style={{width: "100%"}} value={this.state.webhook.preset} onChange={value => {
+              this.updateWebhookField("preset", value);
+            }}
+            options={["", "Slack", "Microsoft Teams", "Discord", "Google Chat", "Lark", "DingTalk", "Rocket Chat", "Matrix"].map(item => Setting.getOption(item === "" ? i18next.t("general:None") : item, item))} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Template"), i18next.t("webhook:Template - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input.TextArea rows={4} value={this.state.webhook.template} placeholder={"{\"text\": {{json (message .)}}}"} onChange={e => {
+              this.updateWebhookField("template", e.target.value);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("webhook:Is user extended"), i18next.t("webhook:Is user extended - Tooltip"))} :
@@ -294,6 +335,26 @@ class WebhookEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Secret"), i18next.t("webhook:Secret - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input.Password value={this.state.webhook.secret} onChange={e => {
+              this.updateWebhookField("secret", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Timeout"), i18next.t("webhook:Timeout - Tooltip"))} :
+          </Col>
+          <Col span={4} >
+            <InputNumber min={0} value={this.state.webhook.timeout} onChange={value => {
+              this.updateWebhookField("timeout", value);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:Preview"), i18next.t("general:Preview - Tooltip"))} :
@@ -82,6 +82,17 @@ export function login(values, oAuthParams) {
   }).then(res => res.json());
 }
 
+export function sendMagicLink(values, oAuthParams) {
+  return fetch(`${authConfig.serverUrl}/api/send-magic-link${oAuthParamsToQuery(oAuthParams)}`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(values),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
 export function loginCas(values, params) {
   return fetch(`${authConfig.serverUrl}/api/login?service=${params.service}`, {
     method: "POST",
@@ -208,6 +208,9 @@ class LoginPage extends React.Component {
     if (application?.enableWebAuthn) {
       return "webAuthn";
     }
+    if (application?.enableMagicLink) {
+      return "magicLink";
+    }
 
     return "password";
   }
@@ -313,6 +316,10 @@ class LoginPage extends React.Component {
       this.signInWithWebAuthn(username, values);
       return;
     }
+    if (this.state.loginMethod === "magicLink") {
+      this.sendMagicLink(values);
+      return;
+    }
     if (this.state.loginMethod === "password") {
       if (this.state.enableCaptchaModal === CaptchaRule.Always) {
         this.setState({
@@ -328,6 +335,19 @@ class LoginPage extends React.Component {
     this.login(values);
   }
 
+  sendMagicLink(values) {
+    const oAuthParams = Util.getOAuthGetParameters();
+    this.populateOauthValues(values);
+    AuthBackend.sendMagicLink(values, oAuthParams)
+      .then((res) => {
+        if (res.status === "ok") {
+          Setting.showMessage("success", i18next.t("login:The sign-in link has been sent to your Email, please open it in this browser"));
+        } else {
+          Setting.showMessage("error", `${i18next.t("application:Failed to sign in")}: ${res.msg}`);
+        }
+      });
+  }
+
   login(values) {
     // here we are supposed to determine whether Casdoor is working as an OAuth server or CAS server
     if (this.state.type === "cas") {
@@ -463,7 +483,7 @@ class LoginPage extends React.Component {
       );
     }
 
-    const showForm = application.enablePassword || application.enableCodeSignin || application.enableWebAuthn;
+    const showForm = application.enablePassword || application.enableCodeSignin || application.enableWebAuthn || application.enableMagicLink;
     if (showForm) {
       let loginWidth = 320;
       if (Setting.getLanguage() === "fr") {
@@ -580,7 +600,8 @@ class LoginPage extends React.Component {
             >
               {
                 this.state.loginMethod === "webAuthn" ? i18next.t("login:Sign in with WebAuthn") :
-                  i18next.t("login:Sign In")
+                  this.state.loginMethod === "magicLink" ? i18next.t("login:Send sign-in link") :
+                    i18next.t("login:Sign In")
               }
             </Button>
             {
@@ -846,6 +867,7 @@ class LoginPage extends React.Component {
     application.enablePassword ? items.push({label: i18next.t("general:Password"), key: "password"}) : null;
     application.enableCodeSignin ? items.push({label: i18next.t("login:Verification code"), key: "verificationCode"}) : null;
     application.enableWebAuthn ? items.push({label: i18next.t("login:WebAuthn"), key: "webAuthn"}) : null;
+    application.enableMagicLink ? items.push({label: i18next.t("login:Magic link"), key: "magicLink"}) : null;
 
     if (items.length > 1) {
       return (
@@ -975,7 +997,7 @@ class LoginPage extends React.Component {
     }
 
     const visibleOAuthProviderItems = (application.providers === null) ? [] : application.providers.filter(providerItem => this.isProviderVisible(providerItem));
-    if (this.props.preview !== "auto" && !application.enablePassword && !application.enableCodeSignin && !application.enableWebAuthn && visibleOAuthProviderItems.length === 1) {
+    if (this.props.preview !== "auto" && !application.enablePassword && !application.enableCodeSignin && !application.enableWebAuthn && !application.enableMagicLink && visibleOAuthProviderItems.length === 1) {
       Setting.goToLink(Provider.getAuthUrl(application, visibleOAuthProviderItems[0].provider, "signup"));
       return (
         <div style={{display: "flex", justifyContent: "center", alignItems: "center", width: "100%"}}>
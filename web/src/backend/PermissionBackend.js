@@ -79,3 +79,14 @@ export function deletePermission(permission) {
     },
   }).then(res => res.json());
 }
+
+export function submitApprovalRequest(type, target, reason) {
+  return fetch(`${Setting.ServerUrl}/api/submit-approval-request`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify({type: type, target: target, reason: reason}),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
@@ -14,7 +14,11 @@
 
 package xlsx
 
-import "github.com/tealeg/xlsx"
+import (
+	"bytes"
+
+	"github.com/tealeg/xlsx"
+)
 
 func ReadXlsxFile(path string) [][]string {
 	file, err := xlsx.OpenFile(path)
@@ -37,3 +41,27 @@ func ReadXlsxFile(path string) [][]string {
 
 	return res
 }
+
+// WriteXlsxFile writes the table into a single-sheet xlsx file
+func WriteXlsxFile(sheetName string, table [][]string) ([]byte, error) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet(sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range table {
+		row := sheet.AddRow()
+		for _, text := range line {
+			row.AddCell().SetString(text)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	err = file.Write(buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}